@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+const (
+	// Trials evolve a downscaled target so the search stays quick on large images
+	autotuneMaxDimension = 64
+	// Every trial gets the same number of fitness evaluations (population x generations)
+	autotuneEvaluationBudget = 20000
+	// All trials share a seed so that they differ only in the tuned parameters
+	autotuneSeed int64 = 1
+)
+
+// tuneParams is one combination of the parameters searched by autotune.
+type tuneParams struct {
+	PopulationSize int
+	MutationRate   float64
+}
+
+// tuneTrial is the outcome of a short run with one combination.
+type tuneTrial struct {
+	tuneParams
+	// Improvement is the relative drop in best fitness over the trial
+	Improvement float64
+}
+
+// defaultTuneGrid is the grid searched by -autotune.
+func defaultTuneGrid() []tuneParams {
+	var grid []tuneParams
+	for _, pop := range []int{50, 100, 200} {
+		for _, mut := range []float64{0.02, 0.05, 0.1, 0.2} {
+			grid = append(grid, tuneParams{PopulationSize: pop, MutationRate: mut})
+		}
+	}
+	return grid
+}
+
+// autotune runs a short trial of every combination in grid on a downscaled copy of target,
+// with the same evaluation budget each, and returns all trials along with the one whose
+// best fitness improved the most. targetOptions, if non-nil, returns the options tied to
+// the target's pixels, such as a seed image, for the downscaled target the trials evolve.
+// configure, if non-nil, applies the remaining settings to each trial's algorithm before
+// it runs.
+func autotune(target image.Image, grid []tuneParams, budget, tournamentSize int, opts []genetic.Option, targetOptions func(image.Image) ([]genetic.Option, error), configure func(*genetic.GeneticAlgorithm)) (tuneTrial, []tuneTrial, error) {
+	if len(grid) == 0 {
+		return tuneTrial{}, nil, fmt.Errorf("autotune needs at least one parameter combination")
+	}
+	small := imageio.Resize(target, autotuneMaxDimension)
+	trialOpts := append([]genetic.Option(nil), opts...)
+	if targetOptions != nil {
+		sized, err := targetOptions(small)
+		if err != nil {
+			return tuneTrial{}, nil, err
+		}
+		trialOpts = append(trialOpts, sized...)
+	}
+	trialOpts = append(trialOpts, genetic.WithSeed(autotuneSeed))
+
+	trials := make([]tuneTrial, 0, len(grid))
+	best := -1
+	for _, params := range grid {
+		generations := max(budget/params.PopulationSize, 1)
+		ga, err := genetic.NewGeneticAlgorithm(small, params.PopulationSize, generations, params.MutationRate, tournamentSize, trialOpts...)
+		if err != nil {
+			return tuneTrial{}, nil, fmt.Errorf("trial %+v: %w", params, err)
+		}
+		if configure != nil {
+			configure(ga)
+		}
+
+		initial := ga.Population[0].Fitness
+		result, err := ga.Run(nil, generations)
+		if err != nil {
+			return tuneTrial{}, nil, fmt.Errorf("trial %+v: %w", params, err)
+		}
+
+		trial := tuneTrial{tuneParams: params}
+		if initial > 0 && !math.IsInf(initial, 0) {
+			trial.Improvement = (initial - result.Fitness) / initial
+		}
+		trials = append(trials, trial)
+		if best < 0 || trial.Improvement > trials[best].Improvement {
+			best = len(trials) - 1
+		}
+	}
+	return trials[best], trials, nil
+}
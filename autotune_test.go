@@ -0,0 +1,70 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/bishal0602/chaotic-canvas/targets"
+)
+
+func TestAutotuneBeatsBadBaseline(t *testing.T) {
+	target := targets.SolidTarget(24, 24, color.RGBA{R: 200, G: 40, B: 90, A: 255})
+	baseline := tuneParams{PopulationSize: 4, MutationRate: 0}
+	grid := []tuneParams{
+		baseline,
+		{PopulationSize: 20, MutationRate: 0.1},
+		{PopulationSize: 40, MutationRate: 0.2},
+	}
+	fixed := func(ga *genetic.GeneticAlgorithm) {
+		ga.MutationStrategy = genetic.NewFixedMutationStrategy(ga.MutationRate)
+	}
+
+	chosen, trials, err := autotune(target, grid, 2000, 2, nil, nil, fixed)
+	if err != nil {
+		t.Fatalf("autotune: %v", err)
+	}
+	if len(trials) != len(grid) {
+		t.Fatalf("got %d trials, want %d", len(trials), len(grid))
+	}
+	if chosen.tuneParams == baseline {
+		t.Fatalf("autotune chose the bad baseline %+v; trials: %+v", baseline, trials)
+	}
+	if chosen.Improvement <= trials[0].Improvement {
+		t.Errorf("chosen improvement %.3f does not beat baseline %.3f", chosen.Improvement, trials[0].Improvement)
+	}
+}
+
+func TestAutotuneScalesTargetOptions(t *testing.T) {
+	// Larger than the trials' downscaled target, so the input images and the frozen
+	// region have to shrink with it
+	target := targets.GradientTarget(96, 72, color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255})
+	seed := targets.SolidTarget(96, 72, color.RGBA{G: 255, A: 255})
+	avoid := targets.SolidTarget(96, 72, color.RGBA{B: 255, A: 255})
+	cfg := &config.Config{Freeze: "70,50,20,20"}
+	targetOptions := func(trial image.Image) ([]genetic.Option, error) {
+		frozen, err := frozenRegion(cfg, target.Bounds().Size(), trial.Bounds().Size())
+		if err != nil {
+			return nil, err
+		}
+		return []genetic.Option{
+			genetic.WithSeedImage(resizeToMatch(seed, trial), 1),
+			genetic.WithAvoidImage(resizeToMatch(avoid, trial), 1),
+			genetic.WithFrozenRegion(frozen),
+		}, nil
+	}
+
+	grid := []tuneParams{{PopulationSize: 4, MutationRate: 0.1}}
+	if _, _, err := autotune(target, grid, 40, 2, nil, targetOptions, nil); err != nil {
+		t.Fatalf("autotune with seed and avoid images and a frozen region: %v", err)
+	}
+}
+
+func TestAutotuneEmptyGrid(t *testing.T) {
+	target := targets.SolidTarget(8, 8, color.RGBA{A: 255})
+	if _, _, err := autotune(target, nil, 100, 2, nil, nil, nil); err == nil {
+		t.Fatal("expected an error for an empty grid")
+	}
+}
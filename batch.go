@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// batchExtensions are the file extensions -target-dir picks up from a directory.
+var batchExtensions = map[string]bool{".png": true, ".jpg": true, ".jpeg": true, ".gif": true, ".webp": true}
+
+// runBatch evolves every target found by cfg.TargetDir, cfg.BatchParallel at a time, each
+// with its own algorithm and output subdirectory named after the file. A target that
+// fails is logged and the rest carry on; the error reports how many failed.
+func runBatch(cfg *config.Config, hub *previewHub) error {
+	paths, err := batchTargets(cfg.TargetDir)
+	if err != nil {
+		return err
+	}
+	infof("Evolving %d targets from %s\n", len(paths), cfg.TargetDir)
+
+	jobs := make(chan string, len(paths))
+	names := batchNames(paths)
+	for _, path := range paths {
+		jobs <- path
+	}
+	close(jobs)
+
+	var mu sync.Mutex
+	failed := 0
+	var wg sync.WaitGroup
+	for w := 0; w < mathutil.Min(mathutil.Max(cfg.BatchParallel, 1), len(paths)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for path := range jobs {
+				target := *cfg
+				target.TargetImagePath = path
+				target.TargetDir = ""
+				target.OutDir = filepath.Join(cfg.OutDir, names[path])
+				if cfg.FramesDir != "" {
+					target.FramesDir = filepath.Join(cfg.FramesDir, names[path])
+				}
+				if err := evolveFile(&target, hub); err != nil {
+					log.Printf("Error evolving %s: %v\n", path, err)
+					mu.Lock()
+					failed++
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d targets failed", failed, len(paths))
+	}
+	return nil
+}
+
+// batchTargets lists the image files in dir, or the files matching it as a glob pattern
+// if it isn't a directory, in lexical order.
+func batchTargets(dir string) ([]string, error) {
+	var paths []string
+	if info, err := os.Stat(dir); err == nil && info.IsDir() {
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && batchExtensions[strings.ToLower(filepath.Ext(entry.Name()))] {
+				paths = append(paths, filepath.Join(dir, entry.Name()))
+			}
+		}
+	} else {
+		matches, err := filepath.Glob(dir)
+		if err != nil {
+			return nil, fmt.Errorf("invalid target pattern %q: %w", dir, err)
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err == nil && !info.IsDir() {
+				paths = append(paths, match)
+			}
+		}
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("no target images found in %s", dir)
+	}
+	return paths, nil
+}
+
+// batchNames names each target's output subdirectory after its file name without the
+// extension, keeping the extension for names that would otherwise clash, such as
+// a.png and a.jpg.
+func batchNames(paths []string) map[string]string {
+	count := make(map[string]int, len(paths))
+	for _, path := range paths {
+		base := filepath.Base(path)
+		count[strings.TrimSuffix(base, filepath.Ext(base))]++
+	}
+	names := make(map[string]string, len(paths))
+	for _, path := range paths {
+		base := filepath.Base(path)
+		name := strings.TrimSuffix(base, filepath.Ext(base))
+		if count[name] > 1 {
+			name = strings.ReplaceAll(base, ".", "_")
+		}
+		names[path] = name
+	}
+	return names
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"image/color"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/imageio"
+	"github.com/bishal0602/chaotic-canvas/targets"
+)
+
+func TestRunBatch(t *testing.T) {
+	for _, parallel := range []int{1, 2} {
+		cfg := tinyConfig(t)
+		cfg.TargetDir = t.TempDir()
+		cfg.BatchParallel = parallel
+		for _, name := range []string{"a.png", "b.png"} {
+			target := targets.GradientTarget(12, 10, color.RGBA{G: 255, A: 255}, color.RGBA{R: 255, A: 255})
+			if err := imageio.Save(filepath.Join(cfg.TargetDir, name), target); err != nil {
+				t.Fatal(err)
+			}
+		}
+		// Sorts between the good targets, so a sequential batch has to carry on past it
+		if err := os.WriteFile(filepath.Join(cfg.TargetDir, "b_broken.png"), []byte("not an image"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(cfg.TargetDir, "notes.txt"), []byte("ignored"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+
+		err := run(cfg)
+		if err == nil || !strings.Contains(err.Error(), "1 of 3 targets failed") {
+			t.Errorf("Parallel %d: expected one of three targets to fail, got %v", parallel, err)
+		}
+		for _, name := range []string{"a", "b"} {
+			if _, err := os.Stat(filepath.Join(cfg.OutDir, name, "final_result.png")); err != nil {
+				t.Errorf("Parallel %d: expected a final result for %s: %v", parallel, name, err)
+			}
+		}
+	}
+}
+
+func TestBatchTargets(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"x.png", "x.JPG", "y.gif", "z.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), nil, 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	paths, err := batchTargets(dir)
+	if err != nil {
+		t.Fatalf("batchTargets failed: %v", err)
+	}
+	want := []string{filepath.Join(dir, "x.JPG"), filepath.Join(dir, "x.png"), filepath.Join(dir, "y.gif")}
+	if !reflect.DeepEqual(paths, want) {
+		t.Errorf("Expected %v, got %v", want, paths)
+	}
+	names := batchNames(paths)
+	if names[want[0]] != "x_JPG" || names[want[1]] != "x_png" || names[want[2]] != "y" {
+		t.Errorf("Unexpected output names %v", names)
+	}
+
+	matched, err := batchTargets(filepath.Join(dir, "*.txt"))
+	if err != nil || len(matched) != 1 {
+		t.Errorf("Expected the glob to match z.txt, got %v, %v", matched, err)
+	}
+	if _, err := batchTargets(filepath.Join(dir, "*.bmp")); err == nil {
+		t.Error("Expected an error when nothing matches")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+const compareUsage = "usage: chaotic-canvas compare [-resize=false] a.png b.png"
+
+// runCompare implements the compare subcommand. It prints the fitness of the second image
+// against the first, using the same metric as the genetic algorithm, without running any evolution.
+func runCompare(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("compare", flag.ContinueOnError)
+	fs.SetOutput(out)
+	resize := fs.Bool("resize", true, "Resize the second image to the first when their dimensions differ, instead of failing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 2 {
+		return errors.New(compareUsage)
+	}
+
+	a, err := imageio.Read(fs.Arg(0))
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", fs.Arg(0), err)
+	}
+	b, err := imageio.Read(fs.Arg(1))
+	if err != nil {
+		return fmt.Errorf("error reading %s: %w", fs.Arg(1), err)
+	}
+
+	fitness, err := compareImages(a, b, *resize)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "%f\n", fitness)
+	return nil
+}
+
+// compareImages returns the fitness of b against a, where 0 means identical. When the
+// dimensions differ, b is resized to match a if resize is set, otherwise an error is returned.
+func compareImages(a, b image.Image, resize bool) (float64, error) {
+	if a.Bounds().Size() != b.Bounds().Size() {
+		if !resize {
+			return 0, fmt.Errorf("image sizes differ: %v vs %v", a.Bounds().Size(), b.Bounds().Size())
+		}
+		b = imageio.ResizeTo(b, a.Bounds().Dx(), a.Bounds().Dy())
+	}
+
+	ind := &genetic.Individual{Image: toRGBA(b)}
+	ind.CalculateFitness(toRGBA(a))
+	return ind.Fitness, nil
+}
+
+// toRGBA copies img into an RGBA image whose bounds start at the origin.
+func toRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+func solidImage(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestCompareImages(t *testing.T) {
+	red := solidImage(10, 8, color.RGBA{255, 0, 0, 255})
+	blue := solidImage(10, 8, color.RGBA{0, 0, 255, 255})
+
+	if fitness, err := compareImages(red, red, false); err != nil || fitness != 0 {
+		t.Errorf("Identical images: got %f, %v, expected 0", fitness, err)
+	}
+	if fitness, err := compareImages(red, blue, false); err != nil || fitness <= 0 {
+		t.Errorf("Different images: got %f, %v, expected > 0", fitness, err)
+	}
+
+	// A solid image resized to another size is still identical
+	bigRed := solidImage(20, 16, color.RGBA{255, 0, 0, 255})
+	if fitness, err := compareImages(red, bigRed, true); err != nil || fitness != 0 {
+		t.Errorf("Resized identical images: got %f, %v, expected 0", fitness, err)
+	}
+	if _, err := compareImages(red, bigRed, false); err == nil {
+		t.Errorf("Expected an error for mismatched sizes without resizing")
+	}
+}
+
+func TestRunCompare(t *testing.T) {
+	dir := t.TempDir()
+	a := filepath.Join(dir, "a.png")
+	b := filepath.Join(dir, "b.png")
+	if err := imageio.Save(a, solidImage(6, 6, color.RGBA{0, 0, 0, 255})); err != nil {
+		t.Fatal(err)
+	}
+	if err := imageio.Save(b, solidImage(6, 6, color.RGBA{0, 0, 0, 255})); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	if err := runCompare([]string{a, b}, &out); err != nil {
+		t.Fatalf("runCompare failed: %v", err)
+	}
+	fitness, err := strconv.ParseFloat(strings.TrimSpace(out.String()), 64)
+	if err != nil || fitness != 0 {
+		t.Errorf("Expected 0 for identical files, got %q", out.String())
+	}
+
+	if err := runCompare([]string{a}, &out); err == nil {
+		t.Errorf("Expected a usage error with one image")
+	}
+}
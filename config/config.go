@@ -4,39 +4,314 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+const (
+	// GenerationPlaceholder is replaced by the zero-padded generation in snapshot names
+	GenerationPlaceholder = "{gen}"
+	// DefaultFilenameTemplate names numbered snapshots when -filename-template isn't set
+	DefaultFilenameTemplate = "best_gen_" + GenerationPlaceholder
 )
 
 type Config struct {
 	TargetImagePath string
+	TargetDir       string
+	BatchParallel   int
+	Frame           int
+	Crop            string
+	Freeze          string
+	AllFrames       bool
+	AutoResize      bool
+	ValidateTarget  bool
 	OutDir          string
 	PopulationSize  int
+	PopSchedule     string
 	Generations     int
 	MutationRate    float64
 	TournamentSize  int
 	NoCompress      bool
+	Strict          bool
+	Interactive     bool
+	ResumeRate      float64
+	DebugInvariants bool
+	MaxMemoryMB     int
+	MaxIdleMemoryMB int
+	Posterize       int
+	ServeAddr       string
 	EnablePprof     bool
+	PprofAddr       string
+	CPUProfilePath  string
+	MemProfilePath  string
+	Seed            int64
+	FixedMutation   bool
+	Autotune        bool
+
+	MutationStrength float64
+	FixedStrength    bool
+	MaxFills         int
+	GuidedMutation   bool
+	SharingRadius    float64
+	RestartAfter     int
+	RestartBestOnly  bool
+
+	Warmup     int
+	WarmupRate float64
+
+	EliteSelectProbability float64
+	EliteSelectCount       int
+
+	PatchSize            int
+	PatchSwapProbability float64
+	CrossoverWeights     string
+	CrossoverPoints      int
+	GaussianNoiseScale   float64
+	BlendAlphaSpread     float64
+
+	Dither bool
+	Smooth float64
+
+	GammaCorrectResize bool
+
+	SnapshotCompression string
+	SnapshotPalette     bool
+	RollingOutput       bool
+	KeepHistory         bool
+	DumpShapes          bool
+	FilenameTemplate    string
+	FramesDir           string
+	OutputFormat        string
+	ColorModel          string
+	PreviewScale        int
+
+	SnapshotOnImprovement float64
+	SnapshotMaxInterval   int
+
+	BackgroundInit  string
+	InitColors      string
+	LockPaletteAt   int
+	PaletteFrom     string
+	SeedImagePath   string
+	SeedShapesCount int
+	SeedMix         string
+	InitShapesMin   int
+	InitShapesMax   int
+	FixedShapes     int
+	InitVerticesMin int
+	InitVerticesMax int
+	MaxShapeArea    float64
+	FillRule        string
+	ContrastWeight  float64
+	HistogramWeight float64
+	AvoidImagePath  string
+	AvoidWeight     float64
+	PyramidLevels   int
+	FitnessDeadband int
+	Distance        string
+	FitnessSample   float64
+	ReportMetric    string
+
+	Plot      bool
+	Compare   bool
+	APNG      bool
+	Summary   bool
+	KeepBestN int
+
+	Quiet   bool
+	Verbose bool
+
+	// DumpConfig prints the configuration instead of running, so it isn't part of it
+	DumpConfig bool `json:"-"`
 }
 
-func Load() (*Config, error) {
+// InitShapes returns the configured bounds for the polygons on initial individuals
+// and the area cap that also applies to polygons added by mutation.
+func (cfg *Config) InitShapes() genetic.ShapeConfig {
+	return genetic.ShapeConfig{
+		MinShapes:   cfg.InitShapesMin,
+		MaxShapes:   cfg.InitShapesMax,
+		MinVertices: cfg.InitVerticesMin,
+		MaxVertices: cfg.InitVerticesMax,
+		MaxArea:     cfg.MaxShapeArea,
+	}
+}
+
+// AbsolutePaths returns a copy of cfg with every file and directory path made absolute,
+// so that it describes the same files from any working directory.
+func (cfg *Config) AbsolutePaths() (*Config, error) {
+	resolved := *cfg
+	for _, path := range []*string{
+		&resolved.TargetImagePath, &resolved.TargetDir, &resolved.OutDir, &resolved.FramesDir, &resolved.SeedImagePath,
+		&resolved.AvoidImagePath, &resolved.PaletteFrom, &resolved.CPUProfilePath, &resolved.MemProfilePath,
+	} {
+		if *path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(*path)
+		if err != nil {
+			return nil, err
+		}
+		*path = abs
+	}
+	return &resolved, nil
+}
+
+// Defaults returns a config holding the default value of every flag, without parsing
+// the command line or validating anything.
+func Defaults() *Config {
 	cfg := &Config{}
+	register(flag.NewFlagSet("chaotic-canvas", flag.ContinueOnError), cfg)
+	return cfg
+}
+
+// register defines every flag on fs, storing its value in the matching field of cfg.
+func register(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.TargetImagePath, "target", "examples/afghan_girl.png", "Path to target image")
+	fs.StringVar(&cfg.TargetDir, "target-dir", "", "Evolve every image in this directory, or matching this glob pattern, each into its own subdirectory of -out instead of -target")
+	fs.IntVar(&cfg.BatchParallel, "batch-parallel", 1, "With -target-dir, evolve this many targets at once")
+	fs.IntVar(&cfg.Frame, "frame", 0, "Frame of an animated GIF target to evolve towards")
+	fs.StringVar(&cfg.Crop, "crop", "", "Evolve only this region of the target, given as x,y,w,h in the original image's pixels")
+	fs.StringVar(&cfg.Freeze, "freeze", "", "Keep this region of the canvas equal to the target and evolve only the rest, given as x,y,w,h in the original image's pixels")
+	fs.BoolVar(&cfg.AllFrames, "all-frames", false, "Evolve a separate result for every frame of an animated GIF target, in frame_N subdirectories")
+	fs.BoolVar(&cfg.AutoResize, "auto-resize-inputs", false, "Resize extra input images, such as a seed image, that don't match the target instead of failing")
+	fs.BoolVar(&cfg.ValidateTarget, "validate-target", true, "Warn when the target is a near-solid colour or too small to evolve towards meaningfully")
+	fs.StringVar(&cfg.OutDir, "out", "output", "Output Directory")
+	fs.IntVar(&cfg.PopulationSize, "pop", 500, "Population size")
+	fs.StringVar(&cfg.PopSchedule, "pop-schedule", "", "Resize the population during the run as generation:size pairs, e.g. 2000:300,5000:100")
+	fs.IntVar(&cfg.Generations, "gen", 10000, "Number of generations")
+	fs.Float64Var(&cfg.MutationRate, "mut", 0.05, "Mutation rate")
+	fs.Float64Var(&cfg.MutationStrength, "mut-strength", 0.05, "Mutation strength: how many and how large the polygons added by a mutation are")
+	fs.IntVar(&cfg.MaxFills, "max-fills", 0, "Soft cap on the polygons mutation draws per generation across the population, to bound generation time (0 disables)")
+	fs.BoolVar(&cfg.GuidedMutation, "guided-mutation", false, "Place the polygons added by mutation where the best image differs most from the target instead of uniformly")
+	fs.Float64Var(&cfg.SharingRadius, "fitness-sharing-radius", 0, "Penalize individuals in selection for every similar one within this thumbnail distance (0-255 scale), to keep the population diverse (0 disables)")
+	fs.IntVar(&cfg.RestartAfter, "restart-after", 0, "Restart the population when the best fitness hasn't improved for N generations, keeping the fitter half (0 disables)")
+	fs.BoolVar(&cfg.RestartBestOnly, "restart-best-only", false, "Keep only the best individual found so far on a restart instead of the fitter half")
+	fs.IntVar(&cfg.TournamentSize, "tour", 6, "Tournament selection size")
+	fs.Float64Var(&cfg.EliteSelectProbability, "elite-select-prob", 0, "Probability that a parent is picked directly from the fittest individuals instead of by tournament")
+	fs.IntVar(&cfg.EliteSelectCount, "elite-select-count", 5, "Number of fittest individuals that -elite-select-prob picks from")
+	fs.BoolVar(&cfg.NoCompress, "nocompress", false, "Switch to disable compress")
+	fs.BoolVar(&cfg.GammaCorrectResize, "gamma-correct-resize", false, "Interpolate in linear light when compressing the target, which keeps fine detail from darkening")
+	fs.BoolVar(&cfg.DebugInvariants, "debug-invariants", false, "Check after every generation that the population is complete and sorted, repairing it with a warning if not")
+	fs.BoolVar(&cfg.Interactive, "interactive", false, "At the generation cap, ask whether to continue for another -generations generations (only when stdin is a terminal)")
+	fs.Float64Var(&cfg.ResumeRate, "resume-mutation-rate", 0, "When -interactive continues a run, restart the mutation rate from this base with a fresh history instead of the converged rate (0 keeps it)")
+	fs.BoolVar(&cfg.Strict, "strict", false, "Refuse to start when the projected memory or runtime is excessive instead of warning")
+	fs.IntVar(&cfg.MaxIdleMemoryMB, "max-idle-memory", 0, "After each run, return memory to the OS if the heap still holds more than this many MiB (0 disables)")
+	fs.IntVar(&cfg.MaxMemoryMB, "max-memory", 0, "Refuse to allocate a population needing more than this many MiB (0 uses 80% of available memory)")
+	fs.IntVar(&cfg.Posterize, "posterize", 0, "Posterize the target to N levels per channel before evolution (0 disables)")
+	fs.BoolVar(&cfg.EnablePprof, "pprof", false, "Enable pprof profiling")
+	fs.StringVar(&cfg.ServeAddr, "serve", "", "Serve a live preview of the best image on this address, e.g. localhost:8080")
+	fs.StringVar(&cfg.PprofAddr, "pprof-addr", "localhost:6060", "Address for the pprof HTTP server")
+	fs.StringVar(&cfg.CPUProfilePath, "profile-cpu", "", "Write a CPU profile of the evolution to this file")
+	fs.StringVar(&cfg.MemProfilePath, "profile-mem", "", "Write a heap profile after the evolution to this file")
+	fs.BoolVar(&cfg.FixedMutation, "fixed-mutation", false, "Use the mutation rate verbatim every generation instead of adapting it")
+	fs.BoolVar(&cfg.Autotune, "autotune", false, "Pick the population size and mutation rate from short trial runs before evolving")
+	fs.BoolVar(&cfg.FixedStrength, "fixed-strength", false, "Use the mutation strength verbatim every generation instead of adapting it")
+	fs.IntVar(&cfg.Warmup, "warmup", 0, "Number of initial generations with a pinned high mutation rate and exploratory crossover")
+	fs.Float64Var(&cfg.WarmupRate, "warmup-mut", 0.4, "Mutation rate used during the warm-up generations")
+	fs.Int64Var(&cfg.Seed, "seed", 0, "Random seed for a reproducible run (0 picks a random seed)")
+	fs.IntVar(&cfg.PatchSize, "patch-size", 8, "Side length in pixels of patches swapped by patch crossover")
+	fs.Float64Var(&cfg.PatchSwapProbability, "patch-swap-prob", 0.3, "Probability of swapping each patch in patch crossover")
+	fs.StringVar(&cfg.CrossoverWeights, "crossover-weights", "blend=0.3,point=0.4,gaussian=0.2,patch=0.1", "Relative probability of each crossover operator (blend, point, gaussian, patch, uniform, shapes) as operator=weight pairs")
+	fs.IntVar(&cfg.CrossoverPoints, "crossover-points", 1, "Number of split points used by point crossover")
+	fs.Float64Var(&cfg.GaussianNoiseScale, "gaussian-noise", 0.1, "Maximum noise, in channel values, that gaussian crossover adds to the parents' mean")
+	fs.Float64Var(&cfg.BlendAlphaSpread, "blend-spread", 0.5, "Width of the random range around each parent's fitness-weighted share in blend crossover (0 to 2)")
+	fs.BoolVar(&cfg.Dither, "dither", false, "Dither the final image to reduce banding")
+	fs.Float64Var(&cfg.Smooth, "smooth", 0, "Soften polygon edges in the final image with an edge-preserving filter of this strength, its spatial spread in pixels (0 disables)")
+	fs.StringVar(&cfg.SnapshotCompression, "snapshot-compression", "default", "PNG compression for snapshots: default, best, fast or none")
+	fs.BoolVar(&cfg.SnapshotPalette, "snapshot-palette", false, "Save snapshots as 256-colour paletted PNGs")
+	fs.BoolVar(&cfg.RollingOutput, "rolling-output", false, "Overwrite best.png and current.png on every snapshot instead of writing best_gen_N.png files")
+	fs.BoolVar(&cfg.KeepHistory, "keep-history", false, "With -rolling-output, also keep the numbered best_gen_N.png files")
+	fs.BoolVar(&cfg.DumpShapes, "dump-shapes", false, "Write the best individual's polygons as best_gen_N.json next to each snapshot")
+	fs.StringVar(&cfg.FramesDir, "frames-dir", "", "Also write every snapshot to this directory as contiguously numbered frame_000001.png, frame_000002.png, ... for video encoding")
+	fs.StringVar(&cfg.OutputFormat, "format", "png", "Image format for the final result and snapshots: png or webp")
+	fs.StringVar(&cfg.ColorModel, "color-model", "truecolor", "Colour model for the final PNG: truecolor, paletted (256 colours) or grayscale")
+	fs.Float64Var(&cfg.SnapshotOnImprovement, "snapshot-on-improvement", 0, "Only write a scheduled snapshot if the best fitness improved by more than this since the last one (0 writes every snapshot)")
+	fs.IntVar(&cfg.SnapshotMaxInterval, "snapshot-max-interval", 1000, "With -snapshot-on-improvement, still write a snapshot after this many generations without one (0 never forces one)")
+	fs.IntVar(&cfg.PreviewScale, "preview-scale", 0, "Downscale snapshots so their larger side is at most this many pixels; final_result keeps full resolution (0 disables)")
+	fs.StringVar(&cfg.FilenameTemplate, "filename-template", DefaultFilenameTemplate, "Name of numbered snapshots without extension; {gen} is replaced by the zero-padded generation")
+	fs.StringVar(&cfg.BackgroundInit, "bg-init", "random", "Initial background color: random or edge (average of the target's border)")
+	fs.StringVar(&cfg.InitColors, "init-colors", "random", "Initial polygon colors: random or kmeans (the target's dominant colors)")
+	fs.IntVar(&cfg.LockPaletteAt, "lock-palette-at", 0, "After this generation, restrict new polygon colors to the dominant colors of the best image so far (0 disables)")
+	fs.StringVar(&cfg.PaletteFrom, "palette-from", "", "Restrict all polygon colors to the dominant colors of this image, while the shapes still follow the target")
+	fs.StringVar(&cfg.SeedImagePath, "seed-image", "", "Start every initial individual from this image, e.g. an earlier result")
+	fs.StringVar(&cfg.SeedMix, "seed-mix", "", "Start fractions of the initial population from a blurred target or the target's average color, e.g. blur:0.3,mean:0.2,random:0.5; the rest starts from random polygons")
+	fs.IntVar(&cfg.SeedShapesCount, "seed-shapes-count", 3, "Number of random polygons drawn over the seed image on each initial individual")
+	fs.IntVar(&cfg.InitShapesMin, "init-shapes-min", genetic.DefaultShapeConfig.MinShapes, "Minimum number of polygons on each initial individual")
+	fs.IntVar(&cfg.InitShapesMax, "init-shapes-max", genetic.DefaultShapeConfig.MaxShapes, "Maximum number of polygons on each initial individual")
+	fs.IntVar(&cfg.FixedShapes, "fixed-shapes", 0, "Give every individual exactly this many polygons for the whole run; mutation only edits them and crossover swaps whole polygons (0 disables)")
+	fs.IntVar(&cfg.InitVerticesMin, "init-vertices-min", genetic.DefaultShapeConfig.MinVertices, "Minimum number of vertices per initial polygon")
+	fs.IntVar(&cfg.InitVerticesMax, "init-vertices-max", genetic.DefaultShapeConfig.MaxVertices, "Maximum number of vertices per initial polygon")
+	fs.Float64Var(&cfg.MaxShapeArea, "max-shape-area", 0, "Cap each polygon's bounding box to this fraction of the image area (0 disables)")
+	fs.StringVar(&cfg.FillRule, "fill-rule", "nonzero", "How self-intersecting polygons are filled: nonzero (solid) or evenodd (with hollow parts)")
+	fs.Float64Var(&cfg.ContrastWeight, "contrast-weight", 0, "Weight of the global contrast and saturation fitness penalty (0 disables)")
+	fs.Float64Var(&cfg.HistogramWeight, "histogram-weight", 0, "Weight of the fitness penalty for mismatched color histograms (0 disables)")
+	fs.StringVar(&cfg.AvoidImagePath, "avoid", "", "Penalize results that resemble this image")
+	fs.Float64Var(&cfg.AvoidWeight, "avoid-weight", 0.5, "Weight of the -avoid similarity penalty")
+	fs.IntVar(&cfg.FitnessDeadband, "fitness-deadband", 0, "Treat per-channel differences of at most N as zero when calculating fitness")
+	fs.StringVar(&cfg.Distance, "distance", "l2", "Per-pixel distance for fitness: l2 (Euclidean, RMSE), l1 (Manhattan) or linf (Chebyshev, worst channel); each gives fitness a different scale")
+	fs.Float64Var(&cfg.FitnessSample, "fitness-sample", 1, "Measure fitness on this fraction of the pixels, e.g. 0.1 for a noisy but ~10x faster estimate")
+	fs.IntVar(&cfg.PyramidLevels, "pyramid-levels", 1, "Number of image pyramid levels used for fitness (1 uses full resolution only)")
+	fs.StringVar(&cfg.ReportMetric, "report-metric", "", "Also log and summarize this quality metric of the best image without using it for selection: rmse, ssim or deltae")
+	fs.BoolVar(&cfg.Plot, "plot", false, "Save a chart of best and average fitness per generation")
+	fs.BoolVar(&cfg.Compare, "compare", false, "Save the result, target and difference heatmap side by side")
+	fs.BoolVar(&cfg.APNG, "apng", false, "Save the snapshots and final result as evolution.png, a full-colour animated PNG of at most 100 evenly spaced snapshots")
+	fs.BoolVar(&cfg.Summary, "summary", false, "Save summary.json with the final fitness, similarity, generations, timing and parameters of the run")
+	fs.IntVar(&cfg.KeepBestN, "keep-best-n", 0, "Save the top N individuals of the final population as best_1.png..best_N.png")
 
-	flag.StringVar(&cfg.TargetImagePath, "target", "examples/afghan_girl.png", "Path to target image")
-	flag.StringVar(&cfg.OutDir, "out", "output", "Output Directory")
-	flag.IntVar(&cfg.PopulationSize, "pop", 500, "Population size")
-	flag.IntVar(&cfg.Generations, "gen", 10000, "Number of generations")
-	flag.Float64Var(&cfg.MutationRate, "mut", 0.05, "Mutation rate")
-	flag.IntVar(&cfg.TournamentSize, "tour", 6, "Tournament selection size")
-	flag.BoolVar(&cfg.NoCompress, "nocompress", false, "Switch to disable compress")
-	flag.BoolVar(&cfg.EnablePprof, "pprof", false, "Enable pprof profiling")
+	fs.BoolVar(&cfg.Quiet, "quiet", false, "Only log errors and warnings")
+	fs.BoolVar(&cfg.Verbose, "verbose", false, "Also log the per-operator statistics with every generation line")
 
+	fs.BoolVar(&cfg.DumpConfig, "dump-config", false, "Print the effective configuration, with defaults and absolute paths, as JSON and exit")
+}
+
+func Load() (*Config, error) {
+	cfg := &Config{}
+	register(flag.CommandLine, cfg)
 	flag.Parse()
 
 	// Validation
-	if cfg.TargetImagePath == "" {
-		return nil, fmt.Errorf("target image path cannot be empty")
+	if cfg.TargetDir == "" {
+		if cfg.TargetImagePath == "" {
+			return nil, fmt.Errorf("target image path cannot be empty")
+		}
+		if _, err := os.Stat(cfg.TargetImagePath); os.IsNotExist(err) {
+			return nil, fmt.Errorf("target image file not found: %s", cfg.TargetImagePath)
+		}
+	}
+	if cfg.BatchParallel < 1 {
+		return nil, fmt.Errorf("batch parallelism must be at least 1, got %d", cfg.BatchParallel)
+	}
+	if cfg.BatchParallel > 1 {
+		if cfg.TargetDir == "" {
+			return nil, fmt.Errorf("-batch-parallel only applies with -target-dir")
+		}
+		// These share a terminal, an address or the process-wide CPU profiler
+		if cfg.Interactive || cfg.ServeAddr != "" || cfg.CPUProfilePath != "" {
+			return nil, fmt.Errorf("-interactive, -serve and -profile-cpu can't be used with -batch-parallel above 1")
+		}
+	}
+
+	if cfg.Crop != "" {
+		if _, err := imageio.ParseRect(cfg.Crop); err != nil {
+			return nil, fmt.Errorf("error parsing crop: %w", err)
+		}
 	}
-	if _, err := os.Stat(cfg.TargetImagePath); os.IsNotExist(err) {
-		return nil, fmt.Errorf("target image file not found: %s", cfg.TargetImagePath)
+	if cfg.Freeze != "" {
+		if _, err := imageio.ParseRect(cfg.Freeze); err != nil {
+			return nil, fmt.Errorf("error parsing freeze region: %w", err)
+		}
+	}
+
+	if cfg.LockPaletteAt < 0 {
+		return nil, fmt.Errorf("palette lock generation cannot be negative, got %d", cfg.LockPaletteAt)
+	}
+
+	if cfg.Frame < 0 {
+		return nil, fmt.Errorf("frame cannot be negative, got %d", cfg.Frame)
 	}
 
 	if cfg.OutDir == "" {
@@ -55,6 +330,35 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("mutation rate must be between 0.0 and 1.0, got %f", cfg.MutationRate)
 	}
 
+	if cfg.MutationStrength < 0.0 || cfg.MutationStrength > 1.0 {
+		return nil, fmt.Errorf("mutation strength must be between 0.0 and 1.0, got %f", cfg.MutationStrength)
+	}
+
+	if cfg.SharingRadius < 0 {
+		return nil, fmt.Errorf("fitness sharing radius cannot be negative, got %f", cfg.SharingRadius)
+	}
+	if cfg.RestartAfter < 0 {
+		return nil, fmt.Errorf("restart generations cannot be negative, got %d", cfg.RestartAfter)
+	}
+	if cfg.RestartBestOnly && cfg.RestartAfter == 0 {
+		return nil, fmt.Errorf("-restart-best-only only applies with -restart-after")
+	}
+	if cfg.Quiet && cfg.Verbose {
+		return nil, fmt.Errorf("-quiet and -verbose cannot be used together")
+	}
+
+	if cfg.MaxFills < 0 {
+		return nil, fmt.Errorf("max fills cannot be negative, got %d", cfg.MaxFills)
+	}
+
+	if cfg.Warmup < 0 {
+		return nil, fmt.Errorf("warm-up generations cannot be negative, got %d", cfg.Warmup)
+	}
+
+	if cfg.WarmupRate < 0.0 || cfg.WarmupRate > 1.0 {
+		return nil, fmt.Errorf("warm-up mutation rate must be between 0.0 and 1.0, got %f", cfg.WarmupRate)
+	}
+
 	if cfg.TournamentSize <= 0 {
 		return nil, fmt.Errorf("tournament size must be positive, got %d", cfg.TournamentSize)
 	}
@@ -63,5 +367,175 @@ func Load() (*Config, error) {
 		return nil, fmt.Errorf("tournament size (%d) cannot be larger than population size (%d)", cfg.TournamentSize, cfg.PopulationSize)
 	}
 
+	if cfg.EliteSelectProbability < 0.0 || cfg.EliteSelectProbability > 1.0 {
+		return nil, fmt.Errorf("elite selection probability must be between 0.0 and 1.0, got %f", cfg.EliteSelectProbability)
+	}
+
+	if cfg.EliteSelectCount < 1 {
+		return nil, fmt.Errorf("elite selection count must be at least 1, got %d", cfg.EliteSelectCount)
+	}
+
+	if cfg.ResumeRate < 0.0 || cfg.ResumeRate > 1.0 {
+		return nil, fmt.Errorf("resume mutation rate must be between 0.0 and 1.0, got %f", cfg.ResumeRate)
+	}
+	if cfg.ResumeRate > 0 && !cfg.Interactive {
+		return nil, fmt.Errorf("-resume-mutation-rate only applies with -interactive")
+	}
+
+	if cfg.MaxIdleMemoryMB < 0 {
+		return nil, fmt.Errorf("max idle memory cannot be negative, got %d", cfg.MaxIdleMemoryMB)
+	}
+	if cfg.MaxMemoryMB < 0 {
+		return nil, fmt.Errorf("max memory cannot be negative, got %d", cfg.MaxMemoryMB)
+	}
+
+	if cfg.Posterize != 0 && cfg.Posterize < 2 {
+		return nil, fmt.Errorf("posterize levels must be at least 2 (or 0 to disable), got %d", cfg.Posterize)
+	}
+
+	if cfg.Smooth < 0.0 {
+		return nil, fmt.Errorf("smoothing strength cannot be negative, got %f", cfg.Smooth)
+	}
+
+	if cfg.PatchSize < 1 {
+		return nil, fmt.Errorf("patch size must be at least 1, got %d", cfg.PatchSize)
+	}
+
+	if cfg.PatchSwapProbability < 0.0 || cfg.PatchSwapProbability > 1.0 {
+		return nil, fmt.Errorf("patch swap probability must be between 0.0 and 1.0, got %f", cfg.PatchSwapProbability)
+	}
+
+	if cfg.CrossoverPoints < 1 {
+		return nil, fmt.Errorf("crossover points must be at least 1, got %d", cfg.CrossoverPoints)
+	}
+
+	if cfg.GaussianNoiseScale < 0.0 || cfg.GaussianNoiseScale > 255.0 {
+		return nil, fmt.Errorf("gaussian noise scale must be between 0 and 255, got %f", cfg.GaussianNoiseScale)
+	}
+
+	if cfg.BlendAlphaSpread < 0.0 || cfg.BlendAlphaSpread > 2.0 {
+		return nil, fmt.Errorf("blend spread must be between 0.0 and 2.0, got %f", cfg.BlendAlphaSpread)
+	}
+
+	if _, err := genetic.ParsePopulationSchedule(cfg.PopSchedule); err != nil {
+		return nil, err
+	}
+
+	if _, err := genetic.ParseCrossoverWeights(cfg.CrossoverWeights); err != nil {
+		return nil, err
+	}
+
+	if _, err := imageio.ParseCompressionLevel(cfg.SnapshotCompression); err != nil {
+		return nil, err
+	}
+
+	if cfg.SnapshotOnImprovement < 0 {
+		return nil, fmt.Errorf("snapshot improvement threshold must be non-negative, got %f", cfg.SnapshotOnImprovement)
+	}
+
+	if cfg.SnapshotMaxInterval < 0 {
+		return nil, fmt.Errorf("snapshot max interval must be non-negative, got %d", cfg.SnapshotMaxInterval)
+	}
+
+	if cfg.PreviewScale < 0 {
+		return nil, fmt.Errorf("preview scale must be non-negative, got %d", cfg.PreviewScale)
+	}
+
+	if cfg.OutputFormat != "png" && cfg.OutputFormat != "webp" {
+		return nil, fmt.Errorf("output format must be png or webp, got %q", cfg.OutputFormat)
+	}
+
+	if _, err := imageio.ParseColorModel(cfg.ColorModel); err != nil {
+		return nil, err
+	}
+
+	if !strings.Contains(cfg.FilenameTemplate, GenerationPlaceholder) {
+		return nil, fmt.Errorf("filename template must contain %s, got %q", GenerationPlaceholder, cfg.FilenameTemplate)
+	}
+
+	if strings.ContainsAny(cfg.FilenameTemplate, `/\`) {
+		return nil, fmt.Errorf("filename template must be a file name, not a path, got %q", cfg.FilenameTemplate)
+	}
+
+	if _, err := genetic.ParseBackgroundInit(cfg.BackgroundInit); err != nil {
+		return nil, err
+	}
+
+	if _, err := genetic.ParseInitColors(cfg.InitColors); err != nil {
+		return nil, err
+	}
+
+	if cfg.SeedShapesCount < 0 {
+		return nil, fmt.Errorf("seed shapes count cannot be negative, got %d", cfg.SeedShapesCount)
+	}
+
+	if cfg.FixedShapes < 0 {
+		return nil, fmt.Errorf("fixed shapes cannot be negative, got %d", cfg.FixedShapes)
+	}
+
+	if cfg.PaletteFrom != "" && cfg.LockPaletteAt > 0 {
+		return nil, fmt.Errorf("-palette-from can't be combined with -lock-palette-at")
+	}
+
+	if cfg.FixedShapes > 0 && cfg.SeedImagePath != "" {
+		return nil, fmt.Errorf("-fixed-shapes can't be combined with -seed-image")
+	}
+
+	if mix, err := genetic.ParseSeedMix(cfg.SeedMix); err != nil {
+		return nil, err
+	} else if mix.Blur > 0 || mix.Mean > 0 {
+		if cfg.SeedImagePath != "" {
+			return nil, fmt.Errorf("-seed-mix can't be combined with -seed-image")
+		}
+		if mix.Blur > 0 && cfg.FixedShapes > 0 {
+			return nil, fmt.Errorf("-fixed-shapes can't be combined with blurred seeds in -seed-mix")
+		}
+	}
+
+	if err := cfg.InitShapes().Validate(); err != nil {
+		return nil, err
+	}
+
+	if cfg.FitnessSample <= 0.0 || cfg.FitnessSample > 1.0 {
+		return nil, fmt.Errorf("fitness sample must be greater than 0.0 and at most 1.0, got %f", cfg.FitnessSample)
+	}
+
+	if cfg.FitnessSample < 1.0 && cfg.PyramidLevels > 1 {
+		return nil, fmt.Errorf("-fitness-sample can't be combined with -pyramid-levels")
+	}
+
+	if cfg.ContrastWeight < 0 {
+		return nil, fmt.Errorf("contrast weight cannot be negative, got %f", cfg.ContrastWeight)
+	}
+	if cfg.HistogramWeight < 0 {
+		return nil, fmt.Errorf("histogram weight cannot be negative, got %f", cfg.HistogramWeight)
+	}
+
+	if cfg.AvoidWeight < 0 {
+		return nil, fmt.Errorf("avoid weight cannot be negative, got %f", cfg.AvoidWeight)
+	}
+
+	if cfg.FitnessDeadband < 0 || cfg.FitnessDeadband > 255 {
+		return nil, fmt.Errorf("fitness deadband must be between 0 and 255, got %d", cfg.FitnessDeadband)
+	}
+
+	if distance, err := genetic.ParseDistance(cfg.Distance); err != nil {
+		return nil, err
+	} else if distance != genetic.DistanceL2 && cfg.FitnessSample < 1.0 {
+		return nil, fmt.Errorf("-distance %s can't be combined with -fitness-sample", cfg.Distance)
+	}
+
+	if cfg.PyramidLevels < 1 {
+		return nil, fmt.Errorf("pyramid levels must be at least 1, got %d", cfg.PyramidLevels)
+	}
+
+	if cfg.KeepBestN < 0 {
+		return nil, fmt.Errorf("keep-best-n cannot be negative, got %d", cfg.KeepBestN)
+	}
+	// -autotune and -pop-schedule change the final population size, so the run clamps N instead
+	if cfg.KeepBestN > cfg.PopulationSize && !cfg.Autotune && cfg.PopSchedule == "" {
+		return nil, fmt.Errorf("keep-best-n must be between 0 and the population size (%d), got %d", cfg.PopulationSize, cfg.KeepBestN)
+	}
+
 	return cfg, nil
 }
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+)
+
+const (
+	// Measured on a single core: one generation costs about this much per pixel per individual
+	nsPerPixelPerIndividual = 10
+
+	maxEstimatedMemory  uint64 = 4 << 30
+	maxEstimatedRuntime        = 24 * time.Hour
+)
+
+// runEstimate is a rough projection of the resources a run will need.
+type runEstimate struct {
+	Memory        uint64
+	PerGeneration time.Duration
+	Total         time.Duration
+}
+
+// estimateRun projects the memory and runtime of evolving a width x height target.
+func estimateRun(width, height, popSize, generations int) runEstimate {
+	pixels := uint64(width) * uint64(height)
+	perGen := time.Duration(float64(popSize) * float64(pixels) * nsPerPixelPerIndividual / float64(runtime.NumCPU()))
+	return runEstimate{
+		Memory:        uint64(genetic.RequiredMemory(width, height, popSize)),
+		PerGeneration: perGen,
+		Total:         perGen * time.Duration(generations),
+	}
+}
+
+func (e runEstimate) String() string {
+	return fmt.Sprintf("~%s of memory, ~%s per generation, ~%s in total",
+		formatBytes(e.Memory), e.PerGeneration.Round(time.Millisecond), e.Total.Round(time.Second))
+}
+
+// check returns an error describing which limits the estimate exceeds and how to get
+// under them, or nil if it's within limits. noCompress is whether resizing was disabled.
+func (e runEstimate) check(noCompress bool) error {
+	var problems []string
+	if e.Memory > maxEstimatedMemory {
+		problems = append(problems, fmt.Sprintf("projected memory %s exceeds %s", formatBytes(e.Memory), formatBytes(maxEstimatedMemory)))
+	}
+	if e.Total > maxEstimatedRuntime {
+		problems = append(problems, fmt.Sprintf("projected runtime %s exceeds %s", e.Total.Round(time.Minute), maxEstimatedRuntime))
+	}
+	if len(problems) == 0 {
+		return nil
+	}
+
+	suggestion := "use a smaller population or fewer generations"
+	if noCompress {
+		suggestion = "drop -nocompress to resize the target, or " + suggestion
+	}
+	return fmt.Errorf("%s; %s", strings.Join(problems, " and "), suggestion)
+}
+
+// formatBytes formats n in binary units.
+func formatBytes(n uint64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := uint64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
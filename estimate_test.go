@@ -0,0 +1,37 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestEstimateCheck(t *testing.T) {
+	// Population 5000 on an uncompressed 4K target
+	oversized := estimateRun(3840, 2160, 5000, 10000)
+	err := oversized.check(true)
+	if err == nil {
+		t.Fatalf("Expected an oversized config to exceed the limits, got %s", oversized)
+	}
+	if !strings.Contains(err.Error(), "memory") || !strings.Contains(err.Error(), "-nocompress") {
+		t.Errorf("Expected the error to name memory and suggest compression, got %q", err)
+	}
+
+	// The defaults on a compressed target
+	reasonable := estimateRun(540, 400, 500, 10000)
+	if err := reasonable.check(false); err != nil {
+		t.Errorf("Expected the default config to be within limits, got %v", err)
+	}
+}
+
+func TestFormatBytes(t *testing.T) {
+	cases := map[uint64]string{
+		512:     "512 B",
+		2048:    "2.0 KiB",
+		5 << 30: "5.0 GiB",
+	}
+	for n, want := range cases {
+		if got := formatBytes(n); got != want {
+			t.Errorf("formatBytes(%d) = %q, expected %q", n, got, want)
+		}
+	}
+}
@@ -2,11 +2,21 @@ package genetic
 
 import (
 	"errors"
+	"fmt"
 	"image"
+	"image/color"
 	"image/draw"
+	"log"
 	"math"
+	"math/rand"
 	"runtime"
+	"runtime/debug"
 	"sort"
+	"sync"
+	"time"
+
+	"github.com/bishal0602/chaotic-canvas/imageio"
+	"github.com/bishal0602/chaotic-canvas/mathutil"
 )
 
 // GeneticAlgorithm represents the genetic algorithm parameters and state
@@ -17,6 +27,183 @@ type GeneticAlgorithm struct {
 	MutationRate   float64
 	TournamentSize int
 	Population     []*Individual
+
+	// MutationStrategy decides the mutation rate each generation. When nil, Run uses an
+	// AdaptiveMutationStrategy around MutationRate, which a continued Run carries on with.
+	MutationStrategy MutationStrategy
+
+	// MutationStrength sets how large a mutation is (extra iterations, polygon size and
+	// vertex count), independently of MutationRate, the chance that a child mutates at all.
+	MutationStrength float64
+	// StrengthStrategy decides the mutation strength each generation. When nil, Run uses
+	// an AdaptiveMutationStrategy around MutationStrength.
+	StrengthStrategy MutationStrategy
+	// MaxFillsPerGeneration is a soft cap on the polygon fills mutation makes in one
+	// generation across the population, to bound generation time. Each pair of children
+	// gets a fixed share of it, so a capped run stays reproducible from its seed. Once a
+	// pair's share is used up, its mutations add fewer polygons or are skipped; crossover
+	// isn't limited. 0 disables it.
+	MaxFillsPerGeneration int
+	// GuidedMutation aims the polygons added by mutation at the regions where the best
+	// individual of the previous generation differs most from the target, instead of
+	// placing them uniformly, so the worst areas are fixed first.
+	GuidedMutation bool
+	// FitnessSharingRadius makes both parent and survivor selection penalize
+	// individuals with many similar ones nearby, to keep the population spread over
+	// several good solutions instead of converging on one. Individuals are similar when
+	// the root mean squared difference of their 8x8 thumbnails, on a 0-255 scale, is
+	// below the radius. Comparing every pair costs time quadratic in the population
+	// size. 0 disables it.
+	FitnessSharingRadius float64
+
+	// RestartAfter restarts the population when the best fitness hasn't improved for
+	// this many generations: the fitter half is kept and the rest replaced with new
+	// random individuals. 0 never restarts.
+	RestartAfter int
+	// RestartBestOnly makes a restart keep only the best individual found so far,
+	// a stronger shake-up than keeping half.
+	RestartBestOnly bool
+
+	// History records the best and average fitness and the duration of every generation of Run.
+	History []GenerationStats
+
+	// PatchSize is the side length in pixels of the patches swapped by patch crossover.
+	PatchSize int
+	// PatchSwapProbability is the chance that each patch is swapped by patch crossover.
+	PatchSwapProbability float64
+	// CrossoverWeights sets the relative probability of each crossover operator.
+	CrossoverWeights CrossoverWeights
+	// CrossoverPoints is the number of split points used by point crossover.
+	CrossoverPoints int
+	// GaussianNoiseScale bounds the per-row noise, in channel values, that gaussian
+	// crossover adds to the parents' mean.
+	GaussianNoiseScale float64
+	// BlendAlphaSpread is the width of the random range around the fitness-weighted
+	// share of each parent in blend crossover.
+	BlendAlphaSpread float64
+
+	// EliteSelectProbability is the chance that a parent is drawn directly from the
+	// EliteSelectCount fittest individuals instead of by tournament selection.
+	EliteSelectProbability float64
+	EliteSelectCount       int
+
+	// WarmupGenerations is the number of initial generations of Run during which the
+	// mutation rate is pinned to WarmupMutationRate and crossover favours the exploratory
+	// operators. The mutation strategy still records those generations, so it takes
+	// over with a populated history.
+	WarmupGenerations int
+	// WarmupMutationRate is the mutation rate used during the warm-up.
+	WarmupMutationRate float64
+
+	// LockPaletteAt is the generation of Run after which the colors of new polygons are
+	// restricted to the dominant colors of the best individual at that point, so that
+	// later generations refine geometry with a cohesive palette. 0 disables it.
+	LockPaletteAt int
+
+	// SnapshotMinImprovement, when positive, makes Run skip a scheduled snapshot unless
+	// the best fitness improved by more than this since the last snapshot sent.
+	SnapshotMinImprovement float64
+	// SnapshotMaxInterval forces a scheduled snapshot once this many generations have
+	// passed since the last one, even without improvement. 0 never forces one.
+	SnapshotMaxInterval int
+
+	// FitnessFunc, when set, replaces the built-in root mean squared error as the base
+	// fitness of a candidate image against the target; lower is fitter. It is called
+	// concurrently, so it must be safe for that. Use WithFitnessFunc to have the initial
+	// population scored with it too: setting the field later doesn't rescore individuals
+	// that were already evaluated.
+	FitnessFunc func(candidate, target *image.RGBA) float64
+
+	// ReportMetric, when set, is measured on the best image of every result sent by Run
+	// and stored in ImageResult.Report. It plays no part in selection, so a fast fitness
+	// can drive the evolution while a perceptual metric is reported.
+	ReportMetric Metric
+
+	// DebugInvariants makes Run check after every generation that the population has
+	// the right size and is sorted, logging a warning and repairing it if not.
+	DebugInvariants bool
+
+	// Settings applied through Options at construction time
+	seed           int64
+	seeded         bool
+	backgroundInit BackgroundInit
+	initColors     InitColors
+	seedImage      image.Image
+	seedShapeCount int
+	seedMix        SeedMix
+	paletteImage   image.Image
+	initShapes     ShapeConfig
+	// fixedShapes, if positive, is the exact number of shapes every individual carries
+	fixedShapes     int
+	contrastWeight  float64
+	histogramWeight float64
+	pyramidLevels   int
+	avoidImage      image.Image
+	avoidWeight     float64
+	// fitnessDeadband is the per-channel difference below which pixels count as matching
+	fitnessDeadband int
+	// distance measures how far a candidate pixel is from the target pixel
+	distance Distance
+	// fitnessSample is the fraction of pixels fitness is measured on
+	fitnessSample float64
+	// memoryLimit caps the bytes the population may need; 0 derives it from the system
+	memoryLimit uint64
+	// popSchedule resizes the population at the given generations of Run
+	popSchedule PopulationSchedule
+	// frozen is the region kept equal to the target, or empty
+	frozen image.Rectangle
+
+	targetStats   imageStats
+	targetPyramid []*image.RGBA
+	// targetHistogram is only computed when histogramWeight is set
+	targetHistogram *colorHistogram
+	// avoidRGBA is avoidImage copied to the origin, or nil without one
+	avoidRGBA *image.RGBA
+	// initBackground and initPalette pick the colors of new random individuals; a nil
+	// palette leaves polygon colors random
+	initBackground func(*rand.Rand) color.RGBA
+	initPalette    []color.RGBA
+	// blurredTarget and targetMean start the seed mix's blur and mean individuals
+	blurredTarget *image.RGBA
+	targetMean    color.RGBA
+	// With fitness sampling, every sampleStep-th pixel from sampleOffset is compared.
+	// Run picks a new offset each generation.
+	sampleStep   int
+	sampleOffset int
+
+	// rng drives the serial parts of the algorithm; parallel work uses jobRand
+	rng *rand.Rand
+	// warmingUp is set by Run during the warm-up generations
+	warmingUp bool
+	// generation is the last generation Run completed, which a later Run continues from
+	generation int
+	// released is set by Release, after which the algorithm can't run again
+	released bool
+	// lockedPalette is set by Run at LockPaletteAt, or from the start with a palette image;
+	// nil leaves polygon colors unrestricted
+	lockedPalette []color.RGBA
+	// stats accumulates per-operator telemetry during evolvePopulation
+	stats operatorStats
+	// fills counts the polygon fills of the current generation's mutations
+	fills fillBudget
+	// errorMap is where the current generation's guided mutations place polygons; nil
+	// places them uniformly
+	errorMap *errorMap
+	// sharing measures crowding against the current generation's population, and
+	// sharedFitness is what its tournaments compare, by population index; both are nil
+	// without fitness sharing
+	sharing       *fitnessSharing
+	sharedFitness []float64
+	// adaptiveRate and adaptiveStrength are the default strategies Run created, kept
+	// so that a continued Run resumes their history
+	adaptiveRate     *AdaptiveMutationStrategy
+	adaptiveStrength *AdaptiveMutationStrategy
+
+	// best is the all-time best individual, guarded by bestMu so Best can be called during Run
+	bestMu sync.Mutex
+	best   *Individual
+	// popMu guards changes to the Population slice during Run, so TopThumbnails can read it
+	popMu sync.RWMutex
 }
 
 type ImageResult struct {
@@ -24,73 +211,659 @@ type ImageResult struct {
 	Generation   int
 	Fitness      float64
 	MutationRate float64
+	// Shapes is the shape list Img was drawn from, or nil if it has none
+	Shapes *ShapeList
+	// Report is ReportMetric measured on Img, or 0 if ReportMetric is nil
+	Report float64
+	// GenPerSec is the recent throughput, over the last throughputWindow generations
+	GenPerSec float64
+}
+
+// GenerationStats records the population fitness after a generation.
+type GenerationStats struct {
+	Generation  int
+	BestFitness float64
+	AvgFitness  float64
+	// Duration is the wall-clock time the generation took, excluding sending its result
+	Duration time.Duration
+	// Restarted is set when the population was restarted after the generation
+	Restarted bool
+	// Fills is the number of polygons mutation drew during the generation
+	Fills int
+}
+
+// throughputWindow is the number of recent generations GenPerSec is averaged over.
+const throughputWindow = 20
+
+// genPerSec returns the generations per second over the last throughputWindow
+// generations of History, or 0 if there are none.
+func (ga *GeneticAlgorithm) genPerSec() float64 {
+	recent := ga.History[mathutil.Max(len(ga.History)-throughputWindow, 0):]
+	var total time.Duration
+	for _, stats := range recent {
+		total += stats.Duration
+	}
+	if total <= 0 {
+		return 0
+	}
+	return float64(len(recent)) / total.Seconds()
 }
 
-func NewGeneticAlgorithm(target image.Image, popSize, generations int, mutationRate float64, tournamentSize int) (*GeneticAlgorithm, error) {
+func NewGeneticAlgorithm(target image.Image, popSize, generations int, mutationRate float64, tournamentSize int, opts ...Option) (*GeneticAlgorithm, error) {
 	if target == nil || popSize <= 0 || generations <= 0 || mutationRate < 0 || mutationRate > 1 || tournamentSize <= 0 {
 		return nil, errors.New("invalid parameters for genetic algorithm")
 	}
 
+	// Copy the target to an image at the origin so that pixel loops can index it directly
 	bounds := target.Bounds()
-	targetRGBA := image.NewRGBA(bounds)
-	draw.Draw(targetRGBA, bounds, target, bounds.Min, draw.Src)
+	targetRGBA := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(targetRGBA, targetRGBA.Bounds(), target, bounds.Min, draw.Src)
 
-	population := make([]*Individual, popSize)
-	for i := range population {
-		population[i] = NewIndividual(targetRGBA.Bounds().Dx(), targetRGBA.Bounds().Dy())
-		population[i].CalculateFitness(targetRGBA)
-	}
-	sort.Slice(population, func(i, j int) bool {
-		return population[i].Fitness < population[j].Fitness
-	})
-
-	return &GeneticAlgorithm{
+	ga := &GeneticAlgorithm{
 		TargetRGBA:     targetRGBA,
 		PopulationSize: popSize,
 		Generations:    generations,
 		MutationRate:   mutationRate,
 		TournamentSize: tournamentSize,
-		Population:     population,
-	}, nil
+
+		MutationStrength: defaultMutationStrength,
+
+		PatchSize:            defaultPatchSize,
+		PatchSwapProbability: defaultPatchSwapProbability,
+		CrossoverWeights:     DefaultCrossoverWeights,
+		CrossoverPoints:      defaultCrossoverPoints,
+		GaussianNoiseScale:   defaultGaussianNoiseScale,
+		BlendAlphaSpread:     defaultBlendAlphaSpread,
+
+		EliteSelectCount: defaultEliteSelectCount,
+
+		WarmupMutationRate: defaultWarmupMutationRate,
+
+		initShapes:    DefaultShapeConfig,
+		fitnessSample: 1,
+	}
+	for _, opt := range opts {
+		opt(ga)
+	}
+	if !ga.seeded {
+		ga.seed = rand.Int63()
+	}
+	ga.rng = rand.New(mathutil.NewSplitMix64(ga.seed))
+	ga.targetStats = computeImageStats(targetRGBA)
+	if ga.histogramWeight > 0 {
+		ga.targetHistogram = computeHistogram(targetRGBA)
+	}
+	if ga.pyramidLevels > 1 {
+		ga.targetPyramid = buildPyramid(targetRGBA, ga.pyramidLevels)
+	}
+
+	width, height := targetRGBA.Bounds().Dx(), targetRGBA.Bounds().Dy()
+	if ga.fixedShapes < 0 {
+		return nil, fmt.Errorf("fixed shape count must be non-negative, got %d", ga.fixedShapes)
+	}
+	if !ga.frozen.Empty() {
+		if !ga.frozen.Overlaps(targetRGBA.Bounds()) {
+			return nil, fmt.Errorf("frozen region %v lies outside the %dx%d target", ga.frozen, width, height)
+		}
+		ga.frozen = ga.frozen.Intersect(targetRGBA.Bounds())
+	}
+	if err := ga.seedMix.Validate(); err != nil {
+		return nil, err
+	}
+	if ga.seedImage != nil && (ga.seedMix.Blur > 0 || ga.seedMix.Mean > 0) {
+		return nil, errors.New("a seed mix can't be combined with a seed image")
+	}
+	if ga.fixedShapes > 0 {
+		if ga.seedImage != nil {
+			return nil, errors.New("a fixed shape budget can't be combined with a seed image, which has no shape list")
+		}
+		if ga.seedMix.Blur > 0 {
+			return nil, errors.New("a fixed shape budget can't be combined with blurred seeds, which have no shape list")
+		}
+		ga.initShapes.MinShapes, ga.initShapes.MaxShapes = ga.fixedShapes, ga.fixedShapes
+	}
+	if err := ga.initShapes.Validate(); err != nil {
+		return nil, err
+	}
+	if ga.fitnessDeadband < 0 || ga.fitnessDeadband > 255 {
+		return nil, fmt.Errorf("fitness deadband must be between 0 and 255, got %d", ga.fitnessDeadband)
+	}
+	if ga.fitnessSample <= 0 || ga.fitnessSample > 1 {
+		return nil, fmt.Errorf("fitness sample must be greater than 0.0 and at most 1.0, got %f", ga.fitnessSample)
+	}
+	ga.sampleStep = mathutil.Max(int(math.Round(1/ga.fitnessSample)), 1)
+	if ga.sampleStep > 1 && ga.pyramidLevels > 1 {
+		return nil, errors.New("fitness sampling can't be combined with pyramid fitness")
+	}
+	if ga.FitnessFunc != nil && (ga.sampleStep > 1 || ga.pyramidLevels > 1 || ga.fitnessDeadband > 0) {
+		return nil, errors.New("a custom fitness function can't be combined with fitness sampling, pyramid fitness or a deadband")
+	}
+	if ga.distance < DistanceL2 || ga.distance > DistanceLInf {
+		return nil, fmt.Errorf("unknown distance %d", ga.distance)
+	}
+	if ga.distance != DistanceL2 && (ga.sampleStep > 1 || ga.FitnessFunc != nil) {
+		return nil, fmt.Errorf("the %s distance can't be combined with fitness sampling or a custom fitness function", ga.distance)
+	}
+	if err := ga.popSchedule.Validate(); err != nil {
+		return nil, err
+	}
+	if err := checkMemory(width, height, max(popSize, ga.popSchedule.MaxSize()), ga.memoryLimit); err != nil {
+		return nil, err
+	}
+	if ga.avoidImage != nil {
+		if size := ga.avoidImage.Bounds().Size(); size != targetRGBA.Bounds().Size() {
+			return nil, fmt.Errorf("avoid image is %dx%d but the target is %dx%d", size.X, size.Y, width, height)
+		}
+		if ga.avoidWeight < 0 {
+			return nil, fmt.Errorf("avoid weight cannot be negative, got %f", ga.avoidWeight)
+		}
+		ga.avoidRGBA = image.NewRGBA(image.Rect(0, 0, width, height))
+		draw.Draw(ga.avoidRGBA, ga.avoidRGBA.Bounds(), ga.avoidImage, ga.avoidImage.Bounds().Min, draw.Src)
+	}
+	if ga.seedImage != nil {
+		if size := ga.seedImage.Bounds().Size(); size != targetRGBA.Bounds().Size() {
+			return nil, fmt.Errorf("seed image is %dx%d but the target is %dx%d", size.X, size.Y, width, height)
+		}
+		if ga.seedShapeCount < 0 {
+			return nil, fmt.Errorf("seed shape count cannot be negative, got %d", ga.seedShapeCount)
+		}
+	}
+	if ga.paletteImage != nil {
+		// The palette is locked from the start, for new polygons as well as initial ones
+		ga.initPalette = dominantColors(imageio.ToRGBA(ga.paletteImage), initPaletteSize)
+		ga.lockedPalette = ga.initPalette
+	}
+	ga.setSpawnColors(targetRGBA)
+
+	rngs := make([]*rand.Rand, popSize)
+	for i := range rngs {
+		rngs[i] = ga.jobRand(0, i)
+	}
+	population := ga.spawnBatch(rngs, ga.seedMix.sources(popSize))
+	sort.Slice(population, func(i, j int) bool {
+		return population[i].Fitness < population[j].Fitness
+	})
+	ga.Population = population
+	ga.best = population[0]
+
+	return ga, nil
+}
+
+// setSpawnColors derives what new individuals start from out of targetRGBA: the
+// background and palette of random ones, which restarts use too, and the blurred target
+// and mean color of seeded ones. A palette image overrides the target's palette.
+func (ga *GeneticAlgorithm) setSpawnColors(targetRGBA *image.RGBA) {
+	ga.initBackground = RandomRGBA
+	if ga.backgroundInit == BackgroundEdge {
+		bgColor := edgeAverageColor(targetRGBA)
+		ga.initBackground = func(*rand.Rand) color.RGBA {
+			return bgColor
+		}
+	}
+	if ga.initColors == InitColorsKMeans && ga.paletteImage == nil {
+		ga.initPalette = dominantColors(targetRGBA, initPaletteSize)
+	}
+	if ga.seedMix.Blur > 0 {
+		ga.blurredTarget = blurredImage(targetRGBA)
+	}
+	ga.targetMean = meanColor(targetRGBA)
+}
+
+// spawn returns a new individual as the initial population is made: from the seed
+// image if there is one and otherwise as source says. It isn't evaluated yet.
+func (ga *GeneticAlgorithm) spawn(rng *rand.Rand, source seedSource) *Individual {
+	bounds := ga.TargetRGBA.Bounds()
+	var ind *Individual
+	switch {
+	case ga.seedImage != nil:
+		ind = newIndividualFromImage(rng, ga.seedImage, ga.seedShapeCount, ga.initShapes, ga.lockedPalette)
+	case source == seedBlur:
+		ind = newIndividualFromImage(rng, ga.blurredTarget, blurSeedShapes, ga.initShapes, ga.lockedPalette)
+	case source == seedMean:
+		ind = newIndividual(rng, bounds.Dx(), bounds.Dy(), ga.targetMean, ga.initShapes, ga.initPalette)
+	default:
+		ind = newIndividual(rng, bounds.Dx(), bounds.Dy(), ga.initBackground(rng), ga.initShapes, ga.initPalette)
+	}
+	ga.restoreFrozen(ind)
+	return ind
+}
+
+// spawnBatch spawns and evaluates one individual per random source in parallel, like
+// evaluateBatch, started as the matching entry of sources says or from random polygons
+// if sources is nil. Each individual depends only on its own random source, so the
+// result is the same for any number of workers.
+func (ga *GeneticAlgorithm) spawnBatch(rngs []*rand.Rand, sources []seedSource) []*Individual {
+	inds := make([]*Individual, len(rngs))
+	jobs := make(chan int, len(rngs))
+	for i := range rngs {
+		jobs <- i
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < mathutil.Min(runtime.GOMAXPROCS(0), len(rngs)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				source := seedRandom
+				if sources != nil {
+					source = sources[i]
+				}
+				inds[i] = ga.spawn(rngs[i], source)
+				ga.evaluate(inds[i])
+			}
+		}()
+	}
+	wg.Wait()
+	return inds
+}
+
+// snapshotDue reports whether a scheduled snapshot of gen should be sent, given the
+// generation and best fitness of the last one sent.
+func (ga *GeneticAlgorithm) snapshotDue(gen int, bestFitness float64, lastGen int, lastFitness float64) bool {
+	if ga.SnapshotMinImprovement <= 0 || lastGen == 0 {
+		return true
+	}
+	if ga.SnapshotMaxInterval > 0 && gen-lastGen >= ga.SnapshotMaxInterval {
+		return true
+	}
+	return lastFitness-bestFitness > ga.SnapshotMinImprovement
 }
 
+// Run evolves the population for the configured number of generations and returns the
+// best individual found. The best image is sent on recv for the first generation and
+// every recvEvery generations, subject to SnapshotMinImprovement, and recv is closed
+// when Run returns. recv may be nil for headless use, in which case no snapshots are
+// produced at all.
+//
+// Run picks up after the last generation a previous Run completed, so raising
+// Generations and calling it again extends a run with its current population.
 func (ga *GeneticAlgorithm) Run(recv chan<- ImageResult, recvEvery int) (*Individual, error) {
 	// Initialize
-	defer close(recv)
-	mutationStrategy := NewAdaptiveMutationStrategy(ga.MutationRate)
+	if recv != nil {
+		defer close(recv)
+	}
+	if ga.released {
+		return nil, errReleased
+	}
+	if err := ga.validateOperators(); err != nil {
+		return nil, err
+	}
+	mutationStrategy := ga.MutationStrategy
+	if mutationStrategy == nil {
+		if ga.adaptiveRate == nil || ga.generation == 0 {
+			ga.adaptiveRate = NewAdaptiveMutationStrategy(ga.MutationRate, ga.rng)
+		}
+		mutationStrategy = ga.adaptiveRate
+	}
+	strengthStrategy := ga.StrengthStrategy
+	if strengthStrategy == nil {
+		if ga.adaptiveStrength == nil || ga.generation == 0 {
+			ga.adaptiveStrength = NewAdaptiveMutationStrategy(ga.MutationStrength, ga.rng)
+		}
+		strengthStrategy = ga.adaptiveStrength
+	}
 
+	if ga.generation >= ga.Generations {
+		return nil, fmt.Errorf("all %d generations have already run", ga.Generations)
+	}
 	bestFitness := math.Inf(1)
 	var bestIndividual *Individual
+	if ga.generation > 0 {
+		bestIndividual = ga.best
+		bestFitness = bestIndividual.Fitness
+	}
+	lastSnapshotFitness, lastSnapshotGen := bestFitness, ga.generation
+	lastImprovement := ga.generation
 
-	for gen := 1; gen <= ga.Generations; gen++ {
+	for gen := ga.generation + 1; gen <= ga.Generations; gen++ {
+		genStart := time.Now()
+		if size, ok := ga.popSchedule.sizeAt(gen); ok {
+			ga.resizePopulation(size)
+		}
+		// The strategy is updated during the warm-up too so that its history fills up
 		ga.MutationRate = mutationStrategy.Update(ga.Population, gen, ga.Generations)
+		ga.warmingUp = gen <= ga.WarmupGenerations
+		if ga.warmingUp {
+			ga.MutationRate = ga.WarmupMutationRate
+		}
+		ga.MutationStrength = strengthStrategy.Update(ga.Population, gen, ga.Generations)
+		if ga.sampleStep > 1 {
+			// Draw a new sample and re-score the parents on it, so that parents and
+			// children are compared on the same pixels
+			ga.sampleOffset = ga.rng.Intn(ga.sampleStep)
+			ga.evaluateBatch(ga.Population)
+			ga.popMu.Lock()
+			sort.Slice(ga.Population, func(i, j int) bool {
+				return ga.Population[i].Fitness < ga.Population[j].Fitness
+			})
+			ga.popMu.Unlock()
+		}
 		// Evolve the old population
-		newPopulation := ga.evolvePopulation(ga.Population)
+		ga.fills.reset(ga.MaxFillsPerGeneration)
+		ga.errorMap = nil
+		if ga.GuidedMutation {
+			ga.errorMap = newErrorMap(ga.Population[0].Image, ga.TargetRGBA)
+		}
+		ga.sharing, ga.sharedFitness = nil, nil
+		if ga.FitnessSharingRadius > 0 {
+			ga.sharing = newFitnessSharing(ga.Population, ga.FitnessSharingRadius)
+			ga.sharedFitness = ga.sharing.populationFitness(ga.Population)
+		}
+		newPopulation := ga.evolvePopulation(ga.Population, gen)
+		if ga.DebugInvariants {
+			if err := ga.checkPopulation(newPopulation); err != nil {
+				log.Printf("Warning: generation %d: %v; repairing the population", gen, err)
+				newPopulation = ga.repairPopulation(newPopulation)
+			}
+		}
 		currentBest := newPopulation[0]
+		ga.popMu.Lock()
 		ga.Population = newPopulation
+		ga.popMu.Unlock()
 
 		if currentBest.Fitness < bestFitness {
 			bestFitness = currentBest.Fitness
 			bestIndividual = currentBest
+			ga.bestMu.Lock()
+			ga.best = bestIndividual
+			ga.bestMu.Unlock()
+			lastImprovement = gen
+		}
+		restart := ga.RestartAfter > 0 && gen-lastImprovement >= ga.RestartAfter
+		if gen == ga.LockPaletteAt {
+			ga.lockedPalette = dominantColors(bestIndividual.Image, initPaletteSize)
+		}
+		ga.History = append(ga.History, GenerationStats{
+			Generation:  gen,
+			BestFitness: bestFitness,
+			AvgFitness:  averageFitness(ga.Population),
+			Duration:    time.Since(genStart),
+			Fills:       int(ga.fills.used.Load()),
+			Restarted:   restart,
+		})
+		if restart {
+			ga.restart()
+			lastImprovement = gen
 		}
 
 		// Send progress periodically
-		if gen%recvEvery == 0 || gen == 1 {
-			recv <- ImageResult{
+		if recv != nil && (gen%recvEvery == 0 || gen == 1) && ga.snapshotDue(gen, bestFitness, lastSnapshotGen, lastSnapshotFitness) {
+			lastSnapshotFitness, lastSnapshotGen = bestFitness, gen
+			shapes, _ := bestIndividual.ShapeList()
+			result := ImageResult{
 				Generation:   gen,
 				Img:          bestIndividual.Image,
 				Fitness:      bestFitness,
 				MutationRate: ga.MutationRate,
+				Shapes:       shapes,
+				GenPerSec:    ga.genPerSec(),
 			}
+			if ga.ReportMetric != nil {
+				result.Report = ga.ReportMetric(bestIndividual.Image, ga.TargetRGBA)
+			}
+			recv <- result
 		}
+		ga.generation = gen
+	}
+
+	ga.warmingUp = false
+	ga.errorMap = nil
+	ga.sharing, ga.sharedFitness = nil, nil
+
+	if ga.sampleStep > 1 {
+		// Sampled fitness is only an estimate; report the exact fitness of the result
+		bestIndividual = bestIndividual.CreateCopy()
+		ga.evaluateExact(bestIndividual)
 	}
 
 	return bestIndividual, nil
 }
 
+// SetTarget replaces the target, keeping the current population as the starting
+// point: every individual is re-evaluated against the new target and the population
+// is re-sorted, and the next Run starts again from generation 1. The target must have
+// the same dimensions as the current one. It must not be called while Run is executing.
+func (ga *GeneticAlgorithm) SetTarget(target image.Image) error {
+	if ga.released {
+		return errReleased
+	}
+	if target == nil {
+		return errors.New("target cannot be nil")
+	}
+	bounds := target.Bounds()
+	current := ga.TargetRGBA.Bounds()
+	if bounds.Dx() != current.Dx() || bounds.Dy() != current.Dy() {
+		return fmt.Errorf("new target is %dx%d, expected %dx%d", bounds.Dx(), bounds.Dy(), current.Dx(), current.Dy())
+	}
+
+	targetRGBA := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(targetRGBA, targetRGBA.Bounds(), target, bounds.Min, draw.Src)
+	ga.TargetRGBA = targetRGBA
+	ga.targetStats = computeImageStats(targetRGBA)
+	if ga.histogramWeight > 0 {
+		ga.targetHistogram = computeHistogram(targetRGBA)
+	}
+	if ga.pyramidLevels > 1 {
+		ga.targetPyramid = buildPyramid(targetRGBA, ga.pyramidLevels)
+	}
+	// Restarts spawn individuals in the new target's colors
+	ga.setSpawnColors(targetRGBA)
+
+	for _, ind := range ga.Population {
+		ga.restoreFrozen(ind)
+	}
+	ga.evaluateBatch(ga.Population)
+	sort.Slice(ga.Population, func(i, j int) bool {
+		return ga.Population[i].Fitness < ga.Population[j].Fitness
+	})
+	// The old best was measured against the old target
+	ga.bestMu.Lock()
+	ga.best = ga.Population[0]
+	ga.bestMu.Unlock()
+	ga.generation = 0
+	return nil
+}
+
+// ResetMutationRate restarts the default adaptive mutation rate from rate with an empty
+// fitness history, so that continuing a converged run explores again instead of carrying
+// on with its low rate. A MutationStrategy set by the caller is left alone. It must not
+// be called while Run is executing.
+func (ga *GeneticAlgorithm) ResetMutationRate(rate float64) error {
+	if rate < 0 || rate > 1 {
+		return fmt.Errorf("mutation rate must be between 0 and 1, got %f", rate)
+	}
+	ga.MutationRate = rate
+	ga.adaptiveRate = nil
+	return nil
+}
+
+// Best returns a deep copy of the best individual found so far. It is safe to call
+// from another goroutine while Run is executing. Before the first generation it
+// returns the fittest individual of the initial population. After Release it returns nil.
+func (ga *GeneticAlgorithm) Best() *Individual {
+	ga.bestMu.Lock()
+	defer ga.bestMu.Unlock()
+	if ga.best == nil {
+		return nil
+	}
+	return ga.best.CreateCopy()
+}
+
+// TopThumbnails returns copies of the n fittest individuals' images, fittest first,
+// scaled down to at most maxDim pixels on a side. It is safe to call during Run, e.g.
+// once a generation to show a live view of the population.
+func (ga *GeneticAlgorithm) TopThumbnails(n, maxDim int) []image.Image {
+	ga.popMu.RLock()
+	defer ga.popMu.RUnlock()
+	n = mathutil.Clamp(n, 0, len(ga.Population))
+	thumbnails := make([]image.Image, n)
+	for i, ind := range ga.Population[:n] {
+		thumbnail := imageio.Resize(ind.Image, maxDim)
+		if thumbnail == image.Image(ind.Image) {
+			// Already small enough; copy it so the caller can't see later changes
+			copied := image.NewRGBA(ind.Image.Bounds())
+			copy(copied.Pix, ind.Image.Pix)
+			thumbnail = copied
+		}
+		thumbnails[i] = thumbnail
+	}
+	return thumbnails
+}
+
+// errReleased is returned when a released algorithm is used again.
+var errReleased = errors.New("genetic algorithm has been released and cannot run again")
+
+// Release drops the population and the images derived from the target, so that a
+// long-lived caller holding on to the algorithm, such as a server between jobs, doesn't
+// keep their memory alive. With freeOSMemory it also forces a garbage collection and
+// returns as much memory as possible to the operating system. History and Stats stay
+// available. A released algorithm is spent: Run and SetTarget return an error. Release
+// must not be called while Run is executing.
+func (ga *GeneticAlgorithm) Release(freeOSMemory bool) {
+	ga.bestMu.Lock()
+	ga.best = nil
+	ga.bestMu.Unlock()
+	ga.popMu.Lock()
+	ga.Population = nil
+	ga.popMu.Unlock()
+	ga.TargetRGBA = nil
+	ga.targetPyramid = nil
+	ga.targetHistogram = nil
+	ga.avoidImage, ga.avoidRGBA = nil, nil
+	ga.seedImage = nil
+	ga.paletteImage = nil
+	ga.blurredTarget = nil
+	ga.lockedPalette = nil
+	ga.adaptiveRate, ga.adaptiveStrength = nil, nil
+	ga.errorMap = nil
+	ga.released = true
+	if freeOSMemory {
+		debug.FreeOSMemory()
+	}
+}
+
+// Seed returns the seed that all of the algorithm's randomness is derived from.
+func (ga *GeneticAlgorithm) Seed() int64 {
+	return ga.seed
+}
+
+// jobRand returns a deterministic random source for one unit of parallel work,
+// derived from the seed, the generation (0 for the initial population) and the job index.
+func (ga *GeneticAlgorithm) jobRand(gen, idx int) *rand.Rand {
+	seed := uint64(ga.seed) ^ uint64(gen)*0x9E3779B97F4A7C15 ^ uint64(idx)*0xC2B2AE3D27D4EB4F
+	return rand.New(mathutil.NewSplitMix64(int64(seed)))
+}
+
+// restart keeps the fitter half of the population, or only the best individual found so
+// far with RestartBestOnly, and replaces the rest with new random individuals.
+func (ga *GeneticAlgorithm) restart() {
+	keep := mathutil.Max(ga.PopulationSize/2, 1)
+	if ga.RestartBestOnly {
+		keep = 1
+	}
+	rngs := make([]*rand.Rand, ga.PopulationSize-keep)
+	for i := range rngs {
+		// Draw from the algorithm's source so restarts stay reproducible from the seed
+		rngs[i] = rand.New(mathutil.NewSplitMix64(ga.rng.Int63()))
+	}
+	fresh := ga.spawnBatch(rngs, nil)
+
+	ga.popMu.Lock()
+	defer ga.popMu.Unlock()
+	if ga.RestartBestOnly {
+		// The best may have been lost from the population, e.g. to fitness sharing
+		ga.Population[0] = ga.best
+	}
+	copy(ga.Population[keep:], fresh)
+	sort.Slice(ga.Population, func(i, j int) bool {
+		return ga.Population[i].Fitness < ga.Population[j].Fitness
+	})
+}
+
+// rankCandidates sorts candidates fittest first, by shared fitness when fitness sharing
+// is enabled.
+func (ga *GeneticAlgorithm) rankCandidates(candidates []*Individual) {
+	if ga.sharing == nil {
+		sort.Slice(candidates, func(i, j int) bool {
+			return candidates[i].Fitness < candidates[j].Fitness
+		})
+		return
+	}
+	// A child that didn't change is its parent, so score each individual once
+	scores := make(map[*Individual]float64, len(candidates))
+	for _, ind := range candidates {
+		if _, ok := scores[ind]; !ok {
+			scores[ind] = ga.sharing.sharedFitness(ind)
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		return scores[candidates[i]] < scores[candidates[j]]
+	})
+}
+
+// averageFitness returns the mean fitness of the population.
+func averageFitness(pop []*Individual) float64 {
+	total := 0.0
+	for _, ind := range pop {
+		total += ind.Fitness
+	}
+	return total / float64(len(pop))
+}
+
+// validateOperators checks the tunable operator settings that can be changed after construction.
+func (ga *GeneticAlgorithm) validateOperators() error {
+	if ga.PatchSize < 1 {
+		return fmt.Errorf("patch size must be at least 1, got %d", ga.PatchSize)
+	}
+	if ga.PatchSwapProbability < 0 || ga.PatchSwapProbability > 1 {
+		return fmt.Errorf("patch swap probability must be between 0.0 and 1.0, got %f", ga.PatchSwapProbability)
+	}
+	if ga.MutationStrength < 0 || ga.MutationStrength > 1 {
+		return fmt.Errorf("mutation strength must be between 0.0 and 1.0, got %f", ga.MutationStrength)
+	}
+	if ga.WarmupGenerations < 0 {
+		return fmt.Errorf("warm-up generations cannot be negative, got %d", ga.WarmupGenerations)
+	}
+	if ga.WarmupMutationRate < 0 || ga.WarmupMutationRate > 1 {
+		return fmt.Errorf("warm-up mutation rate must be between 0.0 and 1.0, got %f", ga.WarmupMutationRate)
+	}
+	if ga.CrossoverPoints < 1 {
+		return fmt.Errorf("crossover points must be at least 1, got %d", ga.CrossoverPoints)
+	}
+	if ga.LockPaletteAt < 0 {
+		return fmt.Errorf("palette lock generation cannot be negative, got %d", ga.LockPaletteAt)
+	}
+	if ga.LockPaletteAt > 0 && ga.paletteImage != nil {
+		return fmt.Errorf("a palette lock generation can't be combined with a palette image")
+	}
+	if ga.GaussianNoiseScale < 0 || ga.GaussianNoiseScale > 255 {
+		return fmt.Errorf("gaussian noise scale must be between 0 and 255, got %f", ga.GaussianNoiseScale)
+	}
+	if ga.BlendAlphaSpread < 0 || ga.BlendAlphaSpread > maxBlendAlphaSpread {
+		return fmt.Errorf("blend alpha spread must be between 0.0 and %.1f, got %f", float64(maxBlendAlphaSpread), ga.BlendAlphaSpread)
+	}
+	if ga.RestartAfter < 0 {
+		return fmt.Errorf("restart generations cannot be negative, got %d", ga.RestartAfter)
+	}
+	if ga.FitnessSharingRadius < 0 {
+		return fmt.Errorf("fitness sharing radius cannot be negative, got %f", ga.FitnessSharingRadius)
+	}
+	if ga.EliteSelectProbability < 0 || ga.EliteSelectProbability > 1 {
+		return fmt.Errorf("elite selection probability must be between 0.0 and 1.0, got %f", ga.EliteSelectProbability)
+	}
+	if ga.EliteSelectCount < 1 {
+		return fmt.Errorf("elite selection count must be at least 1, got %d", ga.EliteSelectCount)
+	}
+	return ga.CrossoverWeights.Validate()
+}
+
 // evolvePopulation creates a new population by selecting parents and applying crossover and mutation
 // The population is sorted by fitness, with fittest individuals appearing first.
-func (ga *GeneticAlgorithm) evolvePopulation(population []*Individual) []*Individual {
+// Each pair of children draws from its own random source derived from the seed, generation and
+// pair index, so the result doesn't depend on goroutine scheduling.
+func (ga *GeneticAlgorithm) evolvePopulation(population []*Individual, gen int) []*Individual {
 	newPopulation := make([]*Individual, ga.PopulationSize)
 	batchSize := (runtime.NumCPU() * 3) / 2 * 2 // Ensure even number
 	if batchSize > ga.PopulationSize {
@@ -105,25 +878,29 @@ func (ga *GeneticAlgorithm) evolvePopulation(population []*Individual) []*Indivi
 		batchChan := make(chan struct {
 			indices     [2]int
 			individuals [2]*Individual
+			record      offspringRecord
 		}, (end-start)/2)
 
 		for i := start; i < end; i += 2 {
 			go func(idx int) {
-				parent1 := TournamentSelect(population, ga.TournamentSize)
-				parent2 := TournamentSelect(population, ga.TournamentSize)
+				rng := ga.jobRand(gen, idx)
+				parent1 := ga.selectParent(rng, population)
+				parent2 := ga.selectParent(rng, population)
 
-				child1, child2 := ga.Crossover(parent1, parent2)
-				child1 = ga.Mutate(child1)
-				child2 = ga.Mutate(child2)
-				child1.CalculateFitness(ga.TargetRGBA)
-				child2.CalculateFitness(ga.TargetRGBA)
+				fills := newFillShare(ga.MaxFillsPerGeneration, idx/2, ga.PopulationSize/2)
+				child1, child2, op := ga.crossover(rng, parent1, parent2)
+				child1, mutation1 := ga.mutate(rng, child1, fills)
+				child2, mutation2 := ga.mutate(rng, child2, fills)
+				ga.fills.used.Add(fills.used.Load())
+				ga.restoreFrozen(child1)
+				ga.restoreFrozen(child2)
+				ga.evaluate(child1)
+				ga.evaluate(child2)
 
 				// Select best two from children and parents
 				var result [2]*Individual
 				candidates := [4]*Individual{child1, child2, parent1, parent2}
-				sort.Slice(candidates[:], func(i, j int) bool {
-					return candidates[i].Fitness < candidates[j].Fitness
-				})
+				ga.rankCandidates(candidates[:])
 
 				// removing CreateCopy here causes ~73% less allocations
 				// since we are always using CreateCopy before modifying Individuals
@@ -131,10 +908,18 @@ func (ga *GeneticAlgorithm) evolvePopulation(population []*Individual) []*Indivi
 				result[0] = candidates[0]
 				result[1] = candidates[1]
 
+				parentFitness := math.Min(parent1.Fitness, parent2.Fitness)
+				record := offspringRecord{crossover: op, mutations: [2]mutationKind{mutation1, mutation2}}
+				for k, child := range [2]*Individual{child1, child2} {
+					record.improvements[k] = parentFitness - child.Fitness
+					record.selected[k] = child == result[0] || child == result[1]
+				}
+
 				batchChan <- struct {
 					indices     [2]int
 					individuals [2]*Individual
-				}{[2]int{idx, idx + 1}, result}
+					record      offspringRecord
+				}{[2]int{idx, idx + 1}, result, record}
 			}(i)
 		}
 
@@ -142,6 +927,7 @@ func (ga *GeneticAlgorithm) evolvePopulation(population []*Individual) []*Indivi
 			result := <-batchChan
 			newPopulation[result.indices[0]] = result.individuals[0]
 			newPopulation[result.indices[1]] = result.individuals[1]
+			ga.stats.record(result.record)
 		}
 	}
 
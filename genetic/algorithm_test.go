@@ -1,9 +1,17 @@
 package genetic
 
 import (
+	"bytes"
+	"fmt"
 	"image"
 	"image/color"
+	"math"
+	"reflect"
+	"runtime"
+	"strings"
 	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/imageio"
 )
 
 func TestEvolutionMaintainsPopulationSize(t *testing.T) {
@@ -20,8 +28,8 @@ func TestEvolutionMaintainsPopulationSize(t *testing.T) {
 		t.Fatalf("Failed to create GA: %v", err)
 	}
 
-	for range gen {
-		newPop := ga.evolvePopulation(ga.Population)
+	for g := 1; g <= gen; g++ {
+		newPop := ga.evolvePopulation(ga.Population, g)
 
 		// Check population size remains constant
 		if len(newPop) != popSize {
@@ -61,6 +69,11 @@ func TestEvolutionInvariants(t *testing.T) {
 		t.Errorf("Best individual fitness mismatch: expected %f, got %f", result.Fitness, ga.Population[0].Fitness)
 	}
 
+	// Every generation is recorded in the history
+	if len(ga.History) != gen {
+		t.Errorf("Expected %d history entries, got %d", gen, len(ga.History))
+	}
+
 	// Image dimensions are preserved
 	if ga.Population[0].Image.Bounds().Dx() != targetImg.Bounds().Dx() ||
 		ga.Population[0].Image.Bounds().Dy() != targetImg.Bounds().Dy() {
@@ -158,3 +171,446 @@ func BenchmarkCreateBlankCopy(b *testing.B) {
 		result = ind.CreateBlankCopy()
 	}
 }
+
+func TestSeededRunIsReproducible(t *testing.T) {
+	target := createCheckerPattern(24, 24, 3)
+
+	run := func() *Individual {
+		ga, err := NewGeneticAlgorithm(target, 12, 30, 0.2, 3, WithSeed(42))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		best, err := ga.Run(make(chan ImageResult, ga.Generations), 1)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return best
+	}
+
+	first, second := run(), run()
+	if first.Fitness != second.Fitness {
+		t.Errorf("Fitness differs between seeded runs: %f vs %f", first.Fitness, second.Fitness)
+	}
+	if !bytes.Equal(first.Image.Pix, second.Image.Pix) {
+		t.Errorf("Final images differ between seeded runs")
+	}
+}
+
+func TestSeededRunIndependentOfGOMAXPROCS(t *testing.T) {
+	target := createCheckerPattern(24, 24, 3)
+
+	run := func(procs int) *Individual {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+		ga, err := NewGeneticAlgorithm(target, 12, 30, 0.2, 3, WithSeed(42))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		// Every operator, including the ones that split work into strips
+		ga.CrossoverWeights = CrossoverWeights{Blend: 1, Point: 1, Gaussian: 1, Patch: 1, Uniform: 1}
+		best, err := ga.Run(nil, 1)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return best
+	}
+
+	single, many := run(1), run(7)
+	if single.Fitness != many.Fitness || !bytes.Equal(single.Image.Pix, many.Image.Pix) {
+		t.Errorf("Seeded runs differ between GOMAXPROCS 1 and 7: fitness %f vs %f", single.Fitness, many.Fitness)
+	}
+}
+
+func TestBestDuringRun(t *testing.T) {
+	target := createCheckerPattern(24, 24, 3)
+	ga, err := NewGeneticAlgorithm(target, 12, 60, 0.2, 3, WithSeed(7))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	done := make(chan struct{})
+	errs := make(chan string, 1)
+	go func() {
+		defer close(errs)
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			best := ga.Best()
+			if best == nil || best.Image == nil {
+				errs <- "Best returned nil during Run"
+				return
+			}
+			if best.Image.Bounds() != target.Bounds() || len(best.Image.Pix) != len(target.Pix) {
+				errs <- "Best returned an image of the wrong size"
+				return
+			}
+		}
+	}()
+
+	result, err := ga.Run(make(chan ImageResult, ga.Generations), 1)
+	close(done)
+	for msg := range errs {
+		t.Error(msg)
+	}
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	best := ga.Best()
+	if best.Fitness != result.Fitness || !bytes.Equal(best.Image.Pix, result.Image.Pix) {
+		t.Errorf("Best after Run doesn't match the returned individual")
+	}
+	if best.Image == result.Image {
+		t.Errorf("Best should return a copy, not the live individual")
+	}
+}
+
+func TestSetTargetMidRun(t *testing.T) {
+	first := createCheckerPattern(24, 24, 3)
+	second := createCheckerPattern(24, 24, 6)
+	ga, err := NewGeneticAlgorithm(first, 12, 10, 0.2, 3, WithSeed(3), WithPyramidLevels(2))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if _, err := ga.Run(make(chan ImageResult, ga.Generations), 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if err := ga.SetTarget(second); err != nil {
+		t.Fatalf("SetTarget failed: %v", err)
+	}
+	for i, ind := range ga.Population {
+		expected := &Individual{Image: ind.Image}
+		ga.evaluate(expected)
+		if ind.Fitness != expected.Fitness {
+			t.Errorf("Individual %d: fitness %f, expected %f against the new target", i, ind.Fitness, expected.Fitness)
+		}
+		if i > 0 && ga.Population[i-1].Fitness > ind.Fitness {
+			t.Errorf("Population not sorted at %d", i)
+		}
+	}
+	if best := ga.Best(); best.Fitness != ga.Population[0].Fitness {
+		t.Errorf("Best fitness %f doesn't match the re-sorted population's %f", best.Fitness, ga.Population[0].Fitness)
+	}
+
+	// Evolution continues from the re-evaluated population
+	if _, err := ga.Run(make(chan ImageResult, ga.Generations), 1); err != nil {
+		t.Fatalf("Run after SetTarget failed: %v", err)
+	}
+}
+
+func TestSetTargetUpdatesSpawnColors(t *testing.T) {
+	red, blue := color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}
+	ga, err := NewGeneticAlgorithm(solid(16, 16, red), 4, 1, 0.1, 2, WithSeed(1),
+		WithBackgroundInit(BackgroundEdge), WithInitColors(InitColorsKMeans))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if err := ga.SetTarget(solid(16, 16, blue)); err != nil {
+		t.Fatalf("SetTarget failed: %v", err)
+	}
+
+	// Restarts spawn random individuals like this one
+	ind := ga.spawn(ga.jobRand(1, 0), seedRandom)
+	if ind.Background != blue {
+		t.Errorf("Expected the new target's edge color %v as background, got %v", blue, ind.Background)
+	}
+	for _, c := range ga.initPalette {
+		if c != blue {
+			t.Errorf("Expected a palette of the new target's color %v, got %v", blue, ga.initPalette)
+			break
+		}
+	}
+}
+
+func TestSetTargetRejectsDifferentSize(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(8, 8, 2), 4, 1, 0.1, 2)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if err := ga.SetTarget(createCheckerPattern(8, 9, 2)); err == nil {
+		t.Error("Expected an error for a target of a different size")
+	}
+}
+
+func TestSnapshotOnImprovementSkipsFlatStretches(t *testing.T) {
+	run := func(maxInterval int) []int {
+		target := createCheckerPattern(16, 16, 4)
+		ga, err := NewGeneticAlgorithm(target, 8, 10, 0.2, 2, WithSeed(4))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		// No real improvement can clear this threshold, so every generation is flat
+		ga.SnapshotMinImprovement = 1e9
+		ga.SnapshotMaxInterval = maxInterval
+		recv := make(chan ImageResult, ga.Generations)
+		if _, err := ga.Run(recv, 1); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		var gens []int
+		for result := range recv {
+			gens = append(gens, result.Generation)
+		}
+		return gens
+	}
+
+	if gens := run(0); !reflect.DeepEqual(gens, []int{1}) {
+		t.Errorf("Expected only the first snapshot during a flat run, got generations %v", gens)
+	}
+	if gens := run(4); !reflect.DeepEqual(gens, []int{1, 5, 9}) {
+		t.Errorf("Expected snapshots forced every 4 generations, got %v", gens)
+	}
+}
+
+func TestRunWithoutProgressChannel(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 8, 10, 0.2, 2, WithSeed(4))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	best, err := ga.Run(nil, 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if best == nil || best.Image == nil {
+		t.Fatal("Expected the best individual from a run without a progress channel")
+	}
+	if best.Fitness != ga.Best().Fitness {
+		t.Errorf("Returned fitness %f doesn't match Best's %f", best.Fitness, ga.Best().Fitness)
+	}
+	if len(ga.History) != ga.Generations {
+		t.Errorf("Expected %d generations of history, got %d", ga.Generations, len(ga.History))
+	}
+}
+
+func TestRunContinuesAfterRaisingGenerations(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 4), 8, 4, 0.2, 2, WithSeed(5))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	first, err := ga.Run(nil, 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if _, err := ga.Run(nil, 1); err == nil {
+		t.Error("Expected an error when every generation has already run")
+	}
+
+	ga.Generations = 7
+	recv := make(chan ImageResult, ga.Generations)
+	second, err := ga.Run(recv, 1)
+	if err != nil {
+		t.Fatalf("Continued Run failed: %v", err)
+	}
+	var gens []int
+	for result := range recv {
+		gens = append(gens, result.Generation)
+	}
+	if len(gens) == 0 || gens[0] != 5 || gens[len(gens)-1] != 7 {
+		t.Errorf("Expected the continued snapshots to run from generation 5 to 7, got %v", gens)
+	}
+	for i, stats := range ga.History {
+		if stats.Generation != i+1 {
+			t.Fatalf("History entry %d is for generation %d", i, stats.Generation)
+		}
+	}
+	if len(ga.History) != 7 {
+		t.Errorf("Expected 7 generations of history, got %d", len(ga.History))
+	}
+	if second.Fitness > first.Fitness {
+		t.Errorf("Continuing made the best fitness worse: %f after %f", second.Fitness, first.Fitness)
+	}
+}
+
+func TestReleaseSpendsTheAlgorithm(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 4), 8, 3, 0.2, 2, WithSeed(2))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	ga.Release(true)
+	if ga.Population != nil || ga.TargetRGBA != nil {
+		t.Error("Expected Release to drop the population and target")
+	}
+	if best := ga.Best(); best != nil {
+		t.Error("Expected no best individual after Release")
+	}
+	if len(ga.History) != 3 {
+		t.Errorf("Expected the history to survive Release, got %d entries", len(ga.History))
+	}
+
+	ga.Generations = 6
+	recv := make(chan ImageResult, 1)
+	if _, err := ga.Run(recv, 1); err == nil || !strings.Contains(err.Error(), "released") {
+		t.Errorf("Expected Run to report that the algorithm was released, got %v", err)
+	}
+	if _, open := <-recv; open {
+		t.Error("Expected Run to close recv even when the algorithm was released")
+	}
+	if err := ga.SetTarget(createCheckerPattern(16, 16, 2)); err == nil {
+		t.Error("Expected SetTarget to fail after Release")
+	}
+}
+
+func TestRunRecordsGenerationTiming(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 4), 6, 5, 0.3, 2, WithSeed(3))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	recv := make(chan ImageResult, ga.Generations)
+	if _, err := ga.Run(recv, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for _, stats := range ga.History {
+		if stats.Duration <= 0 {
+			t.Errorf("Generation %d: expected a positive duration, got %v", stats.Generation, stats.Duration)
+		}
+	}
+	for result := range recv {
+		if result.GenPerSec <= 0 || math.IsInf(result.GenPerSec, 0) {
+			t.Errorf("Generation %d: expected a positive throughput, got %f", result.Generation, result.GenPerSec)
+		}
+	}
+}
+
+func TestRestartBestOnly(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 4), 10, 3, 0.3, 2, WithSeed(10))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	best := ga.Best()
+	before := make(map[*Individual]bool)
+	for _, ind := range ga.Population {
+		before[ind] = true
+	}
+
+	ga.RestartBestOnly = true
+	ga.restart()
+
+	if err := ga.checkPopulation(ga.Population); err != nil {
+		t.Fatalf("Restart broke the population: %v", err)
+	}
+	matches := 0
+	for _, ind := range ga.Population {
+		if ind == ga.best {
+			if !bytes.Equal(ind.Image.Pix, best.Image.Pix) || ind.Fitness != best.Fitness {
+				t.Error("Expected the kept individual to be the prior best")
+			}
+			matches++
+		} else if before[ind] {
+			t.Error("Expected every other individual to be new")
+		}
+	}
+	if matches != 1 {
+		t.Errorf("Expected exactly one individual to be the prior best, got %d", matches)
+	}
+}
+
+func TestRestartAfterPlateau(t *testing.T) {
+	// A fitness that never improves is a plateau after the first generation
+	flat := func(candidate, target *image.RGBA) float64 { return 1 }
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 4), 8, 7, 0.3, 2, WithSeed(11), WithFitnessFunc(flat))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	ga.RestartAfter = 3
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for _, stats := range ga.History {
+		if want := stats.Generation > 1 && (stats.Generation-1)%3 == 0; stats.Restarted != want {
+			t.Errorf("Generation %d: expected restarted to be %t", stats.Generation, want)
+		}
+	}
+}
+
+func TestTopThumbnails(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(40, 20, 5), 8, 2, 0.3, 2, WithSeed(12))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	thumbnails := ga.TopThumbnails(3, 10)
+	if len(thumbnails) != 3 {
+		t.Fatalf("Expected 3 thumbnails, got %d", len(thumbnails))
+	}
+	for i, thumbnail := range thumbnails {
+		if b := thumbnail.Bounds(); b.Dx() != 10 || b.Dy() != 5 {
+			t.Errorf("Thumbnail %d: expected 10x5, got %dx%d", i, b.Dx(), b.Dy())
+		}
+		// Thumbnails follow the population, which is sorted fittest first
+		want := imageio.ToRGBA(imageio.Resize(ga.Population[i].Image, 10))
+		if !bytes.Equal(imageio.ToRGBA(thumbnail).Pix, want.Pix) {
+			t.Errorf("Thumbnail %d doesn't match individual %d", i, i)
+		}
+		if i > 0 && ga.Population[i].Fitness < ga.Population[i-1].Fitness {
+			t.Errorf("Thumbnail %d is fitter than the one before it", i)
+		}
+	}
+
+	if got := len(ga.TopThumbnails(20, 10)); got != 8 {
+		t.Errorf("Expected asking for more than the population to return 8, got %d", got)
+	}
+	large := ga.TopThumbnails(1, 100)[0]
+	if large.Bounds().Dx() != 40 || large == image.Image(ga.Population[0].Image) {
+		t.Error("Expected an unscaled copy when the image already fits")
+	}
+}
+
+func TestInitialPopulationIsSortedAndDeterministic(t *testing.T) {
+	target := createCheckerPattern(48, 32, 4)
+	build := func(procs int) *GeneticAlgorithm {
+		defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+		ga, err := NewGeneticAlgorithm(target, 40, 1, 0.3, 2, WithSeed(13))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		return ga
+	}
+
+	parallel := build(4)
+	if err := parallel.checkPopulation(parallel.Population); err != nil {
+		t.Fatalf("Initial population is invalid: %v", err)
+	}
+	serial := build(1)
+	for i := range serial.Population {
+		if !bytes.Equal(serial.Population[i].Image.Pix, parallel.Population[i].Image.Pix) {
+			t.Fatalf("Individual %d differs between 1 and 4 workers", i)
+		}
+		if math.Abs(serial.Population[i].Fitness-parallel.Population[i].Fitness) > 1e-9 {
+			t.Errorf("Individual %d: fitness %f with 1 worker, %f with 4", i, serial.Population[i].Fitness, parallel.Population[i].Fitness)
+		}
+	}
+}
+
+func BenchmarkNewGeneticAlgorithm(b *testing.B) {
+	target := createCheckerPattern(200, 150, 10)
+	workers := []int{1}
+	if n := runtime.NumCPU(); n > 1 {
+		workers = append(workers, n)
+	}
+	for _, procs := range workers {
+		b.Run(fmt.Sprintf("workers=%d", procs), func(b *testing.B) {
+			defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(procs))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if _, err := NewGeneticAlgorithm(target, 100, 1, 0.3, 2, WithSeed(1)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
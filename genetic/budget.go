@@ -0,0 +1,78 @@
+package genetic
+
+import (
+	"sync/atomic"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// fillBudget counts the polygon fills mutations make and, when capped, limits them. The
+// zero value counts without a cap. It is safe for concurrent use.
+type fillBudget struct {
+	capped bool
+	limit  int64
+	used   atomic.Int64
+}
+
+// reset starts counting again with the given limit; 0 or less counts without a cap.
+func (b *fillBudget) reset(limit int) {
+	b.capped, b.limit = limit > 0, int64(limit)
+	b.used.Store(0)
+}
+
+// newFillShare returns the budget job index of jobs gets from a generation's limit: an
+// equal share, with the remainder spread over the first jobs. Fixed shares make which
+// mutations get fills independent of how the jobs are scheduled. A limit of 0 or less
+// gives every job an uncapped budget.
+func newFillShare(limit, index, jobs int) *fillBudget {
+	b := &fillBudget{}
+	b.reset(limit)
+	if limit > 0 {
+		share := limit / jobs
+		if index < limit%jobs {
+			share++
+		}
+		b.limit = int64(share)
+	}
+	return b
+}
+
+// take reserves up to n fills and returns how many were granted.
+func (b *fillBudget) take(n int) int {
+	if !b.capped {
+		b.used.Add(int64(n))
+		return n
+	}
+	for {
+		used := b.used.Load()
+		granted := mathutil.Min(int64(n), b.limit-used)
+		if granted <= 0 {
+			return 0
+		}
+		if b.used.CompareAndSwap(used, used+granted) {
+			return int(granted)
+		}
+	}
+}
+
+// takeAll reserves exactly n fills, or none if fewer remain, and reports which.
+func (b *fillBudget) takeAll(n int) bool {
+	if !b.capped {
+		b.used.Add(int64(n))
+		return true
+	}
+	for {
+		used := b.used.Load()
+		if used+int64(n) > b.limit {
+			return false
+		}
+		if b.used.CompareAndSwap(used, used+int64(n)) {
+			return true
+		}
+	}
+}
+
+// refund returns n reserved fills that weren't made.
+func (b *fillBudget) refund(n int) {
+	b.used.Add(-int64(n))
+}
@@ -0,0 +1,102 @@
+package genetic
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestFillCapBoundsEachGeneration(t *testing.T) {
+	const maxFills = 40
+	target := createCheckerPattern(24, 24, 4)
+	run := func(cap int) []GenerationStats {
+		ga, err := NewGeneticAlgorithm(target, 20, 6, 1, 2, WithSeed(5))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		ga.MaxFillsPerGeneration = cap
+		ga.MutationStrategy = NewFixedMutationStrategy(1)
+		if _, err := ga.Run(nil, 1); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return ga.History
+	}
+
+	exceeded := false
+	for _, stats := range run(0) {
+		exceeded = exceeded || stats.Fills > maxFills
+	}
+	if !exceeded {
+		t.Fatalf("Expected an uncapped run to exceed %d fills in some generation", maxFills)
+	}
+	for _, stats := range run(maxFills) {
+		if stats.Fills == 0 || stats.Fills > maxFills {
+			t.Errorf("Generation %d: expected between 1 and %d fills, got %d", stats.Generation, maxFills, stats.Fills)
+		}
+	}
+}
+
+func TestFillCapIsReproducible(t *testing.T) {
+	target := createCheckerPattern(24, 24, 4)
+	run := func() []GenerationStats {
+		ga, err := NewGeneticAlgorithm(target, 20, 6, 1, 2, WithSeed(5))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		// Far below what the mutations ask for, so every pair runs out of fills
+		ga.MaxFillsPerGeneration = 25
+		ga.MutationStrategy = NewFixedMutationStrategy(1)
+		if _, err := ga.Run(nil, 1); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return ga.History
+	}
+
+	first, second := run(), run()
+	for i := range first {
+		if first[i].BestFitness != second[i].BestFitness || first[i].Fills != second[i].Fills {
+			t.Errorf("Generation %d: runs with the same seed differ: %+v and %+v", i+1, first[i], second[i])
+		}
+	}
+}
+
+func TestFillShareSplitsTheLimit(t *testing.T) {
+	total := 0
+	for i := 0; i < 4; i++ {
+		share := newFillShare(10, i, 4)
+		if want := map[bool]int64{true: 3, false: 2}[i < 2]; share.limit != want {
+			t.Errorf("Job %d: share %d, want %d", i, share.limit, want)
+		}
+		total += int(share.limit)
+	}
+	if total != 10 {
+		t.Errorf("Expected the shares to add up to 10, got %d", total)
+	}
+	if share := newFillShare(3, 3, 4); share.take(5) != 0 {
+		t.Error("Expected a job without a share to get no fills")
+	}
+	if share := newFillShare(0, 3, 4); share.take(5) != 5 {
+		t.Error("Expected an uncapped share to grant every fill")
+	}
+}
+
+func TestFillBudgetConcurrentTakes(t *testing.T) {
+	var b fillBudget
+	b.reset(100)
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !b.takeAll(3) {
+				b.take(3)
+			}
+		}()
+	}
+	wg.Wait()
+	if used := b.used.Load(); used != 100 {
+		t.Errorf("Expected the whole budget of 100 to be used exactly, got %d", used)
+	}
+	if b.take(1) != 0 || !b.takeAll(0) {
+		t.Error("Expected an exhausted budget to grant nothing but empty reservations")
+	}
+}
@@ -1,15 +1,99 @@
 package genetic
 
 import (
+	"fmt"
+	"image"
 	"image/color"
 	"math/rand"
 )
 
-func RandomRGBA() color.RGBA {
+// BackgroundInit selects how the background of initial individuals is chosen.
+type BackgroundInit int
+
+const (
+	// BackgroundRandom gives every initial individual a random background color.
+	BackgroundRandom BackgroundInit = iota
+	// BackgroundEdge uses the average color of the target's border pixels.
+	BackgroundEdge
+)
+
+// ParseBackgroundInit converts a background mode name (random or edge) to a BackgroundInit.
+func ParseBackgroundInit(name string) (BackgroundInit, error) {
+	switch name {
+	case "random":
+		return BackgroundRandom, nil
+	case "edge":
+		return BackgroundEdge, nil
+	}
+	return BackgroundRandom, fmt.Errorf("unknown background init %q, expected random or edge", name)
+}
+
+// InitColors selects how the colors of the polygons on initial individuals are chosen.
+type InitColors int
+
+const (
+	// InitColorsRandom gives every initial polygon a random color.
+	InitColorsRandom InitColors = iota
+	// InitColorsKMeans picks initial polygon colors from the target's dominant colors.
+	InitColorsKMeans
+)
+
+// ParseInitColors converts a color mode name (random or kmeans) to an InitColors.
+func ParseInitColors(name string) (InitColors, error) {
+	switch name {
+	case "random":
+		return InitColorsRandom, nil
+	case "kmeans":
+		return InitColorsKMeans, nil
+	}
+	return InitColorsRandom, fmt.Errorf("unknown init colors %q, expected random or kmeans", name)
+}
+
+// RandomRGBA returns a color drawn from rng with an alpha of at least 50, so shapes are
+// never invisible. It is the single source of random colors for backgrounds and polygons.
+func RandomRGBA(rng *rand.Rand) color.RGBA {
+	return color.RGBA{
+		R: uint8(rng.Intn(256)),
+		G: uint8(rng.Intn(256)),
+		B: uint8(rng.Intn(256)),
+		A: uint8(rng.Intn(206) + 50), // Alpha between 50-255 for semi-transparency
+	}
+}
+
+// edgeAverageColor returns the average color of the pixels along the image border.
+func edgeAverageColor(img *image.RGBA) color.RGBA {
+	bounds := img.Bounds()
+	var r, g, b, a, n int
+
+	add := func(x, y int) {
+		c := img.RGBAAt(x, y)
+		r += int(c.R)
+		g += int(c.G)
+		b += int(c.B)
+		a += int(c.A)
+		n++
+	}
+
+	for x := bounds.Min.X; x < bounds.Max.X; x++ {
+		add(x, bounds.Min.Y)
+		if bounds.Dy() > 1 {
+			add(x, bounds.Max.Y-1)
+		}
+	}
+	for y := bounds.Min.Y + 1; y < bounds.Max.Y-1; y++ {
+		add(bounds.Min.X, y)
+		if bounds.Dx() > 1 {
+			add(bounds.Max.X-1, y)
+		}
+	}
+
+	if n == 0 {
+		return color.RGBA{}
+	}
 	return color.RGBA{
-		R: uint8(rand.Intn(256)),
-		G: uint8(rand.Intn(256)),
-		B: uint8(rand.Intn(256)),
-		A: uint8(rand.Intn(206) + 50), // Alpha between 50-255 for semi-transparency
+		R: uint8(r / n),
+		G: uint8(g / n),
+		B: uint8(b / n),
+		A: uint8(a / n),
 	}
 }
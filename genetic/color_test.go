@@ -0,0 +1,60 @@
+package genetic
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+)
+
+func TestRandomRGBA(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		if c := RandomRGBA(rng); c.A < 50 {
+			t.Fatalf("RandomRGBA() alpha %d is below 50", c.A)
+		}
+	}
+
+	// A random background is the first thing drawn from the individual's source
+	want := RandomRGBA(rand.New(rand.NewSource(7)))
+	if got := NewIndividual(rand.New(rand.NewSource(7)), 8, 8).Background; got != want {
+		t.Errorf("NewIndividual background = %v, want RandomRGBA's %v", got, want)
+	}
+}
+
+func TestEdgeAverageColor(t *testing.T) {
+	border := color.RGBA{R: 20, G: 120, B: 220, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(img, img.Bounds(), &image.Uniform{border}, image.Point{}, draw.Src)
+	// The subject inside the frame must not affect the result
+	draw.Draw(img, image.Rect(1, 1, 39, 29), &image.Uniform{color.RGBA{R: 250, G: 0, B: 0, A: 255}}, image.Point{}, draw.Src)
+
+	if got := edgeAverageColor(img); got != border {
+		t.Errorf("edgeAverageColor() = %v, want %v", got, border)
+	}
+}
+
+func TestBackgroundEdgeInit(t *testing.T) {
+	border := color.RGBA{R: 200, G: 100, B: 50, A: 255}
+	target := image.NewRGBA(image.Rect(0, 0, 20, 20))
+	draw.Draw(target, target.Bounds(), &image.Uniform{border}, image.Point{}, draw.Src)
+
+	ga, err := NewGeneticAlgorithm(target, 10, 1, 0.05, 3, WithBackgroundInit(BackgroundEdge))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	// Polygons cover only part of the canvas, so the border color must show through somewhere
+	for i, ind := range ga.Population {
+		found := false
+		for y := 0; y < 20 && !found; y++ {
+			for x := 0; x < 20 && !found; x++ {
+				found = ind.Image.RGBAAt(x, y) == border
+			}
+		}
+		if !found {
+			t.Errorf("Individual %d has no pixels of the edge background color", i)
+		}
+	}
+}
@@ -1,58 +1,237 @@
 package genetic
 
 import (
+	"fmt"
+	"math"
 	"math/rand"
-	"runtime"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 
 	"github.com/bishal0602/chaotic-canvas/mathutil"
 )
 
 const (
-	blendCrossoverThreshold    = 0.3
-	pointCrossoverThreshold    = 0.7 // blend + point
-	gaussianCrossoverThreshold = 0.9 // blend + point + gaussian
+	defaultPatchSwapProbability = 0.3
+	defaultPatchSize            = 8
+	defaultCrossoverPoints      = 1
 
-	patchCrossoverSwapProbability = 0.3
-	patchSize                     = 8
+	defaultGaussianNoiseScale = 0.1
+	defaultBlendAlphaSpread   = 0.5
+	// maxBlendAlphaSpread is wide enough for the blend alpha to reach 0 and 1 from any share
+	maxBlendAlphaSpread = 2
 
-	gaussianNoiseScale = 0.1
+	// crossoverStrips is the number of strips the parallel operators split an image into.
+	// Each strip draws its own random values, so the count is fixed rather than derived
+	// from GOMAXPROCS to keep seeded runs identical on machines with different core counts.
+	crossoverStrips = 8
 )
 
-func (ga *GeneticAlgorithm) Crossover(parent1 *Individual, parent2 *Individual) (*Individual, *Individual) {
-	var child1, child2 *Individual
+// crossoverOperator identifies one of the crossover strategies.
+type crossoverOperator int
 
-	r := rand.Float64()
-	if r < blendCrossoverThreshold {
-		child1, child2 = blendCrossover(parent1, parent2)
-	} else if r < pointCrossoverThreshold {
-		child1, child2 = crossoverPoint(parent1, parent2)
-	} else if r < gaussianCrossoverThreshold {
-		child1, child2 = gaussianPerturbationCrossover(parent1, parent2)
-	} else {
-		child1, child2 = patchCrossover(parent1, parent2)
+const (
+	opBlend crossoverOperator = iota
+	opPoint
+	opGaussian
+	opPatch
+	opUniform
+	opShapes
+	numCrossoverOperators
+)
+
+// String returns the operator's name as used in ParseCrossoverWeights.
+func (op crossoverOperator) String() string {
+	return [...]string{"blend", "point", "gaussian", "patch", "uniform", "shapes"}[op]
+}
+
+// CrossoverWeights holds the relative probability of each crossover operator.
+// The weights don't have to sum to 1; Crossover samples proportionally to them.
+type CrossoverWeights struct {
+	Blend    float64
+	Point    float64
+	Gaussian float64
+	Patch    float64
+	Uniform  float64
+	Shapes   float64
+}
+
+// DefaultCrossoverWeights favors blend and single-point crossover.
+var DefaultCrossoverWeights = CrossoverWeights{
+	Blend:    0.3,
+	Point:    0.4,
+	Gaussian: 0.2,
+	Patch:    0.1,
+}
+
+// warmupCrossoverWeights favours the operators that introduce new pixel values or mix
+// parents finely over the ones that converge on the parents, for the warm-up generations.
+var warmupCrossoverWeights = CrossoverWeights{
+	Gaussian: 0.4,
+	Uniform:  0.3,
+	Patch:    0.3,
+}
+
+// ParseCrossoverWeights parses a comma-separated list of operator=weight pairs, such as
+// "blend=0.2,point=0.5,patch=0.3", into weights normalized to sum to 1.
+// Operators that aren't listed get a weight of 0.
+func ParseCrossoverWeights(s string) (CrossoverWeights, error) {
+	var cw CrossoverWeights
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			return cw, fmt.Errorf("invalid crossover weight %q, expected operator=weight", pair)
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return cw, fmt.Errorf("invalid crossover weight %q: %w", pair, err)
+		}
+		switch strings.TrimSpace(name) {
+		case "blend":
+			cw.Blend = weight
+		case "point":
+			cw.Point = weight
+		case "gaussian":
+			cw.Gaussian = weight
+		case "patch":
+			cw.Patch = weight
+		case "uniform":
+			cw.Uniform = weight
+		case "shapes":
+			cw.Shapes = weight
+		default:
+			return cw, fmt.Errorf("unknown crossover operator %q, expected blend, point, gaussian, patch, uniform or shapes", name)
+		}
+	}
+	if err := cw.Validate(); err != nil {
+		return cw, err
+	}
+	return cw.normalized(), nil
+}
+
+// Validate checks that no weight is negative and at least one is positive.
+func (cw CrossoverWeights) Validate() error {
+	for _, w := range cw.values() {
+		if w < 0 {
+			return fmt.Errorf("crossover weights must be non-negative, got %v", cw)
+		}
+	}
+	if cw.total() <= 0 {
+		return fmt.Errorf("at least one crossover weight must be positive")
+	}
+	return nil
+}
+
+// values returns the weights indexed by crossoverOperator.
+func (cw CrossoverWeights) values() []float64 {
+	return []float64{cw.Blend, cw.Point, cw.Gaussian, cw.Patch, cw.Uniform, cw.Shapes}
+}
+
+func (cw CrossoverWeights) total() float64 {
+	total := 0.0
+	for _, w := range cw.values() {
+		total += w
 	}
+	return total
+}
+
+func (cw CrossoverWeights) normalized() CrossoverWeights {
+	total := cw.total()
+	return CrossoverWeights{
+		Blend:    cw.Blend / total,
+		Point:    cw.Point / total,
+		Gaussian: cw.Gaussian / total,
+		Patch:    cw.Patch / total,
+		Uniform:  cw.Uniform / total,
+		Shapes:   cw.Shapes / total,
+	}
+}
+
+// pick samples an operator in proportion to its weight.
+func (cw CrossoverWeights) pick(rng *rand.Rand) crossoverOperator {
+	values := cw.values()
+	r := rng.Float64() * cw.total()
+	for op, w := range values {
+		if r < w {
+			return crossoverOperator(op)
+		}
+		r -= w
+	}
+	// Rounding can leave r just past the last bucket; fall back to the last weighted operator
+	for op := len(values) - 1; op > 0; op-- {
+		if values[op] > 0 {
+			return crossoverOperator(op)
+		}
+	}
+	return opBlend
+}
+
+func (ga *GeneticAlgorithm) Crossover(rng *rand.Rand, parent1 *Individual, parent2 *Individual) (*Individual, *Individual) {
+	child1, child2, _ := ga.crossover(rng, parent1, parent2)
 	return child1, child2
 }
 
+// crossover is Crossover that also returns the operator that produced the children.
+func (ga *GeneticAlgorithm) crossover(rng *rand.Rand, parent1 *Individual, parent2 *Individual) (*Individual, *Individual, crossoverOperator) {
+	var child1, child2 *Individual
+
+	weights := ga.CrossoverWeights
+	if ga.warmingUp {
+		weights = warmupCrossoverWeights
+	}
+	op := weights.pick(rng)
+	if ga.fixedShapes > 0 {
+		// Pixel-level operators would lose the shape lists the fixed budget is kept in
+		op = opShapes
+	}
+	switch op {
+	case opBlend:
+		child1, child2 = blendCrossover(rng, parent1, parent2, ga.BlendAlphaSpread)
+	case opPoint:
+		child1, child2 = crossoverPoint(rng, parent1, parent2, ga.CrossoverPoints)
+	case opGaussian:
+		child1, child2 = gaussianPerturbationCrossover(rng, parent1, parent2, ga.GaussianNoiseScale)
+	case opUniform:
+		child1, child2 = uniformCrossover(rng, parent1, parent2)
+	case opShapes:
+		child1, child2 = shapeCrossover(rng, parent1, parent2)
+	default:
+		child1, child2 = patchCrossover(rng, parent1, parent2, ga.PatchSize, ga.PatchSwapProbability)
+	}
+	return child1, child2, op
+}
+
 // blendCrossover performs a blend crossover operation between two parent individuals.
-// It creates two children by interpolating pixel values between parents using a random alpha value.
-func blendCrossover(parent1, parent2 *Individual) (*Individual, *Individual) {
+// It creates two children by interpolating pixel values between parents. The share taken
+// from each parent is biased toward the fitter one, with a random component so the
+// children still explore.
+func blendCrossover(rng *rand.Rand, parent1, parent2 *Individual, spread float64) (*Individual, *Individual) {
 	child1 := parent1.CreateBlankCopy()
 	child2 := parent2.CreateBlankCopy()
 
 	bounds := child1.Image.Bounds()
 	height := bounds.Dy()
-	numGoroutines := runtime.GOMAXPROCS(0)
+	numGoroutines := mathutil.Max(mathutil.Min(crossoverStrips, height), 1)
+	share2 := blendShare(parent1.Fitness, parent2.Fitness)
 	var wg sync.WaitGroup
 
-	// Process image in parallel strips
+	// Process image in parallel strips. Alphas are drawn up front so the shared
+	// rng is only used from this goroutine.
 	rowsPerGoroutine := height / numGoroutines
 	for i := 0; i < numGoroutines; i++ {
+		startY := i * rowsPerGoroutine
+		endY := startY + rowsPerGoroutine
+		if i == numGoroutines-1 {
+			endY = height
+		}
+		// Weight given to parent2 in each child
+		alpha1 := mathutil.Clamp(share2+(rng.Float64()-0.5)*spread, 0, 1)
+		alpha2 := mathutil.Clamp(share2+(rng.Float64()-0.5)*spread, 0, 1)
+
 		wg.Add(1)
 		go func(startY, endY int) {
 			defer wg.Done()
-			blendAlpha := rand.Float64()
 			bounds := child1.Image.Bounds()
 			for y := startY; y < endY; y++ {
 				i := y * child1.Image.Stride
@@ -62,64 +241,114 @@ func blendCrossover(parent1, parent2 *Individual) (*Individual, *Individual) {
 					for j := 0; j < 4; j++ {
 						p1 := float64(parent1.Image.Pix[idx+j])
 						p2 := float64(parent2.Image.Pix[idx+j])
-						child1.Image.Pix[idx+j] = uint8(p1*(1-blendAlpha) + p2*blendAlpha)
-						child2.Image.Pix[idx+j] = uint8(p1*blendAlpha + p2*(1-blendAlpha))
+						// Interpolating from p1 keeps equal parent values exact despite rounding
+						child1.Image.Pix[idx+j] = mathutil.ClampUint8(p1 + (p2-p1)*alpha1)
+						child2.Image.Pix[idx+j] = mathutil.ClampUint8(p1 + (p2-p1)*alpha2)
 					}
 				}
 			}
-		}(i*rowsPerGoroutine, (i+1)*rowsPerGoroutine)
+		}(startY, endY)
 	}
 
 	wg.Wait()
 	return child1, child2
 }
 
-// crossoverPoint performs a single-point crossover between two parent individuals.
-// It randomly chooses either a horizontal or vertical split point and creates two children
-// by combining sections from both parents. The split can be either:
-//   - Horizontal: Takes upper portion from parent1 and lower portion from parent2 for child1
-//     Takes upper portion from parent2 and lower portion from parent1 for child2
-//   - Vertical: Takes left portion from parent1 and right portion from parent2 for child1
-//     Takes left portion from parent2 and right portion from parent1 for child2
-func crossoverPoint(parent1, parent2 *Individual) (*Individual, *Individual) {
+// blendShare returns the share of a blend that should come from parent2, with each
+// parent weighted by its inverse fitness (lower fitness is better). It falls back to an
+// even split when the fitnesses can't be compared, e.g. before evaluation.
+func blendShare(fitness1, fitness2 float64) float64 {
+	sum := fitness1 + fitness2
+	if sum <= 0 || math.IsInf(sum, 0) || math.IsNaN(sum) {
+		return 0.5
+	}
+	// 1/f2 / (1/f1 + 1/f2) simplifies to f1 / (f1 + f2)
+	return fitness1 / sum
+}
+
+// crossoverPoint performs a multi-point crossover between two parent individuals.
+// It randomly chooses either horizontal or vertical splits, cuts the image at points
+// distinct split points and creates two children by alternating the bands between parents:
+//   - Horizontal: child1 takes the top band from parent1, the next band from parent2 and so on,
+//     child2 takes the complementary bands
+//   - Vertical: the same with bands running left to right
+//
+// With a single point this is the classic single-point crossover. When the image is too
+// small along the chosen axis, fewer splits are made.
+func crossoverPoint(rng *rand.Rand, parent1, parent2 *Individual, points int) (*Individual, *Individual) {
 	child1 := parent1.CreateBlankCopy()
 	child2 := parent2.CreateBlankCopy()
 
-	isHorizontal := rand.Float64() <= 0.5
+	isHorizontal := rng.Float64() <= 0.5
 	bounds := child1.Image.Bounds()
 	stride := child1.Image.Stride
 
+	length := bounds.Dx()
 	if isHorizontal {
-		splitPoint := rand.Intn(bounds.Dy()-1) + 1
-		// Child 1: upper from parent1, lower from parent2
-		copy(child1.Image.Pix[:splitPoint*stride], parent1.Image.Pix[:splitPoint*stride])
-		copy(child1.Image.Pix[splitPoint*stride:], parent2.Image.Pix[splitPoint*stride:])
-		// Child 2: upper from parent2, lower from parent1
-		copy(child2.Image.Pix[:splitPoint*stride], parent2.Image.Pix[:splitPoint*stride])
-		copy(child2.Image.Pix[splitPoint*stride:], parent1.Image.Pix[splitPoint*stride:])
+		length = bounds.Dy()
+	}
+	splits := splitPoints(rng, length, points)
+
+	// fromParent1 reports whether position p along the split axis falls in an even band
+	fromParent1 := func(p int) bool {
+		return sort.SearchInts(splits, p+1)%2 == 0
+	}
+
+	if isHorizontal {
+		for y := 0; y < bounds.Dy(); y++ {
+			row := y * stride
+			src1, src2 := parent1, parent2
+			if !fromParent1(y) {
+				src1, src2 = parent2, parent1
+			}
+			copy(child1.Image.Pix[row:row+stride], src1.Image.Pix[row:row+stride])
+			copy(child2.Image.Pix[row:row+stride], src2.Image.Pix[row:row+stride])
+		}
 	} else {
-		splitPoint := rand.Intn(bounds.Dx()-1) + 1
+		// Column band boundaries as byte offsets within a row
+		edges := append([]int{0}, splits...)
+		edges = append(edges, bounds.Dx())
 		for y := 0; y < bounds.Dy(); y++ {
-			i := y * stride
-			// Child 1: left from parent1, right from parent2
-			copy(child1.Image.Pix[i:i+splitPoint*4], parent1.Image.Pix[i:i+splitPoint*4])
-			copy(child1.Image.Pix[i+splitPoint*4:i+stride], parent2.Image.Pix[i+splitPoint*4:i+stride])
-			// Child 2: left from parent2, right from parent1
-			copy(child2.Image.Pix[i:i+splitPoint*4], parent2.Image.Pix[i:i+splitPoint*4])
-			copy(child2.Image.Pix[i+splitPoint*4:i+stride], parent1.Image.Pix[i+splitPoint*4:i+stride])
+			row := y * stride
+			for b := 0; b+1 < len(edges); b++ {
+				start, end := row+edges[b]*4, row+edges[b+1]*4
+				src1, src2 := parent1, parent2
+				if b%2 == 1 {
+					src1, src2 = parent2, parent1
+				}
+				copy(child1.Image.Pix[start:end], src1.Image.Pix[start:end])
+				copy(child2.Image.Pix[start:end], src2.Image.Pix[start:end])
+			}
 		}
 	}
 
 	return child1, child2
 }
 
+// splitPoints returns up to n distinct, sorted split positions in [1, length-1].
+func splitPoints(rng *rand.Rand, length, n int) []int {
+	n = mathutil.Min(n, length-1)
+	if n <= 0 {
+		return nil
+	}
+	if n == 1 {
+		return []int{rng.Intn(length-1) + 1}
+	}
+	splits := rng.Perm(length - 1)[:n]
+	for i := range splits {
+		splits[i]++
+	}
+	sort.Ints(splits)
+	return splits
+}
+
 // gaussianPerturbationCrossover creates two children by averaging the pixel values of both parents
 // and then adding/subtracting a small amount of Gaussian noise to create variation.
 // This method is useful for making subtle changes while preserving the overall image structure:
 // - Child1 receives the parents' average pixel values plus small Gaussian noise
 // - Child2 receives the parents' average pixel values minus small Gaussian noise
 // The results are clamped to ensure valid pixel values (0-255)
-func gaussianPerturbationCrossover(parent1, parent2 *Individual) (*Individual, *Individual) {
+func gaussianPerturbationCrossover(rng *rand.Rand, parent1, parent2 *Individual, noiseScale float64) (*Individual, *Individual) {
 	child1 := parent1.CreateBlankCopy()
 	child2 := parent2.CreateBlankCopy()
 
@@ -128,7 +357,7 @@ func gaussianPerturbationCrossover(parent1, parent2 *Individual) (*Individual, *
 	for y := 0; y < bounds.Dy(); y++ {
 		i := y * child1.Image.Stride
 
-		noise := rand.Float64() * gaussianNoiseScale // Small Gaussian noise
+		noise := rng.Float64() * noiseScale // Small Gaussian noise
 
 		for x := 0; x < bounds.Dx(); x++ {
 			idx := i + x*4
@@ -147,15 +376,15 @@ func gaussianPerturbationCrossover(parent1, parent2 *Individual) (*Individual, *
 			meanA := uint8((mean >> 24) & 0xFF)
 
 			// Apply Gaussian noise
-			child1.Image.Pix[idx] = uint8(mathutil.Clamp(float64(meanR)+noise, 0, 255))
-			child1.Image.Pix[idx+1] = uint8(mathutil.Clamp(float64(meanG)+noise, 0, 255))
-			child1.Image.Pix[idx+2] = uint8(mathutil.Clamp(float64(meanB)+noise, 0, 255))
-			child1.Image.Pix[idx+3] = uint8(mathutil.Clamp(float64(meanA)+noise, 0, 255))
-
-			child2.Image.Pix[idx] = uint8(mathutil.Clamp(float64(meanR)-noise, 0, 255))
-			child2.Image.Pix[idx+1] = uint8(mathutil.Clamp(float64(meanG)-noise, 0, 255))
-			child2.Image.Pix[idx+2] = uint8(mathutil.Clamp(float64(meanB)-noise, 0, 255))
-			child2.Image.Pix[idx+3] = uint8(mathutil.Clamp(float64(meanA)-noise, 0, 255))
+			child1.Image.Pix[idx] = mathutil.ClampUint8(float64(meanR) + noise)
+			child1.Image.Pix[idx+1] = mathutil.ClampUint8(float64(meanG) + noise)
+			child1.Image.Pix[idx+2] = mathutil.ClampUint8(float64(meanB) + noise)
+			child1.Image.Pix[idx+3] = mathutil.ClampUint8(float64(meanA) + noise)
+
+			child2.Image.Pix[idx] = mathutil.ClampUint8(float64(meanR) - noise)
+			child2.Image.Pix[idx+1] = mathutil.ClampUint8(float64(meanG) - noise)
+			child2.Image.Pix[idx+2] = mathutil.ClampUint8(float64(meanB) - noise)
+			child2.Image.Pix[idx+3] = mathutil.ClampUint8(float64(meanA) - noise)
 		}
 	}
 
@@ -165,29 +394,112 @@ func gaussianPerturbationCrossover(parent1, parent2 *Individual) (*Individual, *
 // patchCrossover creates two children by swapping rectangular patches between the parents.
 // It works by:
 // - Creating exact copies of both parents
-// - Dividing the image into small patches of patchSize
-// - For each patch, having a % chance to swap that patch between the children
+// - Dividing the image into square patches of size pixels
+// - For each patch, having a swapProb chance to swap that patch between the children
 // This method preserves local structure within patches while creating diversity
-// by recombining different regions from both parents.
-func patchCrossover(parent1, parent2 *Individual) (*Individual, *Individual) {
+// by recombining different regions from both parents. Larger patches preserve more
+// structure, smaller ones mix more.
+func patchCrossover(rng *rand.Rand, parent1, parent2 *Individual, size int, swapProb float64) (*Individual, *Individual) {
 	child1 := parent1.CreateCopy()
 	child2 := parent2.CreateCopy()
+	// Swapped patches no longer match either shape list
+	child1.Shapes, child2.Shapes = nil, nil
+
+	bounds := child1.Image.Bounds()
+
+	for y := 0; y < bounds.Dy(); y += size {
+		for x := 0; x < bounds.Dx(); x += size {
+			if rng.Float64() < swapProb {
+				// Copy the patch row by row; each image is indexed with its own stride
+				// since the parents may be sub-images or have padded rows
+				rowBytes := mathutil.Min(size, bounds.Dx()-x) * 4
+				for dy := 0; dy < size && (y+dy) < bounds.Dy(); dy++ {
+					c1 := (y+dy)*child1.Image.Stride + x*4
+					c2 := (y+dy)*child2.Image.Stride + x*4
+					p1 := (y+dy)*parent1.Image.Stride + x*4
+					p2 := (y+dy)*parent2.Image.Stride + x*4
+					copy(child1.Image.Pix[c1:c1+rowBytes], parent2.Image.Pix[p2:p2+rowBytes])
+					copy(child2.Image.Pix[c2:c2+rowBytes], parent1.Image.Pix[p1:p1+rowBytes])
+				}
+			}
+		}
+	}
+
+	return child1, child2
+}
+
+// uniformCrossover creates two children by picking every pixel from a random parent.
+// child1 takes each pixel from parent1 or parent2 with equal probability and child2 takes
+// it from the other parent, so the children are complementary. Rows are processed in
+// parallel strips, each with its own random source seeded from rng.
+func uniformCrossover(rng *rand.Rand, parent1, parent2 *Individual) (*Individual, *Individual) {
+	child1 := parent1.CreateBlankCopy()
+	child2 := parent2.CreateBlankCopy()
 
 	bounds := child1.Image.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	stride := child1.Image.Stride
+	numGoroutines := mathutil.Max(mathutil.Min(crossoverStrips, height), 1)
+	rowsPerGoroutine := height / numGoroutines
+	var wg sync.WaitGroup
 
-	for y := 0; y < bounds.Dy(); y += patchSize {
-		for x := 0; x < bounds.Dx(); x += patchSize {
-			if rand.Float64() < patchCrossoverSwapProbability {
-				for dy := 0; dy < patchSize && (y+dy) < bounds.Dy(); dy++ {
-					for dx := 0; dx < patchSize && (x+dx) < bounds.Dx(); dx++ {
-						idx := ((y+dy)*bounds.Dx() + (x + dx)) * 4
-						copy(child1.Image.Pix[idx:idx+4], parent2.Image.Pix[idx:idx+4])
-						copy(child2.Image.Pix[idx:idx+4], parent1.Image.Pix[idx:idx+4])
+	for i := 0; i < numGoroutines; i++ {
+		startY := i * rowsPerGoroutine
+		endY := startY + rowsPerGoroutine
+		if i == numGoroutines-1 {
+			endY = height
+		}
+		stripRng := rand.New(mathutil.NewSplitMix64(rng.Int63()))
+
+		wg.Add(1)
+		go func(startY, endY int, stripRng *rand.Rand) {
+			defer wg.Done()
+			var bits uint64
+			for y := startY; y < endY; y++ {
+				row := y * stride
+				for x := 0; x < width; x++ {
+					// Draw 64 coin flips at a time
+					if x%64 == 0 {
+						bits = stripRng.Uint64()
 					}
+					idx := row + x*4
+					src1, src2 := parent1.Image.Pix, parent2.Image.Pix
+					if bits&1 == 1 {
+						src1, src2 = src2, src1
+					}
+					bits >>= 1
+					copy(child1.Image.Pix[idx:idx+4], src1[idx:idx+4])
+					copy(child2.Image.Pix[idx:idx+4], src2[idx:idx+4])
 				}
 			}
+		}(startY, endY, stripRng)
+	}
+
+	wg.Wait()
+	return child1, child2
+}
+
+// shapeCrossover creates two children by exchanging whole polygons between the parents'
+// shape lists. Each child keeps its own parent's number of shapes: polygons at the same
+// draw position are swapped with equal probability, and so is the background. Parents
+// without shape lists fall back to uniform crossover.
+func shapeCrossover(rng *rand.Rand, parent1, parent2 *Individual) (*Individual, *Individual) {
+	if !parent1.hasShapes() || !parent2.hasShapes() {
+		return uniformCrossover(rng, parent1, parent2)
+	}
+	child1 := parent1.CreateCopy()
+	child2 := parent2.CreateCopy()
+
+	if rng.Intn(2) == 1 {
+		child1.Background, child2.Background = child2.Background, child1.Background
+	}
+	for i := 0; i < mathutil.Min(len(child1.Shapes), len(child2.Shapes)); i++ {
+		if rng.Intn(2) == 1 {
+			child1.Shapes[i], child2.Shapes[i] = child2.Shapes[i], child1.Shapes[i]
 		}
 	}
 
+	child1.rasterize()
+	child2.rasterize()
 	return child1, child2
 }
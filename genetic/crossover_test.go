@@ -0,0 +1,368 @@
+package genetic
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// newSolidIndividual returns an individual whose image is filled with c.
+func newSolidIndividual(width, height int, c color.RGBA) *Individual {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return &Individual{Image: img}
+}
+
+// swappedPixels counts the pixels of child that came from donor instead of its own parent.
+func swappedPixels(child *Individual, donor color.RGBA) int {
+	count := 0
+	bounds := child.Image.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if child.Image.RGBAAt(x, y) == donor {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func TestPatchCrossoverPatchSize(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	rng := rand.New(rand.NewSource(1))
+	parent1 := newSolidIndividual(32, 32, black)
+	parent2 := newSolidIndividual(32, 32, white)
+
+	// Per-pixel swaps mix the children finely
+	child1, child2 := patchCrossover(rng, parent1, parent2, 1, 0.5)
+	swapped := swappedPixels(child1, white)
+	if swapped == 0 || swapped == 32*32 {
+		t.Errorf("Patch size 1 swapped %d of %d pixels, expected a mix", swapped, 32*32)
+	}
+	if swappedPixels(child2, black) != swapped {
+		t.Errorf("Children are not complementary at patch size 1")
+	}
+
+	// Every patch of an 8px crossover is taken from a single parent
+	child1, _ = patchCrossover(rng, parent1, parent2, 8, 0.5)
+	for py := 0; py < 32; py += 8 {
+		for px := 0; px < 32; px += 8 {
+			want := child1.Image.RGBAAt(px, py)
+			for y := py; y < py+8; y++ {
+				for x := px; x < px+8; x++ {
+					if child1.Image.RGBAAt(x, y) != want {
+						t.Fatalf("Patch at (%d,%d) is not uniform", px, py)
+					}
+				}
+			}
+		}
+	}
+
+	// A patch covering the whole image swaps all or nothing
+	for i := 0; i < 10; i++ {
+		child1, _ = patchCrossover(rng, parent1, parent2, 64, 0.5)
+		swapped = swappedPixels(child1, white)
+		if swapped != 0 && swapped != 32*32 {
+			t.Fatalf("Image-sized patch swapped %d pixels, expected all or none", swapped)
+		}
+	}
+}
+
+func TestPatchCrossoverSwapProbability(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	rng := rand.New(rand.NewSource(1))
+	parent1 := newSolidIndividual(16, 16, black)
+	parent2 := newSolidIndividual(16, 16, white)
+
+	child1, _ := patchCrossover(rng, parent1, parent2, 4, 1)
+	if swapped := swappedPixels(child1, white); swapped != 16*16 {
+		t.Errorf("Swap probability 1 swapped %d pixels, expected all", swapped)
+	}
+	child1, _ = patchCrossover(rng, parent1, parent2, 4, 0)
+	if swapped := swappedPixels(child1, white); swapped != 0 {
+		t.Errorf("Swap probability 0 swapped %d pixels, expected none", swapped)
+	}
+}
+
+func TestCrossoverWeightsSingleOperator(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	cases := []struct {
+		weights CrossoverWeights
+		want    crossoverOperator
+	}{
+		{CrossoverWeights{Blend: 1}, opBlend},
+		{CrossoverWeights{Point: 1}, opPoint},
+		{CrossoverWeights{Gaussian: 1}, opGaussian},
+		{CrossoverWeights{Patch: 1}, opPatch},
+		{CrossoverWeights{Uniform: 1}, opUniform},
+	}
+	for _, tc := range cases {
+		for i := 0; i < 1000; i++ {
+			if got := tc.weights.pick(rng); got != tc.want {
+				t.Fatalf("Weights %+v picked operator %d, expected %d", tc.weights, got, tc.want)
+			}
+		}
+	}
+
+	// A patch-only crossover of solid parents yields children made of the two colors only
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	ga := &GeneticAlgorithm{CrossoverWeights: CrossoverWeights{Patch: 1}, PatchSize: 4, PatchSwapProbability: 0.5}
+	child1, _ := ga.Crossover(rng, newSolidIndividual(16, 16, black), newSolidIndividual(16, 16, white))
+	if swappedPixels(child1, black)+swappedPixels(child1, white) != 16*16 {
+		t.Errorf("Patch-only crossover produced blended pixels")
+	}
+}
+
+func TestParseCrossoverWeights(t *testing.T) {
+	cw, err := ParseCrossoverWeights("blend=1, point=2,patch=1")
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	want := CrossoverWeights{Blend: 0.25, Point: 0.5, Patch: 0.25}
+	if cw != want {
+		t.Errorf("Got %+v, expected %+v", cw, want)
+	}
+
+	for _, bad := range []string{"", "blend", "blend=x", "mystery=1", "blend=-1,point=2", "blend=0,point=0"} {
+		if _, err := ParseCrossoverWeights(bad); err == nil {
+			t.Errorf("Expected an error for %q", bad)
+		}
+	}
+}
+
+func TestShapeCrossoverKeepsShapeCounts(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	shapes := ShapeConfig{MinShapes: 6, MaxShapes: 6, MinVertices: 3, MaxVertices: 5}
+	parent1 := newIndividual(rng, 20, 20, RandomRGBA(rng), shapes, nil)
+	parent2 := newIndividual(rng, 20, 20, RandomRGBA(rng), shapes, nil)
+
+	child1, child2 := shapeCrossover(rng, parent1, parent2)
+	for i, child := range []*Individual{child1, child2} {
+		if len(child.Shapes) != 6 {
+			t.Fatalf("Child %d has %d shapes, expected 6", i+1, len(child.Shapes))
+		}
+		// Every polygon comes from the parent at the same draw position
+		for j, polygon := range child.Shapes {
+			if !reflect.DeepEqual(polygon, parent1.Shapes[j]) && !reflect.DeepEqual(polygon, parent2.Shapes[j]) {
+				t.Fatalf("Child %d shape %d comes from neither parent", i+1, j)
+			}
+		}
+		redrawn := child.CreateCopy()
+		redrawn.rasterize()
+		if !bytes.Equal(redrawn.Image.Pix, child.Image.Pix) {
+			t.Errorf("Child %d image doesn't match its shape list", i+1)
+		}
+	}
+}
+
+// bandColors returns the color of each row of img, or of each column when vertical is set,
+// and false if any row (or column) isn't a single color.
+func bandColors(img *image.RGBA, vertical bool) ([]color.RGBA, bool) {
+	bounds := img.Bounds()
+	outer, inner := bounds.Dy(), bounds.Dx()
+	if vertical {
+		outer, inner = inner, outer
+	}
+	colors := make([]color.RGBA, outer)
+	for o := 0; o < outer; o++ {
+		for i := 0; i < inner; i++ {
+			x, y := i, o
+			if vertical {
+				x, y = o, i
+			}
+			c := img.RGBAAt(x, y)
+			if i == 0 {
+				colors[o] = c
+			} else if c != colors[o] {
+				return nil, false
+			}
+		}
+	}
+	return colors, true
+}
+
+func TestCrossoverPointTwoPointsAlternatesBands(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	rng := rand.New(rand.NewSource(1))
+	parent1 := newSolidIndividual(20, 20, black)
+	parent2 := newSolidIndividual(20, 20, white)
+
+	for i := 0; i < 100; i++ {
+		child1, child2 := crossoverPoint(rng, parent1, parent2, 2)
+
+		vertical := false
+		colors1, ok := bandColors(child1.Image, vertical)
+		if !ok {
+			vertical = true
+			if colors1, ok = bandColors(child1.Image, vertical); !ok {
+				t.Fatalf("Child isn't split into horizontal or vertical bands")
+			}
+		}
+		colors2, _ := bandColors(child2.Image, vertical)
+
+		// parent1, parent2, parent1 for child1 and the complement for child2
+		bands := []color.RGBA{colors1[0]}
+		for j, c := range colors1 {
+			if c != bands[len(bands)-1] {
+				bands = append(bands, c)
+			}
+			if colors2[j] == c {
+				t.Fatalf("Children aren't complementary at band position %d", j)
+			}
+		}
+		if len(bands) != 3 || bands[0] != black || bands[1] != white || bands[2] != black {
+			t.Fatalf("Expected black, white, black bands, got %v", bands)
+		}
+	}
+}
+
+func TestCrossoverPointTinyImages(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, size := range []image.Point{{1, 1}, {1, 5}, {5, 1}, {2, 2}} {
+		parent1 := newSolidIndividual(size.X, size.Y, color.RGBA{0, 0, 0, 255})
+		parent2 := newSolidIndividual(size.X, size.Y, color.RGBA{255, 255, 255, 255})
+		for i := 0; i < 20; i++ {
+			crossoverPoint(rng, parent1, parent2, 3)
+		}
+	}
+}
+
+func TestUniformCrossoverPicksWholePixels(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const width, height = 37, 23
+	parent1 := &Individual{Image: image.NewRGBA(image.Rect(0, 0, width, height))}
+	rng.Read(parent1.Image.Pix)
+	// parent2 differs from parent1 in every channel of every pixel
+	parent2 := parent1.CreateCopy()
+	for i := range parent2.Image.Pix {
+		parent2.Image.Pix[i] ^= 0x80
+	}
+
+	child1, child2 := uniformCrossover(rng, parent1, parent2)
+
+	fromParent1 := 0
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c1, c2 := child1.Image.RGBAAt(x, y), child2.Image.RGBAAt(x, y)
+			p1, p2 := parent1.Image.RGBAAt(x, y), parent2.Image.RGBAAt(x, y)
+			switch {
+			case c1 == p1 && c2 == p2:
+				fromParent1++
+			case c1 == p2 && c2 == p1:
+			default:
+				t.Fatalf("Pixel (%d,%d) is not a complementary pick from the parents: %v %v", x, y, c1, c2)
+			}
+		}
+	}
+	if total := width * height; fromParent1 < total/3 || fromParent1 > 2*total/3 {
+		t.Errorf("child1 took %d of %d pixels from parent1, expected about half", fromParent1, total)
+	}
+}
+
+func TestBlendCrossoverFavorsFitterParent(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	parent1 := newSolidIndividual(16, 16, color.RGBA{0, 0, 0, 255})
+	parent2 := newSolidIndividual(16, 16, color.RGBA{255, 255, 255, 255})
+	parent1.Fitness, parent2.Fitness = 1, 100
+
+	total, n := 0, 0
+	for i := 0; i < 20; i++ {
+		child1, child2 := blendCrossover(rng, parent1, parent2, defaultBlendAlphaSpread)
+		for _, child := range []*Individual{child1, child2} {
+			for j := 0; j < len(child.Image.Pix); j += 4 {
+				total += int(child.Image.Pix[j])
+				n++
+			}
+		}
+	}
+	if mean := float64(total) / float64(n); mean >= 255.0/2 {
+		t.Errorf("Mean child red channel %.1f is closer to the weaker parent (255) than the fitter one (0)", mean)
+	}
+}
+
+func TestBlendCrossoverFillsEveryRow(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	white := color.RGBA{255, 255, 255, 255}
+	// Heights that don't divide evenly into strips, including fewer rows than goroutines
+	for _, height := range []int{1, 3, 7, 13, 101} {
+		child1, child2 := blendCrossover(rng, newSolidIndividual(5, height, white), newSolidIndividual(5, height, white), defaultBlendAlphaSpread)
+		if swappedPixels(child1, white) != 5*height || swappedPixels(child2, white) != 5*height {
+			t.Errorf("Height %d: blended children have unfilled pixels", height)
+		}
+	}
+}
+
+func TestGaussianNoiseScaleIncreasesVariance(t *testing.T) {
+	gray := color.RGBA{128, 128, 128, 128}
+	// Variance of the red channel across both children of identical flat parents
+	variance := func(scale float64) float64 {
+		rng := rand.New(rand.NewSource(1))
+		child1, child2 := gaussianPerturbationCrossover(rng, newSolidIndividual(16, 64, gray), newSolidIndividual(16, 64, gray), scale)
+		var sum, sumSq, n float64
+		for _, child := range []*Individual{child1, child2} {
+			for j := 0; j < len(child.Image.Pix); j += 4 {
+				v := float64(child.Image.Pix[j])
+				sum += v
+				sumSq += v * v
+				n++
+			}
+		}
+		mean := sum / n
+		return sumSq/n - mean*mean
+	}
+
+	low, high := variance(defaultGaussianNoiseScale), variance(20)
+	if high <= low || high < 10 {
+		t.Errorf("Expected a noise scale of 20 to give clearly more variance than %v, got %f vs %f", defaultGaussianNoiseScale, high, low)
+	}
+}
+
+// newPaddedIndividual returns a solid individual whose rows are followed by padding
+// bytes, so its Stride exceeds 4*width.
+func newPaddedIndividual(width, height int, c color.RGBA) *Individual {
+	const padding = 12
+	img := &image.RGBA{
+		Pix:    make([]uint8, (width*4+padding)*height),
+		Stride: width*4 + padding,
+		Rect:   image.Rect(0, 0, width, height),
+	}
+	for i := range img.Pix {
+		img.Pix[i] = 0x77
+	}
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return &Individual{Image: img}
+}
+
+func TestPatchCrossoverPaddedStride(t *testing.T) {
+	black := color.RGBA{0, 0, 0, 255}
+	white := color.RGBA{255, 255, 255, 255}
+	rng := rand.New(rand.NewSource(1))
+	const width, height, size = 18, 13, 4
+	parent1 := newPaddedIndividual(width, height, black)
+	parent2 := newPaddedIndividual(width, height, white)
+
+	child1, child2 := patchCrossover(rng, parent1, parent2, size, 0.5)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c1, c2 := child1.Image.RGBAAt(x, y), child2.Image.RGBAAt(x, y)
+			if (c1 != black && c1 != white) || (c2 != black && c2 != white) || c1 == c2 {
+				t.Fatalf("Pixel (%d,%d) isn't a complementary parent pixel: %v %v", x, y, c1, c2)
+			}
+			// Every pixel matches the top-left pixel of its patch
+			if corner := child1.Image.RGBAAt(x/size*size, y/size*size); c1 != corner {
+				t.Fatalf("Pixel (%d,%d) was swapped separately from its patch", x, y)
+			}
+		}
+	}
+	if swapped := swappedPixels(child1, white); swapped == 0 || swapped == width*height {
+		t.Errorf("Expected a mix of patches, got %d swapped pixels", swapped)
+	}
+}
@@ -0,0 +1,95 @@
+package genetic
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// Distance selects how far apart a candidate pixel and a target pixel are. Each gives
+// fitness a different scale, so fitness values are only comparable under one distance.
+type Distance int
+
+const (
+	// DistanceL2 is the Euclidean distance over the RGBA channels. Fitness is the root of
+	// the mean squared distance, the usual RMSE.
+	DistanceL2 Distance = iota
+	// DistanceL1 is the Manhattan distance, the sum of the absolute channel differences.
+	// Fitness is its mean, with no squares or root.
+	DistanceL1
+	// DistanceLInf is the Chebyshev distance, the largest absolute channel difference, so
+	// only a pixel's worst channel counts. Fitness is its mean.
+	DistanceLInf
+)
+
+// ParseDistance converts a distance name (l1, l2 or linf) to a Distance.
+func ParseDistance(name string) (Distance, error) {
+	switch name {
+	case "l2":
+		return DistanceL2, nil
+	case "l1":
+		return DistanceL1, nil
+	case "linf":
+		return DistanceLInf, nil
+	}
+	return DistanceL2, fmt.Errorf("unknown distance %q, expected l1, l2 or linf", name)
+}
+
+func (d Distance) String() string {
+	switch d {
+	case DistanceL1:
+		return "l1"
+	case DistanceLInf:
+		return "linf"
+	}
+	return "l2"
+}
+
+// fitness turns the total of calculateRegionFitness over pixels pixels into a fitness.
+func (d Distance) fitness(total, pixels float64) float64 {
+	if d == DistanceL2 {
+		return math.Sqrt(total / pixels)
+	}
+	return total / pixels
+}
+
+// total is the inverse of fitness: the calculateRegionFitness total over pixels pixels
+// that gives fitness.
+func (d Distance) total(fitness, pixels float64) float64 {
+	if d == DistanceL2 {
+		return fitness * fitness * pixels
+	}
+	return fitness * pixels
+}
+
+// calculateRegionDistance sums the L1 or L-infinity distances between the pixels of
+// rows [startY, endY). Channel differences of at most deadband count as zero.
+func calculateRegionDistance(img1, img2 *image.RGBA, startY, endY, deadband int, distance Distance) float64 {
+	var difference float64
+	width := img1.Bounds().Dx()
+
+	for y := startY; y < endY; y++ {
+		row1 := img1.Pix[y*img1.Stride : y*img1.Stride+width*4]
+		row2 := img2.Pix[y*img2.Stride : y*img2.Stride+width*4]
+		for j := 0; j < len(row1); j += 4 {
+			sum, largest := 0, 0
+			for c := 0; c < 4; c++ {
+				d := mathutil.Abs(int(row1[j+c]) - int(row2[j+c]))
+				if d <= deadband {
+					continue
+				}
+				sum += d
+				largest = mathutil.Max(largest, d)
+			}
+			if distance == DistanceLInf {
+				difference += float64(largest)
+			} else {
+				difference += float64(sum)
+			}
+		}
+	}
+
+	return difference
+}
@@ -0,0 +1,66 @@
+package genetic
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestDistancePerPixel(t *testing.T) {
+	// Channel differences of 3, 4, 0 and 12, then a matching pixel
+	candidate := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	target := image.NewRGBA(image.Rect(0, 0, 2, 1))
+	candidate.SetRGBA(0, 0, color.RGBA{R: 13, G: 20, B: 30, A: 255})
+	target.SetRGBA(0, 0, color.RGBA{R: 10, G: 24, B: 30, A: 243})
+	candidate.SetRGBA(1, 0, color.RGBA{R: 1, G: 2, B: 3, A: 4})
+	target.SetRGBA(1, 0, color.RGBA{R: 1, G: 2, B: 3, A: 4})
+
+	for _, tc := range []struct {
+		distance Distance
+		deadband int
+		want     float64
+	}{
+		// The matching pixel halves the squared distance and the plain distances
+		{DistanceL2, 0, math.Sqrt(13 * 13 / 2.0)},
+		{DistanceL1, 0, 19 / 2.0},
+		{DistanceLInf, 0, 12 / 2.0},
+		{DistanceL1, 3, 16 / 2.0},
+		{DistanceLInf, 12, 0},
+	} {
+		ind := &Individual{Image: candidate}
+		ind.calculateFitness(target, tc.deadband, tc.distance)
+		if math.Abs(ind.Fitness-tc.want) > 1e-9 {
+			t.Errorf("%s with deadband %d: expected %f, got %f", tc.distance, tc.deadband, tc.want, ind.Fitness)
+		}
+	}
+}
+
+func TestWithDistanceSetsFitness(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 4, 1, 0.1, 2, WithSeed(1), WithDistance(DistanceL1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	for _, ind := range ga.Population {
+		want := calculateRegionDistance(ind.Image, target, 0, 16, 0, DistanceL1) / (16 * 16)
+		if math.Abs(ind.Fitness-want) > 1e-9 {
+			t.Errorf("Expected the mean L1 distance %f, got %f", want, ind.Fitness)
+		}
+	}
+
+	if _, err := NewGeneticAlgorithm(target, 4, 1, 0.1, 2, WithDistance(DistanceLInf), WithFitnessSample(0.5)); err == nil {
+		t.Error("Expected an error combining linf with fitness sampling")
+	}
+}
+
+func TestParseDistance(t *testing.T) {
+	for _, d := range []Distance{DistanceL1, DistanceL2, DistanceLInf} {
+		if got, err := ParseDistance(d.String()); err != nil || got != d {
+			t.Errorf("ParseDistance(%q) = %v, %v", d.String(), got, err)
+		}
+	}
+	if _, err := ParseDistance("cosine"); err == nil {
+		t.Error("Expected an error for an unknown distance")
+	}
+}
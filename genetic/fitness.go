@@ -0,0 +1,213 @@
+package genetic
+
+import (
+	"image"
+	"math"
+	"runtime"
+	"sync"
+
+	"github.com/bishal0602/chaotic-canvas/imageio"
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// evaluate calculates the fitness of ind against the target, including any
+// optional terms enabled on the algorithm.
+func (ga *GeneticAlgorithm) evaluate(ind *Individual) {
+	ga.evaluateStep(ind, ga.sampleStep)
+}
+
+// evaluateExact is evaluate over every pixel, even when fitness sampling is enabled.
+func (ga *GeneticAlgorithm) evaluateExact(ind *Individual) {
+	ga.evaluateStep(ind, 1)
+}
+
+// evaluateStep is evaluate comparing every step-th pixel.
+func (ga *GeneticAlgorithm) evaluateStep(ind *Individual, step int) {
+	if ga.FitnessFunc != nil {
+		ind.Fitness = ga.FitnessFunc(ind.Image, ga.TargetRGBA)
+	} else if step > 1 {
+		ind.calculateFitnessSampled(ga.TargetRGBA, ga.fitnessDeadband, step, ga.sampleOffset)
+	} else if len(ga.targetPyramid) > 1 {
+		ind.calculateFitnessPyramid(ga.targetPyramid, ga.fitnessDeadband, ga.distance)
+	} else {
+		ind.calculateFitness(ga.TargetRGBA, ga.fitnessDeadband, ga.distance)
+	}
+	ga.addPenalties(ind)
+}
+
+// EvaluateAtMost calculates the fitness of ind like the algorithm does, but stops
+// comparing pixels as soon as the fitness is certain to exceed limit, which makes
+// rejecting clearly worse candidates cheap. The penalty terms are never negative, so a
+// distance past limit already rules ind out. It reports whether the fitness is at most
+// limit; only then is ind.Fitness exact, and otherwise it is at least limit. With a
+// custom fitness function, fitness sampling or pyramid fitness there is nothing to cut
+// short, and the full fitness is calculated.
+func (ga *GeneticAlgorithm) EvaluateAtMost(ind *Individual, limit float64) bool {
+	if ga.FitnessFunc != nil || ga.sampleStep > 1 || len(ga.targetPyramid) > 1 {
+		ga.evaluate(ind)
+		return ind.Fitness <= limit
+	}
+	if !ind.calculateFitnessAtMost(ga.TargetRGBA, ga.fitnessDeadband, ga.distance, limit) {
+		return false
+	}
+	ga.addPenalties(ind)
+	return ind.Fitness <= limit
+}
+
+// addPenalties adds the optional penalty terms enabled on the algorithm to ind's fitness.
+func (ga *GeneticAlgorithm) addPenalties(ind *Individual) {
+	if ga.contrastWeight > 0 {
+		ind.Fitness += ga.contrastWeight * contrastPenalty(ga.targetStats, computeImageStats(ind.Image))
+	}
+	if ga.histogramWeight > 0 {
+		ind.Fitness += ga.histogramWeight * histogramPenalty(ga.targetHistogram, computeHistogram(ind.Image))
+	}
+	if ga.avoidRGBA != nil && ga.avoidWeight > 0 {
+		ind.Fitness += ga.avoidWeight * avoidPenalty(ind.Image, ga.avoidRGBA)
+	}
+}
+
+// EvaluateBatch calculates the fitness of inds like the algorithm does, penalty terms
+// included, distributing whole individuals across workers. Use it rather than
+// CalculateFitnessBatch to score individuals that are compared with the population.
+func (ga *GeneticAlgorithm) EvaluateBatch(inds []*Individual) {
+	ga.evaluateBatch(inds)
+}
+
+// evaluateBatch evaluates inds in parallel, one individual per job. The same individual
+// may appear more than once, e.g. a parent kept twice by selection; it is evaluated once.
+func (ga *GeneticAlgorithm) evaluateBatch(inds []*Individual) {
+	seen := make(map[*Individual]bool, len(inds))
+	jobs := make(chan *Individual, len(inds))
+	for _, ind := range inds {
+		if !seen[ind] {
+			seen[ind] = true
+			jobs <- ind
+		}
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < mathutil.Min(runtime.GOMAXPROCS(0), len(seen)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ind := range jobs {
+				ga.evaluate(ind)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+// buildPyramid returns img followed by successively half-sized copies, up to levels
+// images in total. It stops early once a level would have an empty dimension.
+func buildPyramid(img *image.RGBA, levels int) []*image.RGBA {
+	pyramid := []*image.RGBA{img}
+	for len(pyramid) < levels {
+		prev := pyramid[len(pyramid)-1]
+		maxDim := mathutil.Max(prev.Bounds().Dx(), prev.Bounds().Dy()) / 2
+		if maxDim < 1 {
+			break
+		}
+		next, ok := imageio.Resize(prev, maxDim).(*image.RGBA)
+		if !ok || next.Bounds().Empty() {
+			break
+		}
+		pyramid = append(pyramid, next)
+	}
+	return pyramid
+}
+
+// CalculateFitnessPyramid calculates the fitness as a weighted mean of the squared
+// error at every level of an image pyramid. Each coarser level counts twice as
+// much as the one above it, rewarding coarse structure over fine detail and noise.
+// targetPyramid must come from buildPyramid on the target.
+func (ind *Individual) CalculateFitnessPyramid(targetPyramid []*image.RGBA) {
+	ind.calculateFitnessPyramid(targetPyramid, 0, DistanceL2)
+}
+
+func (ind *Individual) calculateFitnessPyramid(targetPyramid []*image.RGBA, deadband int, distance Distance) {
+	candidatePyramid := buildPyramid(ind.Image, len(targetPyramid))
+
+	var weighted, totalWeight float64
+	weight := 1.0
+	for level, target := range targetPyramid {
+		candidate := candidatePyramid[level]
+		bounds := target.Bounds()
+		mean := calculateRegionFitness(candidate, target, 0, bounds.Dy(), deadband, distance) / float64(bounds.Dx()*bounds.Dy())
+
+		weighted += weight * mean
+		totalWeight += weight
+		weight *= 2
+	}
+
+	ind.Fitness = distance.fitness(weighted, totalWeight)
+}
+
+// imageStats holds global color statistics of an image on a 0-255 scale.
+type imageStats struct {
+	LumMean    float64
+	LumStdDev  float64
+	Saturation float64
+}
+
+// computeImageStats returns the mean and standard deviation of luminance and the
+// mean saturation of img.
+func computeImageStats(img *image.RGBA) imageStats {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	n := float64(width * height)
+	if n == 0 {
+		return imageStats{}
+	}
+
+	var lumSum, lumSqSum, satSum float64
+	for y := 0; y < height; y++ {
+		i := y * img.Stride
+		for x := 0; x < width; x++ {
+			idx := i + x*4
+			r, g, b := float64(img.Pix[idx]), float64(img.Pix[idx+1]), float64(img.Pix[idx+2])
+
+			lum := 0.299*r + 0.587*g + 0.114*b
+			lumSum += lum
+			lumSqSum += lum * lum
+
+			// HSV saturation scaled to 0-255
+			maxC := mathutil.Max(r, mathutil.Max(g, b))
+			minC := mathutil.Min(r, mathutil.Min(g, b))
+			if maxC > 0 {
+				satSum += (maxC - minC) / maxC * 255
+			}
+		}
+	}
+
+	mean := lumSum / n
+	variance := mathutil.Max(lumSqSum/n-mean*mean, 0)
+	return imageStats{
+		LumMean:    mean,
+		LumStdDev:  math.Sqrt(variance),
+		Saturation: satSum / n,
+	}
+}
+
+// maxImageDistance is the largest possible fitness between two images: every channel
+// of every pixel differs by 255.
+const maxImageDistance = 2 * 255
+
+// avoidPenalty grows as the candidate approaches the avoid image, from 0 when they are
+// as different as possible to maxImageDistance when they are identical, so it never
+// makes fitness negative.
+func avoidPenalty(candidate, avoid *image.RGBA) float64 {
+	bounds := avoid.Bounds()
+	difference := calculateRegionFitness(candidate, avoid, 0, bounds.Dy(), 0, DistanceL2)
+	return maxImageDistance - math.Sqrt(difference/float64(bounds.Dx()*bounds.Dy()))
+}
+
+// contrastPenalty measures how far the candidate's global brightness, contrast and
+// saturation are from the target's.
+func contrastPenalty(target, candidate imageStats) float64 {
+	return math.Abs(target.LumMean-candidate.LumMean) +
+		math.Abs(target.LumStdDev-candidate.LumStdDev) +
+		math.Abs(target.Saturation-candidate.Saturation)
+}
@@ -0,0 +1,385 @@
+package genetic
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"math/rand"
+	"sync/atomic"
+	"testing"
+)
+
+func TestContrastPenaltyFlatGray(t *testing.T) {
+	target := createBlackWhiteChecker(32, 32, 4)
+	gray := newSolidIndividual(32, 32, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+
+	targetStats := computeImageStats(target)
+	penalty := contrastPenalty(targetStats, computeImageStats(gray.Image))
+	// The checker has a luminance standard deviation of ~127 while flat gray has none
+	if penalty < 100 {
+		t.Errorf("Expected a large contrast penalty for flat gray, got %f", penalty)
+	}
+
+	if same := contrastPenalty(targetStats, targetStats); same != 0 {
+		t.Errorf("Expected no penalty for identical statistics, got %f", same)
+	}
+}
+
+func TestContrastWeightAddsToFitness(t *testing.T) {
+	target := createBlackWhiteChecker(32, 32, 4)
+
+	plain, err := NewGeneticAlgorithm(target, 2, 1, 0.05, 2)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	weighted, err := NewGeneticAlgorithm(target, 2, 1, 0.05, 2, WithContrastWeight(1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	gray := newSolidIndividual(32, 32, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+	plain.evaluate(gray)
+	base := gray.Fitness
+	weighted.evaluate(gray)
+	if gray.Fitness <= base+100 {
+		t.Errorf("Expected the contrast penalty to raise fitness well above %f, got %f", base, gray.Fitness)
+	}
+}
+
+func TestAvoidImagePushesAwayFromIt(t *testing.T) {
+	target := createBlackWhiteChecker(16, 16, 4)
+	// A flat gray is a close early approximation of the checker, so a plain run drifts toward it
+	avoid := newSolidIndividual(16, 16, color.RGBA{R: 128, G: 128, B: 128, A: 255}).Image
+
+	distanceFromAvoid := func(opts ...Option) float64 {
+		ga, err := NewGeneticAlgorithm(target, 20, 40, 0.1, 3, append(opts, WithSeed(1))...)
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		best, err := ga.Run(nil, 1)
+		if err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return maxImageDistance - avoidPenalty(best.Image, avoid)
+	}
+
+	plain := distanceFromAvoid()
+	avoiding := distanceFromAvoid(WithAvoidImage(avoid, 1))
+	if avoiding <= plain {
+		t.Errorf("Expected the avoiding run to end farther from the avoid image, got %f vs %f", avoiding, plain)
+	}
+}
+
+func TestAvoidImageSizeMismatch(t *testing.T) {
+	avoid := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	if _, err := NewGeneticAlgorithm(createBlackWhiteChecker(16, 16, 4), 2, 1, 0.05, 2, WithAvoidImage(avoid, 1)); err == nil {
+		t.Error("Expected an error for an avoid image of a different size")
+	}
+}
+
+func TestSampledFitnessTracksExact(t *testing.T) {
+	// Horizontal gradient target
+	width, height := 64, 48
+	target := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(x * 255 / (width - 1))
+			target.SetRGBA(x, y, color.RGBA{v, 255 - v, uint8(y * 5), 255})
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	exact := make([]float64, 40)
+	sampled := make([]float64, len(exact))
+	for i := range exact {
+		ind := NewIndividual(rng, width, height)
+		ind.CalculateFitness(target)
+		exact[i] = ind.Fitness
+
+		ind.calculateFitnessSampled(target, 0, 1, 0)
+		if math.Abs(ind.Fitness-exact[i]) > 1e-9 {
+			t.Fatalf("Individual %d: sampling every pixel gave %f, expected %f", i, ind.Fitness, exact[i])
+		}
+		ind.calculateFitnessSampled(target, 0, 10, 3)
+		sampled[i] = ind.Fitness
+	}
+
+	if r := correlation(exact, sampled); r < 0.95 {
+		t.Errorf("Expected 10%% sampled fitness to correlate strongly with exact fitness, got r = %f", r)
+	}
+}
+
+func TestRunWithFitnessSampleReturnsExactFitness(t *testing.T) {
+	target := createCheckerPattern(24, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 10, 5, 0.1, 3, WithSeed(1), WithFitnessSample(0.25))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	best, err := ga.Run(nil, 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	exact := best.CreateCopy()
+	exact.CalculateFitness(target)
+	if math.Abs(best.Fitness-exact.Fitness) > 1e-9 {
+		t.Errorf("Expected the result to be re-scored exactly: got %f, exact %f", best.Fitness, exact.Fitness)
+	}
+}
+
+func TestCustomFitnessFunc(t *testing.T) {
+	// Ignores the target entirely and rewards images with little red
+	var calls atomic.Int64
+	redness := func(candidate, target *image.RGBA) float64 {
+		calls.Add(1)
+		var sum float64
+		for i := 0; i < len(candidate.Pix); i += 4 {
+			sum += float64(candidate.Pix[i])
+		}
+		return sum / float64(len(candidate.Pix)/4)
+	}
+
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 12, 15, 0.2, 3, WithSeed(1), WithFitnessFunc(redness))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	initialBest := ga.Population[0].Fitness
+	best, err := ga.Run(nil, 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	if calls.Load() == 0 {
+		t.Fatal("Custom fitness function was never called")
+	}
+	for i, ind := range ga.Population {
+		if want := redness(ind.Image, target); ind.Fitness != want {
+			t.Fatalf("Individual %d has fitness %f, custom metric gives %f", i, ind.Fitness, want)
+		}
+		if i > 0 && ind.Fitness < ga.Population[i-1].Fitness {
+			t.Fatalf("Population is not sorted by the custom metric at %d", i)
+		}
+	}
+	if best.Fitness > initialBest {
+		t.Errorf("Best redness got worse: %f from %f", best.Fitness, initialBest)
+	}
+
+	if _, err := NewGeneticAlgorithm(target, 4, 1, 0.1, 2, WithFitnessFunc(redness), WithFitnessSample(0.5)); err == nil {
+		t.Error("Expected an error combining a custom fitness function with sampling")
+	}
+}
+
+// correlation returns the Pearson correlation coefficient of a and b.
+func correlation(a, b []float64) float64 {
+	n := float64(len(a))
+	var sumA, sumB float64
+	for i := range a {
+		sumA += a[i]
+		sumB += b[i]
+	}
+	meanA, meanB := sumA/n, sumB/n
+	var cov, varA, varB float64
+	for i := range a {
+		cov += (a[i] - meanA) * (b[i] - meanB)
+		varA += (a[i] - meanA) * (a[i] - meanA)
+		varB += (b[i] - meanB) * (b[i] - meanB)
+	}
+	return cov / math.Sqrt(varA*varB)
+}
+
+// createBlackWhiteChecker returns a high-contrast black and white checkerboard.
+func createBlackWhiteChecker(width, height, size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/size+y/size)%2 == 0 {
+				img.SetRGBA(x, y, color.RGBA{255, 255, 255, 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{0, 0, 0, 255})
+			}
+		}
+	}
+	return img
+}
+
+func TestPyramidFitnessPrefersStructure(t *testing.T) {
+	const size = 64
+	background := color.RGBA{R: 98, G: 98, B: 98, A: 255}
+	square := color.RGBA{R: 158, G: 158, B: 158, A: 255}
+
+	drawSquare := func(offsetX int) *image.RGBA {
+		img := image.NewRGBA(image.Rect(0, 0, size, size))
+		for y := 0; y < size; y++ {
+			for x := 0; x < size; x++ {
+				c := background
+				if x >= 16+offsetX && x < 48+offsetX && y >= 16 && y < 48 {
+					c = square
+				}
+				img.SetRGBA(x, y, c)
+			}
+		}
+		return img
+	}
+
+	target := drawSquare(0)
+
+	// Structurally correct, but every pixel carries noise
+	noisy := drawSquare(0)
+	rng := rand.New(rand.NewSource(1))
+	for i := range noisy.Pix {
+		if i%4 != 3 {
+			noisy.Pix[i] = uint8(int(noisy.Pix[i]) + rng.Intn(121) - 60)
+		}
+	}
+	noisyInd := &Individual{Image: noisy}
+
+	// Crisp, but the square is in the wrong place
+	misplacedInd := &Individual{Image: drawSquare(16)}
+
+	// At full resolution the misplaced square looks better
+	noisyInd.CalculateFitness(target)
+	misplacedInd.CalculateFitness(target)
+	if noisyInd.Fitness <= misplacedInd.Fitness {
+		t.Fatalf("Expected noise to dominate the full-resolution fitness, got noisy %f vs misplaced %f", noisyInd.Fitness, misplacedInd.Fitness)
+	}
+
+	pyramid := buildPyramid(target, 4)
+	if len(pyramid) != 4 {
+		t.Fatalf("Expected 4 pyramid levels, got %d", len(pyramid))
+	}
+	noisyInd.CalculateFitnessPyramid(pyramid)
+	misplacedInd.CalculateFitnessPyramid(pyramid)
+	if noisyInd.Fitness >= misplacedInd.Fitness {
+		t.Errorf("Expected the pyramid to favour correct structure, got noisy %f vs misplaced %f", noisyInd.Fitness, misplacedInd.Fitness)
+	}
+}
+
+func TestPyramidFitnessIdenticalImage(t *testing.T) {
+	target := createCheckerPattern(40, 30, 2)
+	ind := &Individual{Image: createCheckerPattern(40, 30, 2)}
+
+	ind.CalculateFitnessPyramid(buildPyramid(target, 3))
+	if ind.Fitness != 0 {
+		t.Errorf("Expected fitness 0 for identical images, got %f", ind.Fitness)
+	}
+}
+
+func TestFitnessDeadband(t *testing.T) {
+	target := &Individual{Image: image.NewRGBA(image.Rect(0, 0, 4, 4))}
+	for i := range target.Image.Pix {
+		target.Image.Pix[i] = 100
+	}
+
+	// Every channel is off by exactly the deadband
+	within := target.CreateCopy()
+	for i := range within.Image.Pix {
+		within.Image.Pix[i] += 3
+	}
+	within.CalculateFitnessDeadband(target.Image, 3)
+	if within.Fitness != 0 {
+		t.Errorf("Differences within the deadband should contribute zero, got %f", within.Fitness)
+	}
+
+	// One channel beyond the deadband contributes its full squared difference
+	beyond := within.CreateCopy()
+	beyond.Image.Pix[0] = 110
+	beyond.CalculateFitnessDeadband(target.Image, 3)
+	want := math.Sqrt(10 * 10 / 16.0)
+	if math.Abs(beyond.Fitness-want) > 1e-9 {
+		t.Errorf("Expected fitness %f from the single differing channel, got %f", want, beyond.Fitness)
+	}
+
+	// Without a deadband every difference counts
+	beyond.CalculateFitness(target.Image)
+	if beyond.Fitness <= want {
+		t.Errorf("Expected a zero deadband to count all differences, got %f", beyond.Fitness)
+	}
+}
+
+func TestSubImageTargetFitness(t *testing.T) {
+	full := createCheckerPattern(40, 30, 3)
+	region := image.Rect(5, 7, 25, 22)
+	sub := full.SubImage(region).(*image.RGBA)
+
+	ga, err := NewGeneticAlgorithm(sub, 4, 1, 0.05, 2)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if ga.TargetRGBA.Bounds() != image.Rect(0, 0, region.Dx(), region.Dy()) {
+		t.Fatalf("Expected the target to be normalized to the origin, got %v", ga.TargetRGBA.Bounds())
+	}
+	for y := 0; y < region.Dy(); y++ {
+		for x := 0; x < region.Dx(); x++ {
+			if ga.TargetRGBA.RGBAAt(x, y) != sub.RGBAAt(region.Min.X+x, region.Min.Y+y) {
+				t.Fatalf("Target pixel (%d,%d) doesn't match the sub-image", x, y)
+			}
+		}
+	}
+
+	// The sub-image itself scores 0 against the normalized target and vice versa,
+	// despite its non-zero origin and wider stride
+	ind := &Individual{Image: sub}
+	ind.CalculateFitness(ga.TargetRGBA)
+	if ind.Fitness != 0 {
+		t.Errorf("Expected fitness 0 for the sub-image against its normalized copy, got %f", ind.Fitness)
+	}
+	normalized := &Individual{Image: ga.TargetRGBA}
+	normalized.CalculateFitness(sub)
+	if normalized.Fitness != 0 {
+		t.Errorf("Expected fitness 0 against a sub-image target, got %f", normalized.Fitness)
+	}
+
+	// Copies of a sub-image individual are normalized too
+	copied := ind.CreateCopy()
+	copied.CalculateFitness(ga.TargetRGBA)
+	if copied.Image.Bounds().Min != (image.Point{}) || copied.Fitness != 0 {
+		t.Errorf("Expected a normalized identical copy, got bounds %v and fitness %f", copied.Image.Bounds(), copied.Fitness)
+	}
+}
+
+func TestEvaluateAtMostMatchesEvaluate(t *testing.T) {
+	target := createCheckerPattern(24, 17, 3)
+	ga, err := NewGeneticAlgorithm(target, 10, 1, 0.1, 2, WithSeed(1), WithFitnessDeadband(4),
+		WithDistance(DistanceL1), WithHistogramWeight(0.5), WithContrastWeight(0.5))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	for i, ind := range ga.Population {
+		exact := ind.Fitness
+		// A limit above the true fitness must give the exact value, penalties included
+		if !ga.EvaluateAtMost(ind, exact+1) || math.Abs(ind.Fitness-exact) > 1e-9 {
+			t.Errorf("Individual %d: bounded fitness %f (within limit), expected exact %f", i, ind.Fitness, exact)
+		}
+		// A limit below it must be reported as exceeded, with a value past the limit
+		limit := exact / 2
+		if ga.EvaluateAtMost(ind, limit) {
+			t.Errorf("Individual %d: fitness %f reported within limit %f", i, exact, limit)
+		}
+		if ind.Fitness < limit || ind.Fitness > exact+1e-9 {
+			t.Errorf("Individual %d: pruned fitness %f should be in [%f, %f]", i, ind.Fitness, limit, exact)
+		}
+	}
+}
+
+func TestEvaluateBatchMatchesEvaluate(t *testing.T) {
+	target := createCheckerPattern(24, 17, 3)
+	ga, err := NewGeneticAlgorithm(target, 10, 1, 0.1, 2, WithSeed(1), WithFitnessDeadband(4),
+		WithDistance(DistanceL1), WithContrastWeight(0.5))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	want := make([]float64, len(ga.Population))
+	for i, ind := range ga.Population {
+		want[i] = ind.Fitness
+		ind.Fitness = 0
+	}
+	ga.EvaluateBatch(ga.Population)
+	for i, ind := range ga.Population {
+		if ind.Fitness != want[i] {
+			t.Errorf("Individual %d: batch fitness %f, expected %f", i, ind.Fitness, want[i])
+		}
+	}
+}
@@ -0,0 +1,16 @@
+package genetic
+
+// restoreFrozen copies the target's pixels over the frozen region of ind's image, so
+// that only the rest of the canvas evolves. The shape list, if any, is left alone and
+// describes the image outside the frozen region.
+func (ga *GeneticAlgorithm) restoreFrozen(ind *Individual) {
+	r := ga.frozen
+	if r.Empty() {
+		return
+	}
+	target := ga.TargetRGBA
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		row := target.Pix[target.PixOffset(r.Min.X, y):target.PixOffset(r.Max.X, y)]
+		copy(ind.Image.Pix[ind.Image.PixOffset(r.Min.X, y):], row)
+	}
+}
@@ -0,0 +1,37 @@
+package genetic
+
+import (
+	"bytes"
+	"image"
+	"testing"
+)
+
+func TestFrozenRegionMatchesTarget(t *testing.T) {
+	target := createCheckerPattern(24, 16, 3)
+	frozen := image.Rect(4, 2, 14, 9)
+	ga, err := NewGeneticAlgorithm(target, 8, 6, 0.5, 2, WithSeed(4), WithFrozenRegion(frozen))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	best, err := ga.Run(nil, 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for i, ind := range append([]*Individual{best}, ga.Population...) {
+		for y := frozen.Min.Y; y < frozen.Max.Y; y++ {
+			got := ind.Image.Pix[ind.Image.PixOffset(frozen.Min.X, y):ind.Image.PixOffset(frozen.Max.X, y)]
+			want := target.Pix[target.PixOffset(frozen.Min.X, y):target.PixOffset(frozen.Max.X, y)]
+			if !bytes.Equal(got, want) {
+				t.Fatalf("Individual %d: row %d of the frozen region differs from the target", i, y)
+			}
+		}
+	}
+}
+
+func TestFrozenRegionOutsideTarget(t *testing.T) {
+	_, err := NewGeneticAlgorithm(createCheckerPattern(8, 8, 2), 4, 1, 0.1, 2, WithFrozenRegion(image.Rect(10, 10, 12, 12)))
+	if err == nil {
+		t.Error("Expected an error for a frozen region outside the target")
+	}
+}
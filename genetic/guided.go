@@ -0,0 +1,71 @@
+package genetic
+
+import (
+	"image"
+	"math/rand"
+	"sort"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// errorMapCells is the number of cells the error map has along each side, at most.
+const errorMapCells = 16
+
+// errorMap is a coarse grid of how much an image differs from the target, used by
+// guided mutation to place new polygons where they are needed most.
+type errorMap struct {
+	width, height int
+	cols, rows    int
+	// cumulative holds the running total of the squared error of the cells in row-major
+	// order, so a cell can be drawn in proportion to its error by binary search
+	cumulative []float64
+}
+
+// newErrorMap measures img against target, which must have the same size, cell by cell.
+func newErrorMap(img, target *image.RGBA) *errorMap {
+	bounds := target.Bounds()
+	em := &errorMap{
+		width:  bounds.Dx(),
+		height: bounds.Dy(),
+		cols:   mathutil.Min(errorMapCells, bounds.Dx()),
+		rows:   mathutil.Min(errorMapCells, bounds.Dy()),
+	}
+	cells := make([]float64, em.cols*em.rows)
+	for y := 0; y < em.height; y++ {
+		row1 := img.Pix[y*img.Stride : y*img.Stride+em.width*4]
+		row2 := target.Pix[y*target.Stride : y*target.Stride+em.width*4]
+		cellRow := y * em.rows / em.height * em.cols
+		for x := 0; x < em.width; x++ {
+			var difference int
+			for c := 0; c < 4; c++ {
+				d := int(row1[x*4+c]) - int(row2[x*4+c])
+				difference += d * d
+			}
+			cells[cellRow+x*em.cols/em.width] += float64(difference)
+		}
+	}
+
+	em.cumulative = cells
+	for i := 1; i < len(cells); i++ {
+		em.cumulative[i] += em.cumulative[i-1]
+	}
+	return em
+}
+
+// sample returns a random point, choosing its cell in proportion to the cell's error
+// and the point uniformly within the cell. Without any error the whole image is uniform.
+func (em *errorMap) sample(rng *rand.Rand) (int, int) {
+	total := em.cumulative[len(em.cumulative)-1]
+	if total <= 0 {
+		return rng.Intn(em.width), rng.Intn(em.height)
+	}
+	r := rng.Float64() * total
+	cell := sort.Search(len(em.cumulative), func(i int) bool { return em.cumulative[i] > r })
+	cell = mathutil.Min(cell, len(em.cumulative)-1)
+	col, row := cell%em.cols, cell/em.cols
+
+	// The pixels that newErrorMap assigned to this cell
+	x0, x1 := (col*em.width+em.cols-1)/em.cols, ((col+1)*em.width+em.cols-1)/em.cols
+	y0, y1 := (row*em.height+em.rows-1)/em.rows, ((row+1)*em.height+em.rows-1)/em.rows
+	return x0 + rng.Intn(x1-x0), y0 + rng.Intn(y1-y0)
+}
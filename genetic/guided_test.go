@@ -0,0 +1,97 @@
+package genetic
+
+import (
+	"image"
+	"math/rand"
+	"testing"
+)
+
+// withWrongQuadrant returns a copy of target whose top-left quadrant is inverted.
+func withWrongQuadrant(target *image.RGBA) (*image.RGBA, image.Rectangle) {
+	img := image.NewRGBA(target.Bounds())
+	copy(img.Pix, target.Pix)
+	quadrant := image.Rect(0, 0, target.Bounds().Dx()/2, target.Bounds().Dy()/2)
+	for y := quadrant.Min.Y; y < quadrant.Max.Y; y++ {
+		for x := quadrant.Min.X; x < quadrant.Max.X; x++ {
+			i := img.PixOffset(x, y)
+			for c := 0; c < 3; c++ {
+				img.Pix[i+c] = 255 - img.Pix[i+c]
+			}
+		}
+	}
+	return img, quadrant
+}
+
+func TestErrorMapSamplesWhereImagesDiffer(t *testing.T) {
+	target := createCheckerPattern(32, 32, 3)
+	img, wrong := withWrongQuadrant(target)
+
+	em := newErrorMap(img, target)
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 2000; i++ {
+		if x, y := em.sample(rng); !image.Pt(x, y).In(wrong) {
+			t.Fatalf("Sampled (%d,%d) outside the differing region %v", x, y, wrong)
+		}
+	}
+
+	// 37x29 doesn't divide evenly into cells, so the cells on the quadrant's edge
+	// overlap it by part of a cell
+	target = createCheckerPattern(37, 29, 3)
+	img, wrong = withWrongQuadrant(target)
+	em = newErrorMap(img, target)
+	for i := 0; i < 2000; i++ {
+		if x, y := em.sample(rng); !image.Pt(x, y).In(wrong.Inset(-2)) || x < 0 || y < 0 {
+			t.Fatalf("Sampled (%d,%d) outside the cells covering %v", x, y, wrong)
+		}
+	}
+
+	// Identical images fall back to uniform sampling over the whole image
+	em = newErrorMap(target, target)
+	var right int
+	for i := 0; i < 2000; i++ {
+		if x, _ := em.sample(rng); x >= 18 {
+			right++
+		}
+	}
+	if right < 800 {
+		t.Errorf("Expected uniform samples without error, got %d of 2000 in the right half", right)
+	}
+}
+
+func TestGuidedMutationConcentratesOnTheWorstQuadrant(t *testing.T) {
+	target := createCheckerPattern(64, 64, 8)
+	img, quadrant := withWrongQuadrant(target)
+
+	// The share of added polygons centred in the wrong quadrant
+	inQuadrant := func(guided bool) float64 {
+		ga, err := NewGeneticAlgorithm(target, 4, 1, 1, 2, WithSeed(7))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		if guided {
+			ga.errorMap = newErrorMap(img, ga.TargetRGBA)
+		}
+		ind := &Individual{Image: img, Shapes: []Polygon{}}
+		rng := rand.New(rand.NewSource(8))
+		var in, total int
+		for i := 0; i < 300; i++ {
+			child := ga.Mutate(rng, ind)
+			for _, polygon := range child.Shapes {
+				var sum image.Point
+				for _, p := range polygon.Points {
+					sum = sum.Add(p)
+				}
+				if sum.Div(len(polygon.Points)).In(quadrant) {
+					in++
+				}
+				total++
+			}
+		}
+		return float64(in) / float64(total)
+	}
+
+	uniform, guided := inQuadrant(false), inQuadrant(true)
+	if guided < 0.6 || guided < uniform+0.3 {
+		t.Errorf("Expected guided polygons to concentrate in the wrong quadrant, got %.0f%% (uniform %.0f%%)", guided*100, uniform*100)
+	}
+}
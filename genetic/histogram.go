@@ -0,0 +1,49 @@
+package genetic
+
+import (
+	"image"
+	"math"
+)
+
+// colorHistogram counts the pixels at each level of the red, green and blue channels.
+type colorHistogram struct {
+	counts [3][256]uint32
+	pixels int
+}
+
+// computeHistogram returns the per-channel histograms of img.
+func computeHistogram(img *image.RGBA) *colorHistogram {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	h := &colorHistogram{pixels: width * height}
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride : y*img.Stride+width*4]
+		for i := 0; i < len(row); i += 4 {
+			h.counts[0][row[i]]++
+			h.counts[1][row[i+1]]++
+			h.counts[2][row[i+2]]++
+		}
+	}
+	return h
+}
+
+// histogramPenalty is the earth mover's distance between the candidate's and the
+// target's histograms, summed over the channels. For each channel it is the average
+// number of levels each pixel's value would have to move to turn one distribution
+// into the other, so it ignores where colors are and only sees the overall balance.
+func histogramPenalty(target, candidate *colorHistogram) float64 {
+	if target.pixels == 0 || candidate.pixels == 0 {
+		return 0
+	}
+	var penalty float64
+	for c := 0; c < 3; c++ {
+		// In one dimension the distance is the area between the cumulative distributions
+		var targetCumulative, candidateCumulative float64
+		for level := 0; level < 256; level++ {
+			targetCumulative += float64(target.counts[c][level]) / float64(target.pixels)
+			candidateCumulative += float64(candidate.counts[c][level]) / float64(candidate.pixels)
+			penalty += math.Abs(targetCumulative - candidateCumulative)
+		}
+	}
+	return penalty
+}
@@ -0,0 +1,76 @@
+package genetic
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// histogramTestImages returns a gradient target, a copy with red shifted up by 40
+// everywhere, and a copy with red alternately 40 up and down. Both copies are equally
+// far from the target pixel by pixel.
+func histogramTestImages() (target, shifted, noisy *image.RGBA) {
+	rect := image.Rect(0, 0, 64, 64)
+	target, shifted, noisy = image.NewRGBA(rect), image.NewRGBA(rect), image.NewRGBA(rect)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			c := color.RGBA{R: uint8(60 + 2*x), G: uint8(60 + 2*y), B: 120, A: 255}
+			target.SetRGBA(x, y, c)
+			s := c
+			s.R += 40
+			shifted.SetRGBA(x, y, s)
+			n := c
+			if (x+y)%2 == 0 {
+				n.R += 40
+			} else {
+				n.R -= 40
+			}
+			noisy.SetRGBA(x, y, n)
+		}
+	}
+	return target, shifted, noisy
+}
+
+func TestHistogramPenaltyCatchesColorShift(t *testing.T) {
+	target, shifted, noisy := histogramTestImages()
+	targetHistogram := computeHistogram(target)
+
+	if same := histogramPenalty(targetHistogram, computeHistogram(target)); same != 0 {
+		t.Errorf("Expected no penalty for identical histograms, got %f", same)
+	}
+	// Every red value moves 40 levels
+	shiftPenalty := histogramPenalty(targetHistogram, computeHistogram(shifted))
+	if math.Abs(shiftPenalty-40) > 1e-6 {
+		t.Errorf("Expected a penalty of 40 for a shift of 40 levels, got %f", shiftPenalty)
+	}
+	// Noise of the same size mostly keeps the distribution
+	noisePenalty := histogramPenalty(targetHistogram, computeHistogram(noisy))
+	if noisePenalty*2 > shiftPenalty {
+		t.Errorf("Expected the color shift to cost far more than noise, got %f and %f", shiftPenalty, noisePenalty)
+	}
+}
+
+func TestHistogramWeightAddsToFitness(t *testing.T) {
+	target, shifted, noisy := histogramTestImages()
+	plain, err := NewGeneticAlgorithm(target, 2, 1, 0.05, 2)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	weighted, err := NewGeneticAlgorithm(target, 2, 1, 0.05, 2, WithHistogramWeight(1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	fitness := func(ga *GeneticAlgorithm, img *image.RGBA) float64 {
+		ind := &Individual{Image: img}
+		ga.evaluate(ind)
+		return ind.Fitness
+	}
+	if a, b := fitness(plain, shifted), fitness(plain, noisy); math.Abs(a-b) > 1e-9 {
+		t.Fatalf("Expected equal plain fitness for the shifted and noisy images, got %f and %f", a, b)
+	}
+	if a, b := fitness(weighted, shifted), fitness(weighted, noisy); a < b+20 {
+		t.Errorf("Expected the histogram term to penalize the color shift, got %f for it and %f for noise", a, b)
+	}
+}
@@ -1,6 +1,7 @@
 package genetic
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
@@ -17,6 +18,14 @@ import (
 type Individual struct {
 	Fitness float64
 	Image   *image.RGBA
+
+	// Background and Shapes describe how Image was drawn: Shapes are filled over
+	// Background in order. Pixel-level operators such as crossover don't preserve
+	// this, so Shapes is nil once Image can no longer be rebuilt from it.
+	Background color.RGBA
+	Shapes     []Polygon
+	// FillRule decides which parts of a self-intersecting polygon are filled
+	FillRule FillRule
 }
 
 // Polygon represents a colored polygon
@@ -25,83 +34,290 @@ type Polygon struct {
 	Color  color.RGBA
 }
 
-// NewIndividual creates a new individual with random polygons
-func NewIndividual(width, height int) *Individual {
+// FillRule selects how the interior of a self-intersecting polygon is filled.
+type FillRule int
+
+const (
+	// FillNonZero fills every region the outline winds around, so self-intersecting
+	// polygons come out solid.
+	FillNonZero FillRule = iota
+	// FillEvenOdd leaves regions the outline winds around twice unfilled, which gives
+	// self-intersecting polygons hollow parts.
+	FillEvenOdd
+)
+
+// ParseFillRule converts a fill rule name (nonzero or evenodd) to a FillRule.
+func ParseFillRule(name string) (FillRule, error) {
+	switch name {
+	case "nonzero":
+		return FillNonZero, nil
+	case "evenodd":
+		return FillEvenOdd, nil
+	}
+	return FillNonZero, fmt.Errorf("unknown fill rule %q, expected nonzero or evenodd", name)
+}
+
+func (fr FillRule) String() string {
+	if fr == FillEvenOdd {
+		return "evenodd"
+	}
+	return "nonzero"
+}
+
+// ShapeConfig bounds the random polygons drawn on a new individual.
+type ShapeConfig struct {
+	MinShapes   int
+	MaxShapes   int
+	MinVertices int
+	MaxVertices int
+	// MaxArea caps the bounding-box area of any polygon, including those added or
+	// edited by mutation, as a fraction of the image area. 0 means no cap.
+	MaxArea float64
+	// FillRule is given to every individual and decides how its polygons are filled.
+	FillRule FillRule
+}
+
+// DefaultShapeConfig draws 3-7 polygons of 3-6 vertices each.
+var DefaultShapeConfig = ShapeConfig{
+	MinShapes:   3,
+	MaxShapes:   7,
+	MinVertices: 3,
+	MaxVertices: 6,
+}
+
+// Validate checks that the ranges are ordered and every polygon has at least 3 vertices.
+func (sc ShapeConfig) Validate() error {
+	if sc.MinShapes < 0 || sc.MinShapes > sc.MaxShapes {
+		return fmt.Errorf("invalid initial shape range %d-%d", sc.MinShapes, sc.MaxShapes)
+	}
+	if sc.MinVertices < 3 || sc.MinVertices > sc.MaxVertices {
+		return fmt.Errorf("invalid initial vertex range %d-%d, polygons need at least 3 vertices", sc.MinVertices, sc.MaxVertices)
+	}
+	if sc.MaxArea < 0 || sc.MaxArea > 1 {
+		return fmt.Errorf("max shape area must be between 0.0 and 1.0, got %f", sc.MaxArea)
+	}
+	return nil
+}
+
+// maxShapeArea returns the largest bounding-box area allowed for a polygon on a
+// width x height image, or -1 when there is no cap.
+func (sc ShapeConfig) maxShapeArea(width, height int) int {
+	if sc.MaxArea <= 0 {
+		return -1
+	}
+	return int(sc.MaxArea * float64(width*height))
+}
+
+// capShapeArea shrinks points toward the center of their bounding box so that its
+// area is at most maxArea. A negative maxArea means no cap.
+func capShapeArea(points []image.Point, maxArea int) {
+	if maxArea < 0 || len(points) == 0 {
+		return
+	}
+	box := image.Rectangle{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		box.Min.X, box.Min.Y = mathutil.Min(box.Min.X, p.X), mathutil.Min(box.Min.Y, p.Y)
+		box.Max.X, box.Max.Y = mathutil.Max(box.Max.X, p.X), mathutil.Max(box.Max.Y, p.Y)
+	}
+	area := box.Dx() * box.Dy()
+	if area <= maxArea {
+		return
+	}
+
+	// Offsets from the center are truncated toward it, so each side shrinks by at least factor
+	factor := math.Sqrt(float64(maxArea) / float64(area))
+	cx, cy := (box.Min.X+box.Max.X)/2, (box.Min.Y+box.Max.Y)/2
+	for i, p := range points {
+		points[i] = image.Point{
+			X: cx + int(float64(p.X-cx)*factor),
+			Y: cy + int(float64(p.Y-cy)*factor),
+		}
+	}
+}
+
+// NewIndividual creates a new individual with a random background and random polygons
+func NewIndividual(rng *rand.Rand, width, height int) *Individual {
+	return NewIndividualWithBackground(rng, width, height, RandomRGBA(rng))
+}
+
+// NewIndividualWithBackground creates a new individual with the given background color and random polygons
+func NewIndividualWithBackground(rng *rand.Rand, width, height int, bgColor color.RGBA) *Individual {
+	return newIndividual(rng, width, height, bgColor, DefaultShapeConfig, nil)
+}
+
+// newIndividual creates a new individual with the given background color and random polygons bounded by shapes.
+// Polygon colors are drawn from palette, or are fully random when it is empty.
+func newIndividual(rng *rand.Rand, width, height int, bgColor color.RGBA, shapes ShapeConfig, palette []color.RGBA) *Individual {
 	ind := &Individual{
-		Fitness: math.Inf(1),
-		Image:   image.NewRGBA(image.Rect(0, 0, width, height)),
+		Fitness:  math.Inf(1),
+		Image:    image.NewRGBA(image.Rect(0, 0, width, height)),
+		FillRule: shapes.FillRule,
 	}
 
-	// Create random background color
-	bgColor := RandomRGBA()
 	draw.Draw(ind.Image, ind.Image.Bounds(), &image.Uniform{bgColor}, image.Point{}, draw.Src)
 
 	// Add random polygons
-	ind.createRandomPolygons()
+	ind.Background = bgColor
+	ind.Shapes = ind.createRandomPolygons(rng, shapes, palette)
 
 	return ind
 }
 
-// CreateCopy creates a deep copy of the individual
+// NewIndividualFromImage creates an individual that starts from a copy of seed with
+// shapeCount random polygons drawn over it, so a population can be warm-started from an
+// earlier result while keeping some diversity. The image has no shape list.
+func NewIndividualFromImage(rng *rand.Rand, seed image.Image, shapeCount int) *Individual {
+	return newIndividualFromImage(rng, seed, shapeCount, DefaultShapeConfig, nil)
+}
+
+// newIndividualFromImage is NewIndividualFromImage with the polygons' vertex counts and
+// area bounded by shapes and, with a palette, their colors taken from it.
+func newIndividualFromImage(rng *rand.Rand, seed image.Image, shapeCount int, shapes ShapeConfig, palette []color.RGBA) *Individual {
+	bounds := seed.Bounds()
+	ind := &Individual{
+		Fitness:  math.Inf(1),
+		Image:    image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy())),
+		FillRule: shapes.FillRule,
+	}
+	draw.Draw(ind.Image, ind.Image.Bounds(), seed, bounds.Min, draw.Src)
+
+	shapes.MinShapes, shapes.MaxShapes = shapeCount, shapeCount
+	ind.createRandomPolygons(rng, shapes, palette)
+	return ind
+}
+
+// CreateCopy creates a deep copy of the individual. The copy's image starts at the origin
+// with contiguous rows, even if the original is a sub-image.
 func (ind *Individual) CreateCopy() *Individual {
-	newImg := image.NewRGBA(ind.Image.Bounds())
-	copy(newImg.Pix, ind.Image.Pix)
+	bounds := ind.Image.Bounds()
+	newImg := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	if ind.Image.Stride == newImg.Stride {
+		copy(newImg.Pix, ind.Image.Pix)
+	} else {
+		for y := 0; y < bounds.Dy(); y++ {
+			copy(newImg.Pix[y*newImg.Stride:(y+1)*newImg.Stride], ind.Image.Pix[y*ind.Image.Stride:])
+		}
+	}
 
 	return &Individual{
-		Fitness: ind.Fitness,
-		Image:   newImg,
+		Fitness:    ind.Fitness,
+		Image:      newImg,
+		Background: ind.Background,
+		Shapes:     copyShapes(ind.Shapes),
+		FillRule:   ind.FillRule,
+	}
+}
+
+// copyShapes returns a deep copy of shapes, preserving nil.
+func copyShapes(shapes []Polygon) []Polygon {
+	if shapes == nil {
+		return nil
+	}
+	out := make([]Polygon, len(shapes))
+	for i, p := range shapes {
+		out[i] = Polygon{
+			Points: append([]image.Point(nil), p.Points...),
+			Color:  p.Color,
+		}
+	}
+	return out
+}
+
+// hasShapes reports whether the individual's image can be rebuilt from its shape list.
+func (ind *Individual) hasShapes() bool {
+	return ind.Shapes != nil
+}
+
+// rasterize redraws the image from the background and shape list.
+func (ind *Individual) rasterize() {
+	draw.Draw(ind.Image, ind.Image.Bounds(), &image.Uniform{ind.Background}, image.Point{}, draw.Src)
+	dc := ind.drawingContext()
+	for _, polygon := range ind.Shapes {
+		drawPolygon(dc, polygon)
+	}
+}
+
+// drawingContext returns a context that draws on the individual's image with its fill rule.
+func (ind *Individual) drawingContext() *gg.Context {
+	dc := gg.NewContextForRGBA(ind.Image)
+	if ind.FillRule == FillEvenOdd {
+		dc.SetFillRule(gg.FillRuleEvenOdd)
+	}
+	return dc
+}
+
+// drawPolygon fills polygon on the context's image.
+func drawPolygon(dc *gg.Context, polygon Polygon) {
+	dc.SetRGBA255(int(polygon.Color.R), int(polygon.Color.G), int(polygon.Color.B), int(polygon.Color.A))
+	for j, point := range polygon.Points {
+		if j == 0 {
+			dc.MoveTo(float64(point.X), float64(point.Y))
+		} else {
+			dc.LineTo(float64(point.X), float64(point.Y))
+		}
 	}
+	dc.ClosePath()
+	dc.Fill()
 }
 
-// CreateBlankCopy creates a copy of the individual with only a blank image of the same size.
+// CreateBlankCopy creates a copy of the individual with only a blank image of the same
+// size and the same fill rule.
 func (ind *Individual) CreateBlankCopy() *Individual {
 	return &Individual{
-		Image: image.NewRGBA(image.Rect(0, 0, ind.Image.Bounds().Dx(), ind.Image.Bounds().Dy())),
+		Image:    image.NewRGBA(image.Rect(0, 0, ind.Image.Bounds().Dx(), ind.Image.Bounds().Dy())),
+		FillRule: ind.FillRule,
 	}
 }
 
-// createRandomPolygons creates random polygons for the individual
-func (ind *Individual) createRandomPolygons() {
-	numOfPoly := rand.Intn(5) + 3
-	region := (ind.Image.Bounds().Dx() + ind.Image.Bounds().Dy()) / 8
+// createRandomPolygons draws random polygons on the individual and returns them in draw order
+func (ind *Individual) createRandomPolygons(rng *rand.Rand, shapes ShapeConfig, palette []color.RGBA) []Polygon {
+	numOfPoly := mathutil.RandomBetweenR(rng, shapes.MinShapes, shapes.MaxShapes)
+	polygons := make([]Polygon, 0, numOfPoly)
+	// Keep the spread at least 1 so rng.Intn(2*region) never receives 0 on tiny images
+	region := mathutil.Max((ind.Image.Bounds().Dx()+ind.Image.Bounds().Dy())/8, 1)
+	maxArea := shapes.maxShapeArea(ind.Image.Bounds().Dx(), ind.Image.Bounds().Dy())
+	dc := ind.drawingContext()
 
 	for i := 0; i < numOfPoly; i++ {
-		numOfVertices := rand.Intn(4) + 3
+		numOfVertices := mathutil.RandomBetweenR(rng, shapes.MinVertices, shapes.MaxVertices)
 
-		regionX := rand.Intn(ind.Image.Bounds().Dx())
-		regionY := rand.Intn(ind.Image.Bounds().Dy())
+		regionX := rng.Intn(ind.Image.Bounds().Dx())
+		regionY := rng.Intn(ind.Image.Bounds().Dy())
 
 		polygon := Polygon{
 			Points: make([]image.Point, numOfVertices),
-			Color:  RandomRGBA(),
+			Color:  paletteColor(rng, palette),
 		}
 
 		// Generate random points for the polygon
 		for j := 0; j < numOfVertices; j++ {
-			x := mathutil.Clamp(regionX+rand.Intn(2*region)-region, 0, ind.Image.Bounds().Dx()-1)
-			y := mathutil.Clamp(regionY+rand.Intn(2*region)-region, 0, ind.Image.Bounds().Dy()-1)
+			x := mathutil.Clamp(regionX+rng.Intn(2*region)-region, 0, ind.Image.Bounds().Dx())
+			y := mathutil.Clamp(regionY+rng.Intn(2*region)-region, 0, ind.Image.Bounds().Dy())
 			polygon.Points[j] = image.Point{X: x, Y: y}
 		}
+		capShapeArea(polygon.Points, maxArea)
 
-		// Draw the polygon
-		dc := gg.NewContextForRGBA(ind.Image)
-		dc.SetRGBA255(int(polygon.Color.R), int(polygon.Color.G), int(polygon.Color.B), int(polygon.Color.A))
-
-		for j, point := range polygon.Points {
-			if j == 0 {
-				dc.MoveTo(float64(point.X), float64(point.Y))
-			} else {
-				dc.LineTo(float64(point.X), float64(point.Y))
-			}
-		}
-
-		dc.ClosePath()
-		dc.Fill()
+		drawPolygon(dc, polygon)
+		polygons = append(polygons, polygon)
 	}
+
+	return polygons
 }
 
 // CalculateFitness calculates the fitness
 func (ind *Individual) CalculateFitness(targetImage *image.RGBA) {
+	ind.CalculateFitnessDeadband(targetImage, 0)
+}
+
+// CalculateFitnessDeadband calculates the fitness like CalculateFitness, but treats
+// per-channel differences of at most deadband as zero, so imperceptible noise such as
+// lossy compression artifacts isn't penalized.
+func (ind *Individual) CalculateFitnessDeadband(targetImage *image.RGBA, deadband int) {
+	ind.calculateFitness(targetImage, deadband, DistanceL2)
+}
+
+// calculateFitness is CalculateFitnessDeadband measuring pixels with distance.
+func (ind *Individual) calculateFitness(targetImage *image.RGBA, deadband int, distance Distance) {
 	bounds := targetImage.Bounds()
 	width, height := bounds.Dx(), bounds.Dy()
 	numGoroutines := runtime.GOMAXPROCS(0)
@@ -121,7 +337,7 @@ func (ind *Individual) CalculateFitness(targetImage *image.RGBA) {
 
 		go func(startY, endY, idx int) {
 			defer wg.Done()
-			differences[idx] = calculateRegionFitness(ind.Image, targetImage, startY, endY)
+			differences[idx] = calculateRegionFitness(ind.Image, targetImage, startY, endY, deadband, distance)
 		}(startY, endY, i)
 	}
 
@@ -133,21 +349,95 @@ func (ind *Individual) CalculateFitness(targetImage *image.RGBA) {
 		totalDifference += diff
 	}
 
-	ind.Fitness = math.Sqrt(totalDifference / float64(width*height))
+	ind.Fitness = distance.fitness(totalDifference, float64(width*height))
+}
+
+// CalculateFitnessAtMost calculates the fitness like CalculateFitness, but stops as soon
+// as it is certain to exceed limit, which makes rejecting clearly worse candidates cheap.
+// It reports whether the fitness is at most limit; only then is ind.Fitness exact, and
+// otherwise it is a partial value that is already above limit. Like CalculateFitness it
+// is the plain L2 fitness; GeneticAlgorithm.EvaluateAtMost adds the algorithm's terms.
+func (ind *Individual) CalculateFitnessAtMost(targetImage *image.RGBA, limit float64) bool {
+	return ind.calculateFitnessAtMost(targetImage, 0, DistanceL2, limit)
+}
+
+func (ind *Individual) calculateFitnessAtMost(targetImage *image.RGBA, deadband int, distance Distance, limit float64) bool {
+	bounds := targetImage.Bounds()
+	pixels := float64(bounds.Dx() * bounds.Dy())
+	// Compare the summed differences directly instead of converting per row
+	difference, within := calculateRegionFitnessBounded(ind.Image, targetImage, 0, bounds.Dy(), deadband, distance, distance.total(limit, pixels))
+	ind.Fitness = distance.fitness(difference, pixels)
+	return within
+}
+
+// CalculateFitnessBatch calculates the fitness of many individuals at once.
+// Work is distributed per individual rather than per image strip, which is more
+// cache-friendly for small images. Each image is still summed over the same
+// strips as CalculateFitness, so the resulting values are identical.
+//
+// Like CalculateFitness it scores the plain root mean squared error, without the
+// deadband, distance, pyramid or penalty settings of an algorithm, so its values are
+// only comparable with an algorithm's own when none of those are set. Use
+// GeneticAlgorithm.EvaluateBatch otherwise.
+func CalculateFitnessBatch(inds []*Individual, targetImage *image.RGBA) {
+	bounds := targetImage.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	numStrips := runtime.GOMAXPROCS(0)
+	rowsPerStrip := height / numStrips
+
+	numWorkers := mathutil.Min(runtime.GOMAXPROCS(0), len(inds))
+	jobs := make(chan *Individual, len(inds))
+	for _, ind := range inds {
+		jobs <- ind
+	}
+	close(jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ind := range jobs {
+				var totalDifference float64
+				for i := 0; i < numStrips; i++ {
+					startY := i * rowsPerStrip
+					endY := startY + rowsPerStrip
+					if i == numStrips-1 {
+						endY = height
+					}
+					totalDifference += calculateRegionFitness(ind.Image, targetImage, startY, endY, 0, DistanceL2)
+				}
+				ind.Fitness = math.Sqrt(totalDifference / float64(width*height))
+			}
+		}()
+	}
+	wg.Wait()
 }
 
-func calculateRegionFitness(img1, img2 *image.RGBA, startY, endY int) float64 {
+// calculateRegionFitness returns the sum of the pixel distances over rows [startY, endY),
+// squared for DistanceL2. Channel differences of at most deadband count as zero.
+func calculateRegionFitness(img1, img2 *image.RGBA, startY, endY, deadband int, distance Distance) float64 {
+	if distance != DistanceL2 {
+		return calculateRegionDistance(img1, img2, startY, endY, deadband, distance)
+	}
+	if deadband > 0 {
+		return calculateRegionFitnessDeadband(img1, img2, startY, endY, deadband)
+	}
 	var difference float64
 	width := img1.Bounds().Dx()
 
+	// Pix starts at each image's Min, so rows are indexed relative to the bounds and
+	// each image uses its own stride
 	for y := startY; y < endY; y++ {
-		i := y * img1.Stride
+		i1 := y * img1.Stride
+		i2 := y * img2.Stride
 		for x := 0; x < width; x++ {
-			idx := i + x*4
+			idx1 := i1 + x*4
+			idx2 := i2 + x*4
 
 			// Direct pixel access for better performance
-			r1, g1, b1, a1 := img1.Pix[idx], img1.Pix[idx+1], img1.Pix[idx+2], img1.Pix[idx+3]
-			r2, g2, b2, a2 := img2.Pix[idx], img2.Pix[idx+1], img2.Pix[idx+2], img2.Pix[idx+3]
+			r1, g1, b1, a1 := img1.Pix[idx1], img1.Pix[idx1+1], img1.Pix[idx1+2], img1.Pix[idx1+3]
+			r2, g2, b2, a2 := img2.Pix[idx2], img2.Pix[idx2+1], img2.Pix[idx2+2], img2.Pix[idx2+3]
 
 			rDiff := float64(int(r1) - int(r2))
 			gDiff := float64(int(g1) - int(g2))
@@ -161,6 +451,78 @@ func calculateRegionFitness(img1, img2 *image.RGBA, startY, endY int) float64 {
 	return difference
 }
 
+// calculateRegionFitnessBounded sums the differences over rows [startY, endY) like
+// calculateRegionFitness, with the same deadband and distance, but returns as soon as
+// the sum exceeds limit. It reports whether the full sum is at most limit.
+func calculateRegionFitnessBounded(img1, img2 *image.RGBA, startY, endY, deadband int, distance Distance, limit float64) (float64, bool) {
+	var difference float64
+	for y := startY; y < endY; y++ {
+		// Checking once per row keeps the inner loop as tight as the unbounded one
+		difference += calculateRegionFitness(img1, img2, y, y+1, deadband, distance)
+		if difference > limit {
+			return difference, false
+		}
+	}
+	return difference, true
+}
+
+// calculateFitnessSampled estimates the fitness from every step-th pixel, counting in
+// row-major order from offset, so it takes about 1/step of the time of the exact fitness.
+// Channel differences of at most deadband count as zero.
+func (ind *Individual) calculateFitnessSampled(targetImage *image.RGBA, deadband, step, offset int) {
+	difference, samples := calculateRegionFitnessSampled(ind.Image, targetImage, 0, targetImage.Bounds().Dy(), deadband, step, offset)
+	if samples == 0 {
+		ind.Fitness = 0
+		return
+	}
+	ind.Fitness = math.Sqrt(difference / float64(samples))
+}
+
+// calculateRegionFitnessSampled sums squared channel differences over the pixels of rows
+// [startY, endY) whose row-major index is offset modulo step, and returns the sum and the
+// number of pixels compared.
+func calculateRegionFitnessSampled(img1, img2 *image.RGBA, startY, endY, deadband, step, offset int) (float64, int) {
+	var difference float64
+	var samples int
+	width := img1.Bounds().Dx()
+
+	for y := startY; y < endY; y++ {
+		i1 := y * img1.Stride
+		i2 := y * img2.Stride
+		// First column of this row on the sampling lattice
+		first := ((offset-y*width)%step + step) % step
+		for x := first; x < width; x += step {
+			for c := 0; c < 4; c++ {
+				d := int(img1.Pix[i1+x*4+c]) - int(img2.Pix[i2+x*4+c])
+				if d > deadband || d < -deadband {
+					difference += float64(d * d)
+				}
+			}
+			samples++
+		}
+	}
+
+	return difference, samples
+}
+
+func calculateRegionFitnessDeadband(img1, img2 *image.RGBA, startY, endY, deadband int) float64 {
+	var difference float64
+	width := img1.Bounds().Dx()
+
+	for y := startY; y < endY; y++ {
+		row1 := img1.Pix[y*img1.Stride : y*img1.Stride+width*4]
+		row2 := img2.Pix[y*img2.Stride : y*img2.Stride+width*4]
+		for j := range row1 {
+			d := int(row1[j]) - int(row2[j])
+			if d > deadband || d < -deadband {
+				difference += float64(d * d)
+			}
+		}
+	}
+
+	return difference
+}
+
 func (ind *Individual) CalculateFitnessSequential(targetImage *image.RGBA) {
 	width := targetImage.Bounds().Dx()
 	height := targetImage.Bounds().Dy()
@@ -169,14 +531,15 @@ func (ind *Individual) CalculateFitnessSequential(targetImage *image.RGBA) {
 	img1Pix := ind.Image.Pix
 	img2Pix := targetImage.Pix
 
-	stride := ind.Image.Stride
 	for y := 0; y < height; y++ {
-		i := y * stride
+		i1 := y * ind.Image.Stride
+		i2 := y * targetImage.Stride
 		for x := 0; x < width; x++ {
-			idx := i + x*4
+			idx1 := i1 + x*4
+			idx2 := i2 + x*4
 
-			r1, g1, b1, a1 := img1Pix[idx], img1Pix[idx+1], img1Pix[idx+2], img1Pix[idx+3]
-			r2, g2, b2, a2 := img2Pix[idx], img2Pix[idx+1], img2Pix[idx+2], img2Pix[idx+3]
+			r1, g1, b1, a1 := img1Pix[idx1], img1Pix[idx1+1], img1Pix[idx1+2], img1Pix[idx1+3]
+			r2, g2, b2, a2 := img2Pix[idx2], img2Pix[idx2+1], img2Pix[idx2+2], img2Pix[idx2+3]
 
 			rDiff := float64(int(r1) - int(r2))
 			gDiff := float64(int(g1) - int(g2))
@@ -0,0 +1,230 @@
+package genetic
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestNewIndividualTinyImages(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for w := 1; w <= 4; w++ {
+		for h := 1; h <= 4; h++ {
+			ind := NewIndividual(rng, w, h)
+
+			bounds := ind.Image.Bounds()
+			if bounds.Dx() != w || bounds.Dy() != h {
+				t.Errorf("Expected %dx%d image, got %dx%d", w, h, bounds.Dx(), bounds.Dy())
+			}
+			if len(ind.Image.Pix) != w*h*4 {
+				t.Errorf("Expected %d bytes of pixel data for %dx%d, got %d", w*h*4, w, h, len(ind.Image.Pix))
+			}
+			if !math.IsInf(ind.Fitness, 1) {
+				t.Errorf("Expected unevaluated fitness for %dx%d, got %f", w, h, ind.Fitness)
+			}
+		}
+	}
+}
+
+func TestCalculateFitnessBatchMatchesSingle(t *testing.T) {
+	target := createCheckerPattern(24, 17, 3)
+
+	rng := rand.New(rand.NewSource(1))
+	inds := make([]*Individual, 50)
+	expected := make([]float64, len(inds))
+	for i := range inds {
+		inds[i] = NewIndividual(rng, 24, 17)
+		inds[i].CalculateFitness(target)
+		expected[i] = inds[i].Fitness
+		inds[i].Fitness = 0
+	}
+
+	CalculateFitnessBatch(inds, target)
+
+	for i, ind := range inds {
+		if ind.Fitness != expected[i] {
+			t.Errorf("Individual %d: batch fitness %f, expected %f", i, ind.Fitness, expected[i])
+		}
+	}
+}
+
+func TestCalculateFitnessAtMost(t *testing.T) {
+	target := createCheckerPattern(24, 17, 3)
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		ind := NewIndividual(rng, 24, 17)
+		ind.CalculateFitness(target)
+		exact := ind.Fitness
+
+		// A limit above the true fitness must give the exact value
+		if !ind.CalculateFitnessAtMost(target, exact+1) || math.Abs(ind.Fitness-exact) > 1e-9 {
+			t.Errorf("Individual %d: bounded fitness %f (within limit), expected exact %f", i, ind.Fitness, exact)
+		}
+		// A limit below it must be reported as exceeded, with a value past the limit
+		limit := exact / 2
+		if ind.CalculateFitnessAtMost(target, limit) {
+			t.Errorf("Individual %d: fitness %f reported within limit %f", i, exact, limit)
+		}
+		if ind.Fitness < limit || ind.Fitness > exact+1e-9 {
+			t.Errorf("Individual %d: pruned fitness %f should be in [%f, %f]", i, ind.Fitness, limit, exact)
+		}
+	}
+}
+
+func newBenchmarkPopulation(n, size int) []*Individual {
+	rng := rand.New(rand.NewSource(1))
+	inds := make([]*Individual, n)
+	for i := range inds {
+		inds[i] = NewIndividual(rng, size, size)
+	}
+	return inds
+}
+
+func TestNewIndividualMatchesShapeList(t *testing.T) {
+	// All polygons share one drawing context; the result must be what drawing each
+	// shape from scratch gives
+	ind := NewIndividual(rand.New(rand.NewSource(9)), 60, 40)
+	redrawn := ind.CreateCopy()
+	redrawn.rasterize()
+	if !bytes.Equal(redrawn.Image.Pix, ind.Image.Pix) {
+		t.Error("Initial image doesn't match its redrawn shape list")
+	}
+}
+
+func BenchmarkNewIndividual(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		result = NewIndividual(rng, 200, 150)
+	}
+}
+
+func BenchmarkCalculateFitnessSingle(b *testing.B) {
+	target := createCheckerPattern(32, 32, 2)
+	inds := newBenchmarkPopulation(500, 32)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		for _, ind := range inds {
+			ind.CalculateFitness(target)
+		}
+	}
+}
+
+func BenchmarkCalculateFitnessBatch(b *testing.B) {
+	target := createCheckerPattern(32, 32, 2)
+	inds := newBenchmarkPopulation(500, 32)
+
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		CalculateFitnessBatch(inds, target)
+	}
+}
+
+func TestCreateRandomPolygonsRespectsShapeConfig(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	shapes := ShapeConfig{MinShapes: 2, MaxShapes: 4, MinVertices: 5, MaxVertices: 8}
+
+	seenShapes := map[int]bool{}
+	for i := 0; i < 200; i++ {
+		ind := &Individual{Image: image.NewRGBA(image.Rect(0, 0, 30, 30))}
+		polygons := ind.createRandomPolygons(rng, shapes, nil)
+
+		if len(polygons) < shapes.MinShapes || len(polygons) > shapes.MaxShapes {
+			t.Fatalf("Drew %d polygons, expected %d-%d", len(polygons), shapes.MinShapes, shapes.MaxShapes)
+		}
+		seenShapes[len(polygons)] = true
+		for _, polygon := range polygons {
+			if len(polygon.Points) < shapes.MinVertices || len(polygon.Points) > shapes.MaxVertices {
+				t.Fatalf("Polygon has %d vertices, expected %d-%d", len(polygon.Points), shapes.MinVertices, shapes.MaxVertices)
+			}
+		}
+	}
+	if len(seenShapes) != shapes.MaxShapes-shapes.MinShapes+1 {
+		t.Errorf("Expected every shape count in range to occur, saw %v", seenShapes)
+	}
+}
+
+func TestShapeConfigValidate(t *testing.T) {
+	invalid := []ShapeConfig{
+		{MinShapes: 5, MaxShapes: 2, MinVertices: 3, MaxVertices: 6},
+		{MinShapes: -1, MaxShapes: 2, MinVertices: 3, MaxVertices: 6},
+		{MinShapes: 1, MaxShapes: 2, MinVertices: 2, MaxVertices: 6},
+		{MinShapes: 1, MaxShapes: 2, MinVertices: 6, MaxVertices: 4},
+	}
+	for _, shapes := range invalid {
+		if err := shapes.Validate(); err == nil {
+			t.Errorf("Expected %+v to be rejected", shapes)
+		}
+		if _, err := NewGeneticAlgorithm(image.NewRGBA(image.Rect(0, 0, 4, 4)), 2, 1, 0.05, 1, WithInitShapes(shapes)); err == nil {
+			t.Errorf("Expected NewGeneticAlgorithm to reject %+v", shapes)
+		}
+	}
+	if err := DefaultShapeConfig.Validate(); err != nil {
+		t.Errorf("Default shape config is invalid: %v", err)
+	}
+}
+
+func TestNewIndividualFromImageShapeCount(t *testing.T) {
+	target := createCheckerPattern(32, 24, 4)
+	seed := NewIndividual(rand.New(rand.NewSource(2)), 32, 24)
+	seed.CalculateFitness(target)
+
+	rng := rand.New(rand.NewSource(1))
+	plain := NewIndividualFromImage(rng, seed.Image, 0)
+	plain.CalculateFitness(target)
+	if plain.Fitness != seed.Fitness {
+		t.Errorf("With no shapes: fitness %f, expected the seed's %f", plain.Fitness, seed.Fitness)
+	}
+	if plain.Shapes != nil {
+		t.Errorf("Expected no shape list on an individual seeded from an image")
+	}
+
+	perturbed := NewIndividualFromImage(rng, seed.Image, 5)
+	perturbed.CalculateFitness(target)
+	if perturbed.Fitness == seed.Fitness {
+		t.Errorf("With 5 shapes: fitness unchanged from the seed's %f", seed.Fitness)
+	}
+}
+
+func TestSeedImagePopulation(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	seed := NewIndividual(rand.New(rand.NewSource(2)), 16, 16)
+	seed.CalculateFitness(target)
+
+	ga, err := NewGeneticAlgorithm(target, 6, 1, 0.1, 2, WithSeedImage(seed.Image, 0))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	for i, ind := range ga.Population {
+		if ind.Fitness != seed.Fitness {
+			t.Errorf("Individual %d: fitness %f, expected the seed's %f", i, ind.Fitness, seed.Fitness)
+		}
+	}
+
+	if _, err := NewGeneticAlgorithm(target, 6, 1, 0.1, 2, WithSeedImage(image.NewRGBA(image.Rect(0, 0, 8, 8)), 0)); err == nil {
+		t.Error("Expected an error for a seed image of the wrong size")
+	}
+}
+
+func TestFillRuleCarriesOverToCopies(t *testing.T) {
+	shapes := DefaultShapeConfig
+	shapes.FillRule = FillEvenOdd
+	ind := newIndividual(rand.New(rand.NewSource(1)), 20, 20, RandomRGBA(rand.New(rand.NewSource(2))), shapes, nil)
+
+	for name, got := range map[string]*Individual{"new": ind, "copy": ind.CreateCopy(), "blank copy": ind.CreateBlankCopy()} {
+		if got.FillRule != FillEvenOdd {
+			t.Errorf("%s: expected the evenodd fill rule, got %v", name, got.FillRule)
+		}
+	}
+	// Redrawing from the shape list under the same rule reproduces the image
+	if sl, _ := ind.ShapeList(); !bytes.Equal(sl.Rasterize().Pix, ind.Image.Pix) {
+		t.Error("Expected the shape list to redraw the image with its fill rule")
+	}
+}
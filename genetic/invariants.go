@@ -0,0 +1,53 @@
+package genetic
+
+import (
+	"fmt"
+	"sort"
+)
+
+// checkPopulation reports whether population holds PopulationSize individuals sorted by
+// fitness, fittest first, which everything indexing population[0] relies on.
+func (ga *GeneticAlgorithm) checkPopulation(population []*Individual) error {
+	if len(population) != ga.PopulationSize {
+		return fmt.Errorf("population has %d individuals, expected %d", len(population), ga.PopulationSize)
+	}
+	for i, ind := range population {
+		if ind == nil {
+			return fmt.Errorf("individual %d is missing", i)
+		}
+		if i > 0 && ind.Fitness < population[i-1].Fitness {
+			return fmt.Errorf("population is not sorted: individual %d has fitness %f after %f", i, ind.Fitness, population[i-1].Fitness)
+		}
+	}
+	return nil
+}
+
+// repairPopulation restores the invariants checked by checkPopulation: missing individuals
+// are dropped, the rest are sorted, and the population is then truncated or padded with
+// copies of its fittest individual to PopulationSize.
+func (ga *GeneticAlgorithm) repairPopulation(population []*Individual) []*Individual {
+	repaired := make([]*Individual, 0, ga.PopulationSize)
+	for _, ind := range population {
+		if ind != nil {
+			repaired = append(repaired, ind)
+		}
+	}
+	sort.Slice(repaired, func(i, j int) bool {
+		return repaired[i].Fitness < repaired[j].Fitness
+	})
+
+	if len(repaired) > ga.PopulationSize {
+		return repaired[:ga.PopulationSize]
+	}
+	if len(repaired) == 0 {
+		// Nothing to copy from; start over from the all-time best
+		repaired = append(repaired, ga.best.CreateCopy())
+	}
+	// Copies of the fittest individual belong right after it to keep the order
+	fittest := repaired[0]
+	padding := make([]*Individual, ga.PopulationSize-len(repaired))
+	for i := range padding {
+		padding[i] = fittest.CreateCopy()
+	}
+	return append(append([]*Individual{fittest}, padding...), repaired[1:]...)
+}
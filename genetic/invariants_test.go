@@ -0,0 +1,57 @@
+package genetic
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestRepairUnsortedPopulation(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 12, 1, 0.05, 3, WithSeed(1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if err := ga.checkPopulation(ga.Population); err != nil {
+		t.Fatalf("Initial population violates invariants: %v", err)
+	}
+
+	// Reverse the population and lose two individuals other than the fittest
+	corrupt := make([]*Individual, 0, len(ga.Population))
+	for i := len(ga.Population) - 1; i >= 0; i-- {
+		corrupt = append(corrupt, ga.Population[i])
+	}
+	corrupt[3] = nil
+	corrupt = corrupt[1:]
+	rand.New(rand.NewSource(1)).Shuffle(len(corrupt), func(i, j int) { corrupt[i], corrupt[j] = corrupt[j], corrupt[i] })
+
+	if err := ga.checkPopulation(corrupt); err == nil {
+		t.Fatal("Expected the corrupt population to be detected")
+	}
+	repaired := ga.repairPopulation(corrupt)
+	if err := ga.checkPopulation(repaired); err != nil {
+		t.Fatalf("Repaired population still violates invariants: %v", err)
+	}
+	if repaired[0] != ga.Population[0] {
+		t.Errorf("Expected the fittest individual first, got fitness %f", repaired[0].Fitness)
+	}
+
+	// Too many individuals are truncated to the fittest
+	repaired = ga.repairPopulation(append(ga.Population, ga.Population...))
+	if err := ga.checkPopulation(repaired); err != nil {
+		t.Fatalf("Oversized population still violates invariants: %v", err)
+	}
+}
+
+func TestRunWithDebugInvariants(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 4), 10, 3, 0.05, 3, WithSeed(1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	ga.DebugInvariants = true
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if err := ga.checkPopulation(ga.Population); err != nil {
+		t.Errorf("Population violates invariants after Run: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package genetic
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// Images alive per individual during Run: the current population, the next one
+	// and in-flight children
+	imagesPerIndividual = 3
+	// Without an explicit limit, a population may use at most this share of the
+	// memory the system reports as available
+	defaultMemoryFraction = 0.8
+)
+
+// RequiredMemory returns the approximate bytes needed to evolve a population of
+// popSize width x height images. It is a float so that absurd sizes can't overflow.
+func RequiredMemory(width, height, popSize int) float64 {
+	return imagesPerIndividual * float64(popSize) * float64(width) * float64(height) * 4
+}
+
+// checkMemory returns an error if the population would need more than limit bytes.
+// A limit of 0 uses a share of the system's available memory, and skips the check
+// when that can't be determined.
+func checkMemory(width, height, popSize int, limit uint64) error {
+	if limit == 0 {
+		available, ok := availableMemory()
+		if !ok {
+			return nil
+		}
+		limit = uint64(float64(available) * defaultMemoryFraction)
+	}
+	required := RequiredMemory(width, height, popSize)
+	if required > float64(limit) {
+		return fmt.Errorf("a population of %d %dx%d images needs about %.0f MiB, more than the %.0f MiB memory limit; reduce the population size or image size",
+			popSize, width, height, required/(1<<20), float64(limit)/(1<<20))
+	}
+	return nil
+}
+
+// availableMemory reads MemAvailable from /proc/meminfo. It reports false on
+// systems without it.
+func availableMemory() (uint64, bool) {
+	file, err := os.Open("/proc/meminfo")
+	if err != nil {
+		return 0, false
+	}
+	defer file.Close()
+	return parseMemAvailable(file)
+}
+
+// parseMemAvailable extracts the MemAvailable line, given in kB, from meminfo content.
+func parseMemAvailable(r io.Reader) (uint64, bool) {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemAvailable:" {
+			continue
+		}
+		kb, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, false
+		}
+		return kb * 1024, true
+	}
+	return 0, false
+}
@@ -0,0 +1,50 @@
+package genetic
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestNewGeneticAlgorithmRejectsPopulationOverMemoryLimit(t *testing.T) {
+	target := createCheckerPattern(32, 32, 4)
+
+	// 10 individuals of 32x32 need about 120 KiB, far more than a 1 KiB limit
+	ga, err := NewGeneticAlgorithm(target, 10, 1, 0.1, 2, WithMemoryLimit(1<<10))
+	if err == nil {
+		t.Fatal("Expected an error for a population over the memory limit")
+	}
+	if ga != nil {
+		t.Error("Expected no algorithm when the memory check fails")
+	}
+	if !strings.Contains(err.Error(), "memory limit") {
+		t.Errorf("Expected a descriptive error, got %q", err)
+	}
+
+	if _, err := NewGeneticAlgorithm(target, 10, 1, 0.1, 2, WithMemoryLimit(1<<20)); err != nil {
+		t.Errorf("Expected a population within the limit to be created, got %v", err)
+	}
+
+	// A schedule that grows the population is held to the same limit
+	grow := PopulationSchedule{{Generation: 2, Size: 1000}}
+	if _, err := NewGeneticAlgorithm(target, 10, 1, 0.1, 2, WithMemoryLimit(1<<20), WithPopulationSchedule(grow)); err == nil {
+		t.Error("Expected an error for a population schedule over the memory limit")
+	}
+}
+
+func TestCheckMemoryDoesNotOverflow(t *testing.T) {
+	if err := checkMemory(1<<30, 1<<30, 1<<30, 1<<62); err == nil {
+		t.Error("Expected an error for an absurdly large population")
+	}
+}
+
+func TestParseMemAvailable(t *testing.T) {
+	meminfo := "MemTotal:       16303452 kB\nMemFree:         1234567 kB\nMemAvailable:    8000000 kB\n"
+	got, ok := parseMemAvailable(strings.NewReader(meminfo))
+	if !ok || got != 8000000*1024 {
+		t.Errorf("Expected %d bytes, got %d (ok=%t)", 8000000*1024, got, ok)
+	}
+
+	if _, ok := parseMemAvailable(strings.NewReader("MemTotal: 1 kB\n")); ok {
+		t.Error("Expected no result without a MemAvailable line")
+	}
+}
@@ -0,0 +1,139 @@
+package genetic
+
+import (
+	"fmt"
+	"image"
+	"math"
+
+	"github.com/bishal0602/chaotic-canvas/imageio"
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// Metric measures a candidate image against the target. Both images must have the
+// same size and start at the origin.
+type Metric func(candidate, target *image.RGBA) float64
+
+// ParseMetric returns the metric with the given name:
+//   - rmse is the built-in fitness; lower is better and 0 is identical
+//   - ssim is the mean structural similarity of the luminance, up to 1 for identical images
+//   - deltae is the mean CIE76 color difference; 0 is identical and about 2.3 is just noticeable
+func ParseMetric(name string) (Metric, error) {
+	switch name {
+	case "rmse":
+		return rmse, nil
+	case "ssim":
+		return SSIM, nil
+	case "deltae":
+		return DeltaE, nil
+	}
+	return nil, fmt.Errorf("unknown metric %q, expected rmse, ssim or deltae", name)
+}
+
+// rmse is the built-in fitness without any optional terms.
+func rmse(candidate, target *image.RGBA) float64 {
+	ind := &Individual{Image: candidate}
+	ind.CalculateFitness(target)
+	return ind.Fitness
+}
+
+// ssimWindow and ssimStride are the size and spacing of the square windows SSIM is
+// averaged over.
+const (
+	ssimWindow = 8
+	ssimStride = 4
+)
+
+// SSIM returns the mean structural similarity of the candidate's luminance to the
+// target's over overlapping 8x8 windows. Images smaller than a window are compared
+// as a single window.
+func SSIM(candidate, target *image.RGBA) float64 {
+	bounds := target.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return 1
+	}
+	a, b := luminance(candidate), luminance(target)
+
+	// Stabilizing constants from the original SSIM paper for 8-bit values
+	const c1, c2 = (0.01 * 255) * (0.01 * 255), (0.03 * 255) * (0.03 * 255)
+	winW, winH := mathutil.Min(ssimWindow, width), mathutil.Min(ssimWindow, height)
+	var total float64
+	var windows int
+	for y0 := 0; y0+winH <= height; y0 += ssimStride {
+		for x0 := 0; x0+winW <= width; x0 += ssimStride {
+			var sumA, sumB, sumAA, sumBB, sumAB float64
+			for y := y0; y < y0+winH; y++ {
+				for x := x0; x < x0+winW; x++ {
+					va, vb := a[y*width+x], b[y*width+x]
+					sumA += va
+					sumB += vb
+					sumAA += va * va
+					sumBB += vb * vb
+					sumAB += va * vb
+				}
+			}
+			n := float64(winW * winH)
+			meanA, meanB := sumA/n, sumB/n
+			varA, varB := sumAA/n-meanA*meanA, sumBB/n-meanB*meanB
+			cov := sumAB/n - meanA*meanB
+			total += (2*meanA*meanB + c1) * (2*cov + c2) / ((meanA*meanA + meanB*meanB + c1) * (varA + varB + c2))
+			windows++
+		}
+	}
+	return total / float64(windows)
+}
+
+// luminance returns the Rec. 601 luma of every pixel of img in row order.
+func luminance(img *image.RGBA) []float64 {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	lum := make([]float64, width*height)
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride:]
+		for x := 0; x < width; x++ {
+			r, g, b := float64(row[x*4]), float64(row[x*4+1]), float64(row[x*4+2])
+			lum[y*width+x] = 0.299*r + 0.587*g + 0.114*b
+		}
+	}
+	return lum
+}
+
+// DeltaE returns the mean CIE76 color difference between the candidate and target
+// pixels, their Euclidean distance in CIELAB under a D65 white point.
+func DeltaE(candidate, target *image.RGBA) float64 {
+	bounds := target.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width == 0 || height == 0 {
+		return 0
+	}
+	var total float64
+	for y := 0; y < height; y++ {
+		rowA, rowB := candidate.Pix[y*candidate.Stride:], target.Pix[y*target.Stride:]
+		for x := 0; x < width; x++ {
+			l1, a1, b1 := toLab(rowA[x*4], rowA[x*4+1], rowA[x*4+2])
+			l2, a2, b2 := toLab(rowB[x*4], rowB[x*4+1], rowB[x*4+2])
+			total += math.Sqrt((l1-l2)*(l1-l2) + (a1-a2)*(a1-a2) + (b1-b2)*(b1-b2))
+		}
+	}
+	return total / float64(width*height)
+}
+
+// toLab converts an sRGB color to CIELAB with a D65 white point.
+func toLab(r8, g8, b8 uint8) (l, a, b float64) {
+	r, g, bl := imageio.SRGBToLinear(r8), imageio.SRGBToLinear(g8), imageio.SRGBToLinear(b8)
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*bl) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*bl
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*bl) / 1.08883
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return 116*fy - 16, 500 * (fx - fy), 200 * (fy - fz)
+}
+
+// labF is the CIELAB companding function, linear near black to avoid an infinite slope.
+func labF(t float64) float64 {
+	const delta = 6.0 / 29
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29
+}
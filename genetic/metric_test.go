@@ -0,0 +1,83 @@
+package genetic
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func solid(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = c.R, c.G, c.B, c.A
+	}
+	return img
+}
+
+func TestMetricsOnIdenticalImages(t *testing.T) {
+	target := createCheckerPattern(20, 12, 3)
+	for name, want := range map[string]float64{"rmse": 0, "ssim": 1, "deltae": 0} {
+		metric, err := ParseMetric(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := metric(target, target); math.Abs(got-want) > 1e-9 {
+			t.Errorf("Expected %s of identical images to be %v, got %v", name, want, got)
+		}
+	}
+	if _, err := ParseMetric("psnr"); err == nil {
+		t.Error("Expected an error for an unknown metric")
+	}
+}
+
+func TestMetricsOnDifferentImages(t *testing.T) {
+	black := solid(10, 10, color.RGBA{A: 255})
+	white := solid(10, 10, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	// Black and white are L*=0 and L*=100 with no chroma
+	if got := DeltaE(black, white); math.Abs(got-100) > 0.01 {
+		t.Errorf("Expected a ΔE of 100 between black and white, got %f", got)
+	}
+
+	target := createCheckerPattern(16, 16, 2)
+	grey := solid(16, 16, color.RGBA{R: 128, G: 128, B: 128, A: 255})
+	if got := SSIM(grey, target); got > 0.1 {
+		t.Errorf("Expected a flat image to have little structure in common with a checkerboard, got SSIM %f", got)
+	}
+}
+
+func TestReportMetricIsSeparateFromFitness(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 8, 5, 0.3, 2, WithSeed(2))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	plain, err := NewGeneticAlgorithm(target, 8, 5, 0.3, 2, WithSeed(2))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	ga.ReportMetric = SSIM
+
+	recv := make(chan ImageResult, ga.Generations)
+	best, err := ga.Run(recv, 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	plainBest, err := plain.Run(nil, 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if best.Fitness != plainBest.Fitness {
+		t.Errorf("Expected the report metric not to affect selection, got fitness %f vs %f", best.Fitness, plainBest.Fitness)
+	}
+
+	for result := range recv {
+		want := SSIM(result.Img.(*image.RGBA), ga.TargetRGBA)
+		if result.Report != want {
+			t.Errorf("Generation %d: expected the report to be the SSIM %f, got %f", result.Generation, want, result.Report)
+		}
+		if result.Report == result.Fitness {
+			t.Errorf("Generation %d: expected the report to differ from the fitness %f", result.Generation, result.Fitness)
+		}
+	}
+}
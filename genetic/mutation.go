@@ -1,13 +1,14 @@
 package genetic
 
 import (
+	"fmt"
 	"image"
+	"image/color"
 	"math"
 	"math/rand"
 	"sync"
 
 	"github.com/bishal0602/chaotic-canvas/mathutil"
-	"github.com/fogleman/gg"
 )
 
 const (
@@ -30,8 +31,42 @@ const (
 	minPolygonPoints               int = 3
 	maxPolygonPoints               int = 6
 	highMutationExtraPoints        int = 2
+
+	// Mutation strength used when none is set
+	defaultMutationStrength float64 = 0.05
+	// Mutation rate pinned during the warm-up generations
+	defaultWarmupMutationRate float64 = maxMutationRateCeiling
+
+	// Shape edits, applied instead of adding polygons when the shape list is retained
+	translateShapeProbability float64 = 0.15
+	scaleShapeProbability     float64 = 0.10
+	vertexCountProbability    float64 = 0.10
+	reorderShapeProbability   float64 = 0.05
+	maxShapeShiftFraction     float64 = 0.05 // of the larger image side
+	minShapeScale             float64 = 0.8
+	maxShapeScale             float64 = 1.25
+	// maxColorShift bounds the per-channel change when a shape is recolored
+	maxColorShift int = 32
+)
+
+// mutationKind identifies the edit Mutate made to an individual.
+type mutationKind int
+
+const (
+	mutationNone mutationKind = iota
+	mutationAddPolygons
+	mutationTranslate
+	mutationScale
+	mutationVertexCount
+	mutationReorder
+	mutationRecolor
+	numMutationKinds
 )
 
+func (kind mutationKind) String() string {
+	return [...]string{"none", "add", "translate", "scale", "vertices", "reorder", "recolor"}[kind]
+}
+
 // MutationHistory tracks fitness progress over time.
 type MutationHistory struct {
 	history      []float64
@@ -73,30 +108,99 @@ func (mh *MutationHistory) GetImprovementScore() float64 {
 	return improvements / float64(mh.size-1)
 }
 
+// MutationStrategy decides the mutation rate for each generation.
+type MutationStrategy interface {
+	// Update is called once per generation, before evolving pop, and returns the mutation rate to use.
+	Update(pop []*Individual, gen, maxGen int) float64
+}
+
+// FixedMutationStrategy always returns the same mutation rate.
+type FixedMutationStrategy struct {
+	rate float64
+}
+
+// NewFixedMutationStrategy returns a strategy that uses rate for every generation.
+func NewFixedMutationStrategy(rate float64) *FixedMutationStrategy {
+	return &FixedMutationStrategy{rate: rate}
+}
+
+// Update returns the fixed mutation rate.
+func (fms *FixedMutationStrategy) Update(pop []*Individual, gen, maxGen int) float64 {
+	return fms.rate
+}
+
+// AdaptiveMutationStrategy adjusts the mutation rate based on stagnation, diversity and progress.
 type AdaptiveMutationStrategy struct {
 	baseRate float64
 	minRate  float64
 	maxRate  float64
 	history  *MutationHistory
+	rng      *rand.Rand
 }
 
-func NewAdaptiveMutationStrategy(baseMutationRate float64) *AdaptiveMutationStrategy {
+func NewAdaptiveMutationStrategy(baseMutationRate float64, rng *rand.Rand) *AdaptiveMutationStrategy {
 	return &AdaptiveMutationStrategy{
 		baseRate: baseMutationRate,
 		minRate:  mathutil.Max(minMutationRateFloor, minMutationRateScale*baseMutationRate),
 		maxRate:  mathutil.Min(maxMutationRateCeiling, maxMutationRateScale*baseMutationRate),
 		history:  NewMutationHistory(mutationHistorySize),
+		rng:      rng,
 	}
 }
 
+// AdaptiveMutationState is the serializable state of an AdaptiveMutationStrategy, so a
+// resumed run can carry on with the same rate bounds and fitness history instead of
+// re-learning them.
+type AdaptiveMutationState struct {
+	BaseRate     float64
+	MinRate      float64
+	MaxRate      float64
+	History      []float64
+	Index        int
+	LastBest     float64
+	PlateauCount int
+}
+
+// State returns a copy of the strategy's state.
+func (ams *AdaptiveMutationStrategy) State() AdaptiveMutationState {
+	return AdaptiveMutationState{
+		BaseRate:     ams.baseRate,
+		MinRate:      ams.minRate,
+		MaxRate:      ams.maxRate,
+		History:      append([]float64(nil), ams.history.history...),
+		Index:        ams.history.index,
+		LastBest:     ams.history.lastBest,
+		PlateauCount: ams.history.plateauCount,
+	}
+}
+
+// RestoreAdaptiveMutationStrategy recreates a strategy from a saved state. rng supplies
+// the strategy's random jitter from here on.
+func RestoreAdaptiveMutationStrategy(state AdaptiveMutationState, rng *rand.Rand) (*AdaptiveMutationStrategy, error) {
+	if len(state.History) < 2 {
+		return nil, fmt.Errorf("mutation history must hold at least 2 entries, got %d", len(state.History))
+	}
+	if state.Index < 0 || state.Index >= len(state.History) {
+		return nil, fmt.Errorf("mutation history index %d out of range for %d entries", state.Index, len(state.History))
+	}
+	return &AdaptiveMutationStrategy{
+		baseRate: state.BaseRate,
+		minRate:  state.MinRate,
+		maxRate:  state.MaxRate,
+		history: &MutationHistory{
+			history:      append([]float64(nil), state.History...),
+			size:         len(state.History),
+			index:        state.Index,
+			lastBest:     state.LastBest,
+			plateauCount: state.PlateauCount,
+		},
+		rng: rng,
+	}, nil
+}
+
 // Update records the current generation's fitness and calculates the appropriate mutation rate
 func (ams *AdaptiveMutationStrategy) Update(pop []*Individual, gen, maxGen int) float64 {
-	// Calculate current average fitness
-	avgFitness := 0.0
-	for _, ind := range pop {
-		avgFitness += ind.Fitness
-	}
-	avgFitness /= float64(len(pop))
+	avgFitness := averageFitness(pop)
 	bestFitness := pop[0].Fitness
 	ams.history.Record(avgFitness, bestFitness)
 
@@ -145,7 +249,8 @@ func (cm *CacheManager) getMutationCache(region int) *MutationCache {
 		logSize = 1 // Prevent log(0) and logSize = 0
 	}
 	floorPower := mathutil.FloorPowerOfTen(region)
-	maxLimit := region >> 4 // At most 1/16 of the image area
+	// At most 1/16 of the image area, but never below 1 so tiny images still get a valid spread
+	maxLimit := mathutil.Max(region>>4, 1)
 	cache := &MutationCache{
 		LogSize:    logSize,
 		FloorPower: floorPower,
@@ -157,20 +262,48 @@ func (cm *CacheManager) getMutationCache(region int) *MutationCache {
 }
 
 // Mutate creates a modified copy of the individual by adding random polygons.
-func (ga *GeneticAlgorithm) Mutate(ind *Individual) *Individual {
-	if rand.Float64() > ga.MutationRate {
-		return ind
+func (ga *GeneticAlgorithm) Mutate(rng *rand.Rand, ind *Individual) *Individual {
+	child, _ := ga.mutate(rng, ind, &ga.fills)
+	return child
+}
+
+// mutate is Mutate that also returns the kind of mutation applied, taking its polygon
+// fills from fills.
+func (ga *GeneticAlgorithm) mutate(rng *rand.Rand, ind *Individual, fills *fillBudget) (*Individual, mutationKind) {
+	if rng.Float64() > ga.MutationRate {
+		return ind, mutationNone
 	}
 
 	child := ind.CreateCopy()
+	// Editing a shape redraws all of them, so it needs room for every fill
+	if fills.takeAll(len(child.Shapes)) {
+		if kind := child.mutateShape(rng, ga.initShapes); kind != mutationNone {
+			return child, kind
+		}
+		fills.refund(len(child.Shapes))
+	}
+	if ga.fixedShapes > 0 {
+		// The shape budget is fixed, so recolor a shape instead of adding polygons
+		if len(child.Shapes) == 0 || !fills.takeAll(len(child.Shapes)) {
+			return ind, mutationNone
+		}
+		child.recolorShape(rng, rng.Intn(len(child.Shapes)), ga.lockedPalette)
+		child.rasterize()
+		return child, mutationRecolor
+	}
+
+	strength := ga.MutationStrength
 	iterations := func() int {
-		it := mathutil.RandomBetween(minMutationIterations, maxMutationIterationsBase)
-		// Check if we should do a more radical mutation based on stagnation
-		if ga.MutationRate > 0.1 && rand.Float64() < ga.MutationRate*2 {
-			it += rand.Intn(radicalMutationExtraIterations)
+		it := mathutil.RandomBetweenR(rng, minMutationIterations, maxMutationIterationsBase)
+		// Strong mutations sometimes add extra polygons
+		if strength > 0.1 && rng.Float64() < strength*2 {
+			it += rng.Intn(radicalMutationExtraIterations)
 		}
 		return it
 	}()
+	if iterations = fills.take(iterations); iterations == 0 {
+		return ind, mutationNone
+	}
 
 	region := child.Image.Bounds().Dx() * child.Image.Bounds().Dy()
 	// Retrieve precomputed mutation values from global cache
@@ -179,51 +312,197 @@ func (ga *GeneticAlgorithm) Mutate(ind *Individual) *Individual {
 	logSize := cache.LogSize
 	floorPower := cache.FloorPower
 
-	dc := gg.NewContextForRGBA(child.Image)
+	maxArea := ga.initShapes.maxShapeArea(child.Image.Bounds().Dx(), child.Image.Bounds().Dy())
+	dc := child.drawingContext()
 
 	for i := 0; i < iterations; i++ {
 		// Randomly scale mutation size within a reasonable range
-		scaleFactor := mathutil.RandomBetween(1, int(logSize*5))
-		divisor := ga.MutationRate * float64(mathutil.RandomBetween(50, floorPower))
-		regionLimit := (region / int(mathutil.Max(divisor, 1))) / scaleFactor
+		scaleFactor := mathutil.RandomBetweenR(rng, 1, mathutil.Max(int(logSize*5), 1))
+		// Polygons grow with strength, and at the default strength keep their historical size.
+		// A strength of 0 gives the smallest polygons.
+		divisor := float64(region)
+		if strength > 0 {
+			// Small images have a floor power below 50; don't let RandomBetween silently swap the bounds
+			divisor = defaultMutationStrength * defaultMutationStrength / strength * float64(mathutil.RandomBetweenR(rng, 50, mathutil.Max(floorPower, 50)))
+		}
+		regionLimit := (region / int(mathutil.Clamp(divisor, 1, float64(region)))) / scaleFactor
 		regionLimit = mathutil.Clamp(regionLimit, 1, maxLimit)
 		// fmt.Printf("scale: %v, divisor: %v, limit: %v, mut: %v\n", scaleFactor, divisor, regionLimit, ga.MutationRate)
 
 		numPoints := func() int {
-			n := mathutil.RandomBetween(minPolygonPoints, maxPolygonPoints)
-			if ga.MutationRate > 0.1 {
+			n := mathutil.RandomBetweenR(rng, minPolygonPoints, maxPolygonPoints)
+			if strength > 0.1 {
 				n += highMutationExtraPoints
 			}
 			return n
 		}()
 
-		regionX := rand.Intn(child.Image.Bounds().Dx())
-		regionY := rand.Intn(child.Image.Bounds().Dy())
+		var regionX, regionY int
+		if ga.errorMap != nil {
+			regionX, regionY = ga.errorMap.sample(rng)
+		} else {
+			regionX = rng.Intn(child.Image.Bounds().Dx())
+			regionY = rng.Intn(child.Image.Bounds().Dy())
+		}
 
 		polygon := Polygon{
 			Points: make([]image.Point, numPoints),
-			Color:  RandomRGBA(),
+			Color:  paletteColor(rng, ga.lockedPalette),
 		}
 
 		for j := 0; j < numPoints; j++ {
-			x := mathutil.Clamp(regionX+rand.Intn(2*regionLimit)-regionLimit, 0, child.Image.Bounds().Dx()-1)
-			y := mathutil.Clamp(regionY+rand.Intn(2*regionLimit)-regionLimit, 0, child.Image.Bounds().Dy()-1)
+			x := mathutil.Clamp(regionX+rng.Intn(2*regionLimit)-regionLimit, 0, child.Image.Bounds().Dx())
+			y := mathutil.Clamp(regionY+rng.Intn(2*regionLimit)-regionLimit, 0, child.Image.Bounds().Dy())
 			polygon.Points[j] = image.Point{X: x, Y: y}
 		}
+		capShapeArea(polygon.Points, maxArea)
 
-		dc.SetRGBA255(int(polygon.Color.R), int(polygon.Color.G), int(polygon.Color.B), int(polygon.Color.A))
-		for j, point := range polygon.Points {
-			if j == 0 {
-				dc.MoveTo(float64(point.X), float64(point.Y))
-			} else {
-				dc.LineTo(float64(point.X), float64(point.Y))
-			}
+		drawPolygon(dc, polygon)
+		if child.hasShapes() {
+			child.Shapes = append(child.Shapes, polygon)
 		}
-		dc.ClosePath()
-		dc.Fill()
 	}
 
-	return child
+	return child, mutationAddPolygons
+}
+
+// mutateShape edits one of the individual's existing shapes and redraws the image.
+// Vertex counts are kept within the bounds of shapes. It returns the kind of edit made,
+// or mutationNone, leaving the individual untouched, when no edit was chosen or there
+// are no retained shapes to edit.
+func (ind *Individual) mutateShape(rng *rand.Rand, shapes ShapeConfig) mutationKind {
+	if len(ind.Shapes) == 0 {
+		return mutationNone
+	}
+
+	r := rng.Float64()
+	idx := rng.Intn(len(ind.Shapes))
+	var kind mutationKind
+	switch {
+	case r < translateShapeProbability:
+		ind.translateShape(rng, idx)
+		kind = mutationTranslate
+	case r < translateShapeProbability+scaleShapeProbability:
+		ind.scaleShape(rng, idx)
+		kind = mutationScale
+	case r < translateShapeProbability+scaleShapeProbability+vertexCountProbability:
+		if !ind.changeVertexCount(rng, idx, shapes.MinVertices, shapes.MaxVertices) {
+			return mutationNone
+		}
+		kind = mutationVertexCount
+	case r < translateShapeProbability+scaleShapeProbability+vertexCountProbability+reorderShapeProbability:
+		if !ind.swapShapeOrder(rng) {
+			return mutationNone
+		}
+		kind = mutationReorder
+	default:
+		return mutationNone
+	}
+	bounds := ind.Image.Bounds()
+	capShapeArea(ind.Shapes[idx].Points, shapes.maxShapeArea(bounds.Dx(), bounds.Dy()))
+	ind.rasterize()
+	return kind
+}
+
+// translateShape moves every point of shape idx by the same small random offset, clamped to the image.
+func (ind *Individual) translateShape(rng *rand.Rand, idx int) {
+	bounds := ind.Image.Bounds()
+	maxShift := mathutil.Max(int(float64(mathutil.Max(bounds.Dx(), bounds.Dy()))*maxShapeShiftFraction), 1)
+	dx := rng.Intn(2*maxShift+1) - maxShift
+	dy := rng.Intn(2*maxShift+1) - maxShift
+
+	points := ind.Shapes[idx].Points
+	for i, p := range points {
+		points[i] = image.Point{
+			X: mathutil.Clamp(p.X+dx, 0, bounds.Dx()),
+			Y: mathutil.Clamp(p.Y+dy, 0, bounds.Dy()),
+		}
+	}
+}
+
+// scaleShape scales shape idx about its centroid by a random factor, clamped to the image.
+func (ind *Individual) scaleShape(rng *rand.Rand, idx int) {
+	bounds := ind.Image.Bounds()
+	factor := minShapeScale + rng.Float64()*(maxShapeScale-minShapeScale)
+
+	points := ind.Shapes[idx].Points
+	var cx, cy float64
+	for _, p := range points {
+		cx += float64(p.X)
+		cy += float64(p.Y)
+	}
+	cx /= float64(len(points))
+	cy /= float64(len(points))
+
+	for i, p := range points {
+		x := cx + (float64(p.X)-cx)*factor
+		y := cy + (float64(p.Y)-cy)*factor
+		points[i] = image.Point{
+			X: mathutil.Clamp(int(math.Round(x)), 0, bounds.Dx()),
+			Y: mathutil.Clamp(int(math.Round(y)), 0, bounds.Dy()),
+		}
+	}
+}
+
+// changeVertexCount adds a vertex near the midpoint of an edge of shape idx, or removes one
+// of its vertices, keeping the count within [minVertices, maxVertices] and never below 3.
+// It reports whether the shape changed.
+func (ind *Individual) changeVertexCount(rng *rand.Rand, idx, minVertices, maxVertices int) bool {
+	minVertices = mathutil.Max(minVertices, minPolygonPoints)
+	points := ind.Shapes[idx].Points
+	n := len(points)
+	canAdd, canRemove := n < maxVertices, n > minVertices
+
+	if canAdd && (!canRemove || rng.Float64() < 0.5) {
+		bounds := ind.Image.Bounds()
+		i := rng.Intn(n)
+		a, b := points[i], points[(i+1)%n]
+		// Nudge the midpoint off the edge, otherwise the new vertex wouldn't change the outline
+		maxShift := mathutil.Max(int(float64(mathutil.Max(bounds.Dx(), bounds.Dy()))*maxShapeShiftFraction), 1)
+		mid := image.Point{
+			X: mathutil.Clamp((a.X+b.X)/2+rng.Intn(2*maxShift+1)-maxShift, 0, bounds.Dx()),
+			Y: mathutil.Clamp((a.Y+b.Y)/2+rng.Intn(2*maxShift+1)-maxShift, 0, bounds.Dy()),
+		}
+		points = append(points[:i+1], append([]image.Point{mid}, points[i+1:]...)...)
+	} else if canRemove {
+		i := rng.Intn(n)
+		points = append(points[:i], points[i+1:]...)
+	} else {
+		return false
+	}
+
+	ind.Shapes[idx].Points = points
+	return true
+}
+
+// recolorShape shifts each channel of shape idx's color by a small random amount, keeping
+// alpha at least as high as RandomRGBA's floor. With a palette, the new color is instead
+// a palette entry, keeping the shape's alpha.
+func (ind *Individual) recolorShape(rng *rand.Rand, idx int, palette []color.RGBA) {
+	c := &ind.Shapes[idx].Color
+	if len(palette) > 0 {
+		p := palette[rng.Intn(len(palette))]
+		c.R, c.G, c.B = p.R, p.G, p.B
+		return
+	}
+	shift := func(v uint8, low int) uint8 {
+		return uint8(mathutil.Clamp(int(v)+rng.Intn(2*maxColorShift+1)-maxColorShift, low, 255))
+	}
+	c.R, c.G, c.B = shift(c.R, 0), shift(c.G, 0), shift(c.B, 0)
+	c.A = shift(c.A, 50)
+}
+
+// swapShapeOrder swaps the draw order of two random shapes. It reports false when there
+// are fewer than two shapes.
+func (ind *Individual) swapShapeOrder(rng *rand.Rand) bool {
+	n := len(ind.Shapes)
+	if n < 2 {
+		return false
+	}
+	i := rng.Intn(n)
+	j := (i + 1 + rng.Intn(n-1)) % n
+	ind.Shapes[i], ind.Shapes[j] = ind.Shapes[j], ind.Shapes[i]
+	return true
 }
 
 // computeMutationRate adjusts mutation based on factors:
@@ -247,7 +526,7 @@ func (ams *AdaptiveMutationStrategy) computeMutationRate(stagnation, diversity,
 	}
 
 	// Mutation rate should not be strictly deterministic. Adding ±5% randomness
-	randomFactor := 1.0 + (rand.Float64()*0.1 - 0.05)
+	randomFactor := 1.0 + (ams.rng.Float64()*0.1 - 0.05)
 
 	rate := ams.baseRate * stagnationFactor * diversityFactor * progressFactor * plateauFactor * randomFactor
 
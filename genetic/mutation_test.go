@@ -0,0 +1,468 @@
+package genetic
+
+import (
+	"bytes"
+	"encoding/gob"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestFixedMutationStrategy(t *testing.T) {
+	const rate = 0.07
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 2), 10, 25, rate, 3)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	ga.MutationStrategy = NewFixedMutationStrategy(rate)
+
+	recv := make(chan ImageResult, ga.Generations)
+	if _, err := ga.Run(recv, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	count := 0
+	for result := range recv {
+		count++
+		if result.MutationRate != rate {
+			t.Errorf("Generation %d: mutation rate %f, expected %f", result.Generation, result.MutationRate, rate)
+		}
+	}
+	if count != ga.Generations {
+		t.Errorf("Expected %d results, got %d", ga.Generations, count)
+	}
+}
+
+func TestMutateTinyImages(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for size := 1; size <= 8; size++ {
+		for _, rate := range []float64{0.05, 0.5, 1} {
+			ga, err := NewGeneticAlgorithm(image.NewRGBA(image.Rect(0, 0, size, size+1)), 2, 1, rate, 1)
+			if err != nil {
+				t.Fatalf("Failed to create GA: %v", err)
+			}
+			for _, strength := range []float64{0, 0.05, 1} {
+				ga.MutationStrength = strength
+				for i := 0; i < 50; i++ {
+					child := ga.Mutate(rng, ga.Population[0])
+					if child.Image.Bounds() != ga.Population[0].Image.Bounds() {
+						t.Fatalf("%dx%d: mutated image has bounds %v", size, size+1, child.Image.Bounds())
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestMutationStrengthScalesPixelChange(t *testing.T) {
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(64, 64, 8), 2, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	// Without a shape list every mutation adds polygons rather than editing shapes
+	parent := ga.Population[0].CreateCopy()
+	parent.Shapes = nil
+
+	averageChange := func(strength float64) float64 {
+		ga.MutationStrength = strength
+		rng := rand.New(rand.NewSource(1))
+		const trials = 300
+		changed := 0
+		for i := 0; i < trials; i++ {
+			child := ga.Mutate(rng, parent)
+			for p := 0; p < len(child.Image.Pix); p += 4 {
+				if !bytes.Equal(child.Image.Pix[p:p+4], parent.Image.Pix[p:p+4]) {
+					changed++
+				}
+			}
+		}
+		return float64(changed) / trials
+	}
+
+	zero, weak, strong := averageChange(0), averageChange(0.02), averageChange(0.3)
+	if strong <= weak || weak <= zero {
+		t.Errorf("Expected stronger mutations to change more pixels at the same rate: %.1f at 0, %.1f at 0.02, %.1f at 0.3", zero, weak, strong)
+	}
+}
+
+func TestMutationCacheTinyRegion(t *testing.T) {
+	for region := 1; region < 32; region++ {
+		if cache := cacheManager.getMutationCache(region); cache.MaxLimit < 1 {
+			t.Errorf("Region %d: max limit %d, expected at least 1", region, cache.MaxLimit)
+		}
+	}
+}
+
+// newSquareIndividual returns a white individual with one black square shape.
+func newSquareIndividual(size int, square image.Rectangle) *Individual {
+	ind := &Individual{
+		Image:      image.NewRGBA(image.Rect(0, 0, size, size)),
+		Background: color.RGBA{255, 255, 255, 255},
+		Shapes: []Polygon{{
+			Points: []image.Point{
+				square.Min,
+				{X: square.Max.X, Y: square.Min.Y},
+				square.Max,
+				{X: square.Min.X, Y: square.Max.Y},
+			},
+			Color: color.RGBA{0, 0, 0, 255},
+		}},
+	}
+	ind.rasterize()
+	return ind
+}
+
+// hillClimbShape applies edit to copies of ind, keeping each copy that lowers the fitness against target.
+func hillClimbShape(ind *Individual, target *image.RGBA, steps int, edit func(*Individual)) *Individual {
+	ind.CalculateFitness(target)
+	for i := 0; i < steps; i++ {
+		child := ind.CreateCopy()
+		edit(child)
+		child.rasterize()
+		child.CalculateFitness(target)
+		if child.Fitness < ind.Fitness {
+			ind = child
+		}
+	}
+	return ind
+}
+
+func TestTranslateShapeNudgesTowardTarget(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	target := newSquareIndividual(32, image.Rect(8, 8, 16, 16)).Image
+	ind := newSquareIndividual(32, image.Rect(11, 10, 19, 18))
+	ind.CalculateFitness(target)
+	initial := ind.Fitness
+
+	best := hillClimbShape(ind, target, 200, func(c *Individual) { c.translateShape(rng, 0) })
+	if best.Fitness >= initial {
+		t.Errorf("Translating the misplaced shape didn't lower fitness: %f -> %f", initial, best.Fitness)
+	}
+}
+
+func TestScaleShapeNudgesTowardTarget(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	target := newSquareIndividual(32, image.Rect(8, 8, 24, 24)).Image
+	ind := newSquareIndividual(32, image.Rect(12, 12, 20, 20))
+	ind.CalculateFitness(target)
+	initial := ind.Fitness
+
+	best := hillClimbShape(ind, target, 200, func(c *Individual) { c.scaleShape(rng, 0) })
+	if best.Fitness >= initial {
+		t.Errorf("Scaling the undersized shape didn't lower fitness: %f -> %f", initial, best.Fitness)
+	}
+}
+
+func TestMutateKeepsShapeListInSync(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(24, 24, 3), 2, 1, 1, 1)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	ind := ga.Population[0]
+	for i := 0; i < 50; i++ {
+		ind = ga.Mutate(rng, ind)
+		redrawn := ind.CreateCopy()
+		redrawn.rasterize()
+		if !bytes.Equal(redrawn.Image.Pix, ind.Image.Pix) {
+			t.Fatalf("Mutation %d: image no longer matches its shape list", i)
+		}
+	}
+}
+
+func TestChangeVertexCountStaysWithinBounds(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const minVertices, maxVertices = 4, 7
+	ind := newIndividual(rng, 32, 32, color.RGBA{255, 255, 255, 255},
+		ShapeConfig{MinShapes: 5, MaxShapes: 5, MinVertices: minVertices, MaxVertices: maxVertices}, nil)
+
+	added, removed := 0, 0
+	for i := 0; i < 1000; i++ {
+		idx := rng.Intn(len(ind.Shapes))
+		before := len(ind.Shapes[idx].Points)
+		ind.changeVertexCount(rng, idx, minVertices, maxVertices)
+		after := len(ind.Shapes[idx].Points)
+		if after > before {
+			added++
+		} else if after < before {
+			removed++
+		}
+		if after < minVertices || after > maxVertices {
+			t.Fatalf("Step %d: shape %d has %d vertices, expected %d-%d", i, idx, after, minVertices, maxVertices)
+		}
+	}
+	if added == 0 || removed == 0 {
+		t.Errorf("Expected both additions and removals, got %d added and %d removed", added, removed)
+	}
+
+	// Bounds below a triangle are raised to 3
+	tri := newSquareIndividual(16, image.Rect(2, 2, 10, 10))
+	tri.Shapes[0].Points = tri.Shapes[0].Points[:3]
+	for i := 0; i < 20; i++ {
+		tri.changeVertexCount(rng, 0, 1, 3)
+		if n := len(tri.Shapes[0].Points); n != 3 {
+			t.Fatalf("Triangle changed to %d vertices", n)
+		}
+	}
+}
+
+func TestSwapShapeOrder(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	red := color.RGBA{255, 0, 0, 255}
+	blue := color.RGBA{0, 0, 255, 255}
+
+	// Two overlapping opaque squares; the target has red on top
+	target := newSquareIndividual(24, image.Rect(2, 2, 14, 14))
+	target.Shapes = append(target.Shapes, copyShapes(target.Shapes)...)
+	target.Shapes[0].Color = blue
+	target.Shapes[1].Color = red
+	for i := range target.Shapes[1].Points {
+		target.Shapes[1].Points[i] = target.Shapes[1].Points[i].Add(image.Pt(6, 6))
+	}
+	target.rasterize()
+
+	ind := target.CreateCopy()
+	ind.Shapes[0], ind.Shapes[1] = ind.Shapes[1], ind.Shapes[0]
+	ind.rasterize()
+	ind.CalculateFitness(target.Image)
+	if ind.Fitness == 0 {
+		t.Fatal("Expected the wrong draw order to differ from the target")
+	}
+
+	swapped := ind.CreateCopy()
+	if !swapped.swapShapeOrder(rng) {
+		t.Fatal("Expected a swap with two shapes")
+	}
+	swapped.rasterize()
+	if bytes.Equal(swapped.Image.Pix, ind.Image.Pix) {
+		t.Error("Swapping overlapping shapes didn't change any pixels")
+	}
+	swapped.CalculateFitness(target.Image)
+	if swapped.Fitness >= ind.Fitness {
+		t.Errorf("Swapping into the target's order didn't lower fitness: %f -> %f", ind.Fitness, swapped.Fitness)
+	}
+
+	single := newSquareIndividual(8, image.Rect(1, 1, 4, 4))
+	if single.swapShapeOrder(rng) {
+		t.Error("Expected no swap with a single shape")
+	}
+}
+
+// boundingBoxArea returns the area of the bounding box of points.
+func boundingBoxArea(points []image.Point) int {
+	box := image.Rectangle{Min: points[0], Max: points[0]}
+	for _, p := range points[1:] {
+		box = box.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+	}
+	return (box.Dx() - 1) * (box.Dy() - 1)
+}
+
+func TestMaxShapeAreaCapsShapes(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	const size = 64
+	fraction := 0.05
+	maxArea := int(fraction * size * size)
+	shapes := ShapeConfig{MinShapes: 10, MaxShapes: 10, MinVertices: 3, MaxVertices: 8, MaxArea: fraction}
+
+	ga, err := NewGeneticAlgorithm(image.NewRGBA(image.Rect(0, 0, size, size)), 4, 1, 1, 1, WithInitShapes(shapes))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	// A high rate adds large polygons and edits existing ones
+	ga.MutationRate = 1
+
+	for _, ind := range ga.Population {
+		for i := 0; i < 200; i++ {
+			ind = ga.Mutate(rng, ind)
+		}
+		for j, shape := range ind.Shapes {
+			if area := boundingBoxArea(shape.Points); area > maxArea {
+				t.Fatalf("Shape %d has bounding-box area %d, expected at most %d", j, area, maxArea)
+			}
+		}
+	}
+}
+
+func TestCapShapeArea(t *testing.T) {
+	points := []image.Point{{0, 0}, {100, 0}, {100, 50}, {0, 50}}
+	capShapeArea(points, 1250)
+	if area := boundingBoxArea(points); area > 1250 || area < 1000 {
+		t.Errorf("Capped area %d, expected just under 1250", area)
+	}
+
+	untouched := []image.Point{{0, 0}, {10, 0}, {10, 10}}
+	capShapeArea(untouched, -1)
+	if untouched[1] != (image.Point{10, 0}) {
+		t.Errorf("A negative cap should leave the shape unchanged")
+	}
+}
+
+func TestResetMutationRateOnContinue(t *testing.T) {
+	const converged, fresh = 0.05, 0.4
+	target := createCheckerPattern(16, 16, 4)
+	newGA := func() *GeneticAlgorithm {
+		ga, err := NewGeneticAlgorithm(target, 8, 20, converged, 2, WithSeed(6))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		if _, err := ga.Run(nil, 1); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		ga.Generations += 2
+		return ga
+	}
+	recorded := func(state AdaptiveMutationState) int {
+		n := 0
+		for _, v := range state.History {
+			if v != 0 {
+				n++
+			}
+		}
+		return n
+	}
+
+	// By default a continued run carries on with the restored strategy
+	continued := newGA()
+	if _, err := continued.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if state := continued.adaptiveRate.State(); state.BaseRate != converged || recorded(state) != mutationHistorySize {
+		t.Errorf("Expected the restored strategy around %v with a full history, got base %v with %d entries", converged, state.BaseRate, recorded(state))
+	}
+
+	reset := newGA()
+	if err := reset.ResetMutationRate(fresh); err != nil {
+		t.Fatal(err)
+	}
+	recv := make(chan ImageResult, 2)
+	if _, err := reset.Run(recv, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if state := reset.adaptiveRate.State(); state.BaseRate != fresh || recorded(state) != 2 {
+		t.Errorf("Expected a fresh strategy around %v with 2 entries, got base %v with %d entries", fresh, state.BaseRate, recorded(state))
+	}
+	// A fresh strategy never goes below a fifth of its base rate
+	for result := range recv {
+		if result.MutationRate < minMutationRateScale*fresh {
+			t.Errorf("Generation %d: expected a rate from the new base %v, got %f", result.Generation, fresh, result.MutationRate)
+		}
+	}
+
+	if err := reset.ResetMutationRate(1.5); err == nil {
+		t.Error("Expected an error for a rate above 1")
+	}
+}
+
+func TestAdaptiveMutationStateResume(t *testing.T) {
+	// A slowly improving, plateauing population
+	population := func(gen int) []*Individual {
+		best := 50 + 40/float64(gen)
+		return []*Individual{{Fitness: best}, {Fitness: best + 5}, {Fitness: best + 12}}
+	}
+	const resumeAt, total = 15, 40
+
+	uninterrupted := NewAdaptiveMutationStrategy(0.05, rand.New(rand.NewSource(1)))
+	var want []float64
+	for gen := 1; gen <= total; gen++ {
+		rate := uninterrupted.Update(population(gen), gen, total)
+		if gen > resumeAt {
+			want = append(want, rate)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	original := NewAdaptiveMutationStrategy(0.05, rng)
+	for gen := 1; gen <= resumeAt; gen++ {
+		original.Update(population(gen), gen, total)
+	}
+
+	// Round trip the state through gob as a checkpoint would
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(original.State()); err != nil {
+		t.Fatalf("Encoding state failed: %v", err)
+	}
+	var state AdaptiveMutationState
+	if err := gob.NewDecoder(&buf).Decode(&state); err != nil {
+		t.Fatalf("Decoding state failed: %v", err)
+	}
+	// The jitter source continues where the original left off
+	resumed, err := RestoreAdaptiveMutationStrategy(state, rng)
+	if err != nil {
+		t.Fatalf("Restore failed: %v", err)
+	}
+
+	for i, gen := 0, resumeAt+1; gen <= total; i, gen = i+1, gen+1 {
+		if got := resumed.Update(population(gen), gen, total); got != want[i] {
+			t.Fatalf("Generation %d: resumed rate %f, uninterrupted %f", gen, got, want[i])
+		}
+	}
+
+	if _, err := RestoreAdaptiveMutationStrategy(AdaptiveMutationState{History: []float64{1}}, rng); err == nil {
+		t.Errorf("Expected an error for a truncated history")
+	}
+}
+
+func TestWarmupPinsMutationRate(t *testing.T) {
+	const warmup, warmupRate = 8, 0.3
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 2), 10, 20, 0.05, 3, WithSeed(5))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	ga.WarmupGenerations = warmup
+	ga.WarmupMutationRate = warmupRate
+
+	recv := make(chan ImageResult, ga.Generations)
+	if _, err := ga.Run(recv, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	for result := range recv {
+		if result.Generation <= warmup {
+			if result.MutationRate != warmupRate {
+				t.Errorf("Generation %d: mutation rate %f during warm-up, expected %f", result.Generation, result.MutationRate, warmupRate)
+			}
+		} else if result.MutationRate == warmupRate {
+			t.Errorf("Generation %d: mutation rate still pinned after the warm-up", result.Generation)
+		}
+	}
+	if ga.warmingUp {
+		t.Error("Expected the warm-up flag to be cleared after Run")
+	}
+}
+
+func TestFixedShapesKeepsShapeCount(t *testing.T) {
+	target := createCheckerPattern(24, 24, 6)
+	ga, err := NewGeneticAlgorithm(target, 10, 20, 0.5, 3, WithSeed(2), WithFixedShapes(5))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	for i, ind := range ga.Population {
+		if len(ind.Shapes) != 5 {
+			t.Fatalf("Initial individual %d has %d shapes, expected 5", i, len(ind.Shapes))
+		}
+	}
+
+	recv := make(chan ImageResult, ga.Generations)
+	if _, err := ga.Run(recv, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	for result := range recv {
+		if result.Shapes == nil || len(result.Shapes.Shapes) != 5 {
+			t.Fatalf("Generation %d: best individual doesn't have exactly 5 shapes", result.Generation)
+		}
+	}
+	for i, ind := range ga.Population {
+		if len(ind.Shapes) != 5 {
+			t.Errorf("Final individual %d has %d shapes, expected 5", i, len(ind.Shapes))
+		}
+	}
+	stats := ga.Stats()
+	if stats.Mutation["add"].Offspring != 0 {
+		t.Errorf("Expected no polygons to be added, got %d add mutations", stats.Mutation["add"].Offspring)
+	}
+	if stats.Mutation["recolor"].Offspring == 0 {
+		t.Error("Expected some shapes to be recolored")
+	}
+}
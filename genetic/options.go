@@ -0,0 +1,171 @@
+package genetic
+
+import "image"
+
+// Option configures a GeneticAlgorithm at construction time, before the
+// initial population is created and evaluated.
+type Option func(*GeneticAlgorithm)
+
+// WithSeed makes the run reproducible: the initial population and every generation
+// are derived from seed, independently of the number of CPUs and GOMAXPROCS. Without it
+// a random seed is chosen.
+func WithSeed(seed int64) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.seed = seed
+		ga.seeded = true
+	}
+}
+
+// WithBackgroundInit selects how the background of initial individuals is chosen.
+func WithBackgroundInit(mode BackgroundInit) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.backgroundInit = mode
+	}
+}
+
+// WithSeedImage starts every individual of the initial population from a copy of seed,
+// which must have the target's dimensions, with shapeCount random polygons on top.
+func WithSeedImage(seed image.Image, shapeCount int) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.seedImage = seed
+		ga.seedShapeCount = shapeCount
+	}
+}
+
+// WithSeedMix starts fractions of the initial population from a blurred target or the
+// target's average color instead of random polygons, for a more diverse start.
+func WithSeedMix(mix SeedMix) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.seedMix = mix
+	}
+}
+
+// WithPaletteImage restricts the colors of all polygons, initial and new, to the dominant
+// colors of palette, which can be any size. Fitness still compares against the target,
+// so the result takes its shapes from the target and its colors from palette.
+func WithPaletteImage(palette image.Image) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.paletteImage = palette
+	}
+}
+
+// WithInitColors selects how the colors of the polygons on initial individuals are chosen.
+func WithInitColors(mode InitColors) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.initColors = mode
+	}
+}
+
+// WithInitShapes bounds the number of polygons and vertices drawn on each initial individual.
+func WithInitShapes(shapes ShapeConfig) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.initShapes = shapes
+	}
+}
+
+// WithContrastWeight adds a penalty, scaled by weight, for mismatches in global
+// luminance contrast and saturation between a candidate and the target.
+func WithContrastWeight(weight float64) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.contrastWeight = weight
+	}
+}
+
+// WithFrozenRegion keeps the pixels of every individual inside rect, in the target's
+// coordinates, equal to the target's after each crossover and mutation, so that only
+// the rest of the canvas evolves.
+func WithFrozenRegion(rect image.Rectangle) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.frozen = rect
+	}
+}
+
+// WithHistogramWeight adds a penalty, scaled by weight, for differences between the
+// per-channel color histograms of a candidate and the target, which rewards the right
+// overall color balance even before the pixels line up.
+func WithHistogramWeight(weight float64) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.histogramWeight = weight
+	}
+}
+
+// WithAvoidImage adds a fitness penalty, scaled by weight, that grows as a candidate
+// comes to resemble avoid, which must have the target's dimensions.
+func WithAvoidImage(avoid image.Image, weight float64) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.avoidImage = avoid
+		ga.avoidWeight = weight
+	}
+}
+
+// WithDistance measures the difference between candidate and target pixels with
+// distance instead of DistanceL2. This changes the scale of fitness values.
+func WithDistance(distance Distance) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.distance = distance
+	}
+}
+
+// WithFitnessDeadband treats per-channel differences of at most deadband as zero when
+// calculating fitness, so imperceptible noise such as JPEG artifacts isn't chased.
+func WithFitnessDeadband(deadband int) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.fitnessDeadband = deadband
+	}
+}
+
+// WithFitnessSample measures fitness on a fraction of the pixels, evenly spaced and
+// shifted every generation, instead of on all of them. It is roughly 1/fraction times
+// faster but adds noise to selection; the individual returned by Run is re-scored
+// exactly. It can't be combined with WithPyramidLevels.
+func WithFitnessSample(fraction float64) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.fitnessSample = fraction
+	}
+}
+
+// WithFixedShapes gives every individual exactly count shapes for the whole run, like the
+// classic fixed-polygon-count approach. Mutation then only edits existing shapes (their
+// color, position, size, vertices and draw order) and crossover exchanges whole shapes
+// between parents. It overrides the shape count range of WithInitShapes and can't be
+// combined with WithSeedImage.
+func WithFixedShapes(count int) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.fixedShapes = count
+	}
+}
+
+// WithFitnessFunc sets GeneticAlgorithm.FitnessFunc before the initial population is
+// evaluated. Optional penalty terms, such as contrast and avoid, are still added to its
+// result. It can't be combined with WithFitnessSample, WithPyramidLevels or
+// WithFitnessDeadband.
+func WithFitnessFunc(fn func(candidate, target *image.RGBA) float64) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.FitnessFunc = fn
+	}
+}
+
+// WithPyramidLevels evaluates fitness over an image pyramid with the given number
+// of levels instead of at full resolution only. Values below 2 disable it.
+func WithPyramidLevels(levels int) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.pyramidLevels = levels
+	}
+}
+
+// WithPopulationSchedule resizes the population at the given generations of Run,
+// updating PopulationSize. The memory limit is checked against its largest size.
+func WithPopulationSchedule(schedule PopulationSchedule) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.popSchedule = schedule
+	}
+}
+
+// WithMemoryLimit makes NewGeneticAlgorithm fail with an error, instead of attempting
+// the allocation, when the population would need more than limit bytes. Without it
+// the limit is a share of the memory the system reports as available.
+func WithMemoryLimit(limit uint64) Option {
+	return func(ga *GeneticAlgorithm) {
+		ga.memoryLimit = limit
+	}
+}
@@ -0,0 +1,168 @@
+package genetic
+
+import (
+	"image"
+	"image/color"
+	"math/rand"
+	"sort"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+const (
+	// Number of dominant colors extracted for InitColorsKMeans
+	initPaletteSize = 16
+	// k-means runs on at most this many pixels, sampled evenly across the image
+	maxPaletteSamples   = 4096
+	maxKMeansIterations = 20
+)
+
+// dominantColors returns up to k colors that best summarize img, found by k-means
+// clustering of its pixels and ordered from the largest cluster to the smallest.
+// The result is deterministic, so seeded runs stay reproducible.
+func dominantColors(img *image.RGBA, k int) []color.RGBA {
+	samples := samplePixels(img, maxPaletteSamples)
+	if k <= 0 || len(samples) == 0 {
+		return nil
+	}
+	k = mathutil.Min(k, len(samples))
+
+	centroids := initCentroids(samples, k)
+	assignment := make([]int, len(samples))
+	for i := range assignment {
+		assignment[i] = -1
+	}
+	counts := make([]int, k)
+	for iter := 0; iter < maxKMeansIterations; iter++ {
+		changed := false
+		for i, s := range samples {
+			if nearest := nearestCentroid(centroids, s); nearest != assignment[i] {
+				assignment[i] = nearest
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		sums := make([][3]float64, k)
+		clear(counts)
+		for i, s := range samples {
+			c := assignment[i]
+			sums[c][0] += s[0]
+			sums[c][1] += s[1]
+			sums[c][2] += s[2]
+			counts[c]++
+		}
+		for c := range centroids {
+			// An empty cluster keeps its centroid
+			if counts[c] > 0 {
+				n := float64(counts[c])
+				centroids[c] = [3]float64{sums[c][0] / n, sums[c][1] / n, sums[c][2] / n}
+			}
+		}
+	}
+
+	order := make([]int, k)
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return counts[order[i]] > counts[order[j]] })
+
+	colors := make([]color.RGBA, 0, k)
+	for _, c := range order {
+		if counts[c] == 0 {
+			continue
+		}
+		centroid := centroids[c]
+		colors = append(colors, color.RGBA{
+			R: mathutil.ClampUint8(centroid[0] + 0.5),
+			G: mathutil.ClampUint8(centroid[1] + 0.5),
+			B: mathutil.ClampUint8(centroid[2] + 0.5),
+			A: 255,
+		})
+	}
+	return colors
+}
+
+// samplePixels returns the RGB values of at most maxSamples pixels spread evenly over img.
+func samplePixels(img *image.RGBA, maxSamples int) [][3]float64 {
+	bounds := img.Bounds()
+	total := bounds.Dx() * bounds.Dy()
+	if total == 0 {
+		return nil
+	}
+	step := mathutil.Max(total/maxSamples, 1)
+	samples := make([][3]float64, 0, total/step+1)
+	for idx := 0; idx < total; idx += step {
+		x, y := idx%bounds.Dx(), idx/bounds.Dx()
+		i := y*img.Stride + x*4
+		samples = append(samples, [3]float64{float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2])})
+	}
+	return samples
+}
+
+// initCentroids picks k starting centroids by the maximin rule: first the sample farthest
+// from the mean, then repeatedly the sample farthest from every centroid chosen so far.
+func initCentroids(samples [][3]float64, k int) [][3]float64 {
+	var mean [3]float64
+	for _, s := range samples {
+		mean[0] += s[0]
+		mean[1] += s[1]
+		mean[2] += s[2]
+	}
+	n := float64(len(samples))
+	mean = [3]float64{mean[0] / n, mean[1] / n, mean[2] / n}
+
+	// distances holds each sample's distance to the nearest centroid, or to the mean
+	// before the first one is chosen
+	distances := make([]float64, len(samples))
+	for i, s := range samples {
+		distances[i] = colorDistance(s, mean)
+	}
+	centroids := make([][3]float64, 0, k)
+	for len(centroids) < k {
+		farthest := 0
+		for i, d := range distances {
+			if d > distances[farthest] {
+				farthest = i
+			}
+		}
+		next := samples[farthest]
+		for i, s := range samples {
+			if d := colorDistance(s, next); len(centroids) == 0 || d < distances[i] {
+				distances[i] = d
+			}
+		}
+		centroids = append(centroids, next)
+	}
+	return centroids
+}
+
+// nearestCentroid returns the index of the centroid closest to s.
+func nearestCentroid(centroids [][3]float64, s [3]float64) int {
+	best, bestDist := 0, colorDistance(centroids[0], s)
+	for c := 1; c < len(centroids); c++ {
+		if d := colorDistance(centroids[c], s); d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	return best
+}
+
+// colorDistance returns the squared Euclidean distance between two RGB colors.
+func colorDistance(a, b [3]float64) float64 {
+	dr, dg, db := a[0]-b[0], a[1]-b[1], a[2]-b[2]
+	return dr*dr + dg*dg + db*db
+}
+
+// paletteColor returns a random color from palette with a random alpha, or a fully
+// random color when the palette is empty.
+func paletteColor(rng *rand.Rand, palette []color.RGBA) color.RGBA {
+	c := RandomRGBA(rng)
+	if len(palette) > 0 {
+		p := palette[rng.Intn(len(palette))]
+		c.R, c.G, c.B = p.R, p.G, p.B
+	}
+	return c
+}
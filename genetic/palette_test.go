@@ -0,0 +1,153 @@
+package genetic
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"math/rand"
+	"testing"
+)
+
+func TestDominantColorsTwoColorTarget(t *testing.T) {
+	a := color.RGBA{R: 200, G: 30, B: 40, A: 255}
+	b := color.RGBA{R: 20, G: 90, B: 220, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 40, 30))
+	draw.Draw(img, image.Rect(0, 0, 25, 30), &image.Uniform{a}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(25, 0, 40, 30), &image.Uniform{b}, image.Point{}, draw.Src)
+
+	colors := dominantColors(img, 2)
+	if len(colors) != 2 {
+		t.Fatalf("Expected 2 colors, got %v", colors)
+	}
+	// The larger cluster comes first
+	for i, want := range []color.RGBA{a, b} {
+		got := colors[i]
+		if d := colorDistance(rgb(got), rgb(want)); d > 3*2*2 {
+			t.Errorf("Color %d: got %v, expected close to %v", i, got, want)
+		}
+	}
+}
+
+func TestDominantColorsMoreClustersThanColors(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	draw.Draw(img, img.Bounds(), &image.Uniform{color.RGBA{G: 255, A: 255}}, image.Point{}, draw.Src)
+
+	colors := dominantColors(img, 5)
+	if len(colors) != 1 || colors[0] != (color.RGBA{G: 255, A: 255}) {
+		t.Errorf("Expected the single color of a solid image, got %v", colors)
+	}
+}
+
+func TestInitColorsKMeansUsesTargetPalette(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 6, 1, 0.1, 2, WithSeed(1), WithInitColors(InitColorsKMeans))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	palette := dominantColors(ga.TargetRGBA, initPaletteSize)
+	for _, ind := range ga.Population {
+		for _, shape := range ind.Shapes {
+			found := false
+			for _, c := range palette {
+				if c.R == shape.Color.R && c.G == shape.Color.G && c.B == shape.Color.B {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("Shape color %v is not in the target palette %v", shape.Color, palette)
+			}
+		}
+	}
+}
+
+func TestLockPaletteRestrictsNewShapes(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 10, 4, 0.2, 3, WithSeed(1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	ga.LockPaletteAt = 2
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if len(ga.lockedPalette) == 0 {
+		t.Fatal("Expected the palette to be locked during Run")
+	}
+	inPalette := make(map[[3]uint8]bool)
+	for _, c := range ga.lockedPalette {
+		inPalette[[3]uint8{c.R, c.G, c.B}] = true
+	}
+
+	// Every polygon added by mutation from now on must use a locked color
+	ga.MutationRate = 1
+	rng := rand.New(rand.NewSource(1))
+	added := 0
+	for i := 0; i < 200; i++ {
+		parent := NewIndividual(rng, 16, 16)
+		child := ga.Mutate(rng, parent)
+		for _, shape := range child.Shapes[len(parent.Shapes):] {
+			if c := shape.Color; !inPalette[[3]uint8{c.R, c.G, c.B}] {
+				t.Fatalf("New shape color %v is outside the locked palette %v", c, ga.lockedPalette)
+			}
+			added++
+		}
+	}
+	if added == 0 {
+		t.Fatal("Expected mutation to add some polygons")
+	}
+}
+
+func TestPaletteImageRestrictsShapeColors(t *testing.T) {
+	// The palette comes from a red and blue image of a different size from the
+	// black and white target
+	red, blue := color.RGBA{R: 210, G: 20, B: 30, A: 255}, color.RGBA{R: 10, G: 40, B: 200, A: 255}
+	style := image.NewRGBA(image.Rect(0, 0, 30, 10))
+	draw.Draw(style, image.Rect(0, 0, 15, 10), &image.Uniform{red}, image.Point{}, draw.Src)
+	draw.Draw(style, image.Rect(15, 0, 30, 10), &image.Uniform{blue}, image.Point{}, draw.Src)
+
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 10, 5, 0.5, 3, WithSeed(1), WithPaletteImage(style))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if !bytes.Equal(ga.TargetRGBA.Pix, target.Pix) {
+		t.Error("Expected fitness to still compare against the target")
+	}
+	checkColor := func(c color.RGBA) {
+		t.Helper()
+		if rgb := [3]uint8{c.R, c.G, c.B}; rgb != [3]uint8{red.R, red.G, red.B} && rgb != [3]uint8{blue.R, blue.G, blue.B} {
+			t.Fatalf("Shape color %v isn't one of the palette image's colors", c)
+		}
+	}
+
+	for _, ind := range ga.Population {
+		for _, shape := range ind.Shapes {
+			checkColor(shape.Color)
+		}
+	}
+	// Polygons added by mutation are restricted from the first generation on
+	ga.MutationRate = 1
+	rng := rand.New(rand.NewSource(1))
+	added := 0
+	for i := 0; i < 200; i++ {
+		parent := NewIndividual(rng, 16, 16)
+		child := ga.Mutate(rng, parent)
+		for _, shape := range child.Shapes[len(parent.Shapes):] {
+			checkColor(shape.Color)
+			added++
+		}
+	}
+	if added == 0 {
+		t.Fatal("Expected mutation to add some polygons")
+	}
+
+	ga.LockPaletteAt = 2
+	if _, err := ga.Run(nil, 1); err == nil {
+		t.Error("Expected an error combining a palette image with a palette lock")
+	}
+}
+
+func rgb(c color.RGBA) [3]float64 {
+	return [3]float64{float64(c.R), float64(c.G), float64(c.B)}
+}
@@ -0,0 +1,108 @@
+package genetic
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PopulationStep resizes the population to Size at the start of Generation.
+type PopulationStep struct {
+	Generation int
+	Size       int
+}
+
+// PopulationSchedule lists population resizes in increasing generation order, e.g. to
+// shrink a large exploratory population once the run has converged.
+type PopulationSchedule []PopulationStep
+
+// ParsePopulationSchedule parses a comma-separated list of generation:size pairs, such
+// as "2000:300,5000:100". An empty string is an empty schedule.
+func ParsePopulationSchedule(s string) (PopulationSchedule, error) {
+	var schedule PopulationSchedule
+	if strings.TrimSpace(s) == "" {
+		return schedule, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		gen, size, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return nil, fmt.Errorf("invalid population step %q, expected generation:size", pair)
+		}
+		var step PopulationStep
+		var err error
+		if step.Generation, err = strconv.Atoi(strings.TrimSpace(gen)); err != nil {
+			return nil, fmt.Errorf("invalid population step %q: %w", pair, err)
+		}
+		if step.Size, err = strconv.Atoi(strings.TrimSpace(size)); err != nil {
+			return nil, fmt.Errorf("invalid population step %q: %w", pair, err)
+		}
+		schedule = append(schedule, step)
+	}
+	if err := schedule.Validate(); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Validate checks that generations are positive and strictly increasing and that
+// every size is at least 1.
+func (ps PopulationSchedule) Validate() error {
+	for i, step := range ps {
+		if step.Generation < 1 {
+			return fmt.Errorf("population step generation must be at least 1, got %d", step.Generation)
+		}
+		if i > 0 && step.Generation <= ps[i-1].Generation {
+			return fmt.Errorf("population steps must be in increasing generation order, got %d after %d", step.Generation, ps[i-1].Generation)
+		}
+		if step.Size < 1 {
+			return fmt.Errorf("population step size must be at least 1, got %d", step.Size)
+		}
+	}
+	return nil
+}
+
+// MaxSize returns the largest size in the schedule, or 0 if it is empty.
+func (ps PopulationSchedule) MaxSize() int {
+	size := 0
+	for _, step := range ps {
+		size = max(size, step.Size)
+	}
+	return size
+}
+
+// sizeAt returns the size scheduled to take effect at gen, if any.
+func (ps PopulationSchedule) sizeAt(gen int) (int, bool) {
+	i := sort.Search(len(ps), func(i int) bool { return ps[i].Generation >= gen })
+	if i < len(ps) && ps[i].Generation == gen {
+		return ps[i].Size, true
+	}
+	return 0, false
+}
+
+// resizePopulation sets the population to size individuals, keeping it sorted. Shrinking
+// drops the least fit; growing adds copies of the fittest, cycling through them in order.
+func (ga *GeneticAlgorithm) resizePopulation(size int) {
+	population := ga.Population
+	if size <= len(population) {
+		population = population[:size]
+	} else {
+		// Each copy goes right after its original so the population stays sorted
+		copies := make([]int, len(population))
+		for i := 0; i < size-len(population); i++ {
+			copies[i%len(population)]++
+		}
+		grown := make([]*Individual, 0, size)
+		for i, ind := range population {
+			grown = append(grown, ind)
+			for j := 0; j < copies[i]; j++ {
+				grown = append(grown, ind.CreateCopy())
+			}
+		}
+		population = grown
+	}
+	ga.popMu.Lock()
+	ga.Population = population
+	ga.popMu.Unlock()
+	ga.PopulationSize = size
+}
@@ -0,0 +1,58 @@
+package genetic
+
+import "testing"
+
+// sizeRecorder is a MutationStrategy that records the population size of every generation.
+type sizeRecorder struct {
+	sizes []int
+}
+
+func (sr *sizeRecorder) Update(pop []*Individual, gen, maxGen int) float64 {
+	sr.sizes = append(sr.sizes, len(pop))
+	return 0.2
+}
+
+func TestPopulationSchedule(t *testing.T) {
+	// Shrink to an odd size, down to a single individual, then grow again
+	schedule, err := ParsePopulationSchedule("3:7,5:1,6:13")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ga, err := NewGeneticAlgorithm(createCheckerPattern(16, 16, 4), 10, 8, 0.2, 3, WithSeed(1), WithPopulationSchedule(schedule))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	recorder := &sizeRecorder{}
+	ga.MutationStrategy = recorder
+
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	want := []int{10, 10, 7, 7, 1, 13, 13, 13}
+	if len(recorder.sizes) != len(want) {
+		t.Fatalf("Expected %d generations, got %d", len(want), len(recorder.sizes))
+	}
+	for gen, size := range recorder.sizes {
+		if size != want[gen] {
+			t.Errorf("Generation %d: population size %d, expected %d", gen+1, size, want[gen])
+		}
+	}
+	if ga.PopulationSize != 13 || len(ga.Population) != 13 {
+		t.Errorf("Expected a final population of 13, got PopulationSize %d and %d individuals", ga.PopulationSize, len(ga.Population))
+	}
+	if err := ga.checkPopulation(ga.Population); err != nil {
+		t.Errorf("Population violates invariants after Run: %v", err)
+	}
+}
+
+func TestParsePopulationScheduleErrors(t *testing.T) {
+	for _, s := range []string{"100", "a:10", "10:b", "0:10", "10:0", "20:10,10:5", "10:5,10:6"} {
+		if _, err := ParsePopulationSchedule(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+	if schedule, err := ParsePopulationSchedule(""); err != nil || len(schedule) != 0 {
+		t.Errorf("Expected an empty schedule, got %v, %v", schedule, err)
+	}
+}
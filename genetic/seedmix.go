@@ -0,0 +1,125 @@
+package genetic
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+const (
+	// blurSeedShapes is the number of random polygons drawn over the blurred target,
+	// like the default -seed-shapes-count
+	blurSeedShapes = 3
+	// blurSeedLevels is the pyramid level the blurred target is scaled back up from,
+	// 8 times smaller than the target
+	blurSeedLevels = 4
+)
+
+// SeedMix is the fraction of the initial population started in each way. Whatever the
+// fractions leave over starts from random polygons, like Random.
+type SeedMix struct {
+	// Blur starts from a blurred copy of the target with a few random polygons over it.
+	Blur float64
+	// Mean draws random polygons over the target's average color.
+	Mean float64
+	// Random draws random polygons over the usual background.
+	Random float64
+}
+
+// seedSource is how one initial individual is started.
+type seedSource int
+
+const (
+	seedRandom seedSource = iota
+	seedBlur
+	seedMean
+)
+
+// ParseSeedMix parses a comma-separated list of source:fraction pairs, such as
+// "blur:0.3,mean:0.2,random:0.5". An empty string is an empty mix, which starts the
+// whole population from random polygons.
+func ParseSeedMix(s string) (SeedMix, error) {
+	var mix SeedMix
+	if strings.TrimSpace(s) == "" {
+		return mix, nil
+	}
+	for _, pair := range strings.Split(s, ",") {
+		name, value, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			return mix, fmt.Errorf("invalid seed mix entry %q, expected source:fraction", pair)
+		}
+		fraction, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+		if err != nil {
+			return mix, fmt.Errorf("invalid seed mix entry %q: %w", pair, err)
+		}
+		switch strings.TrimSpace(name) {
+		case "blur":
+			mix.Blur = fraction
+		case "mean":
+			mix.Mean = fraction
+		case "random":
+			mix.Random = fraction
+		default:
+			return mix, fmt.Errorf("unknown seed source %q, expected blur, mean or random", name)
+		}
+	}
+	if err := mix.Validate(); err != nil {
+		return mix, err
+	}
+	return mix, nil
+}
+
+// Validate checks that every fraction is between 0 and 1 and that they sum to at most 1.
+func (m SeedMix) Validate() error {
+	for _, f := range []float64{m.Blur, m.Mean, m.Random} {
+		if f < 0 || f > 1 {
+			return fmt.Errorf("seed mix fractions must be between 0 and 1, got %v", m)
+		}
+	}
+	// Allow for decimal fractions such as 0.3+0.2+0.5 not adding up exactly
+	if sum := m.Blur + m.Mean + m.Random; sum > 1+1e-9 {
+		return fmt.Errorf("seed mix fractions must sum to at most 1, got %.3f", sum)
+	}
+	return nil
+}
+
+// sources returns how to start each of size individuals: the blurred target first, then
+// the mean color, then random polygons for the rest.
+func (m SeedMix) sources(size int) []seedSource {
+	blur := int(math.Round(m.Blur * float64(size)))
+	mean := int(math.Round((m.Blur+m.Mean)*float64(size))) - blur
+	sources := make([]seedSource, size)
+	for i := range sources {
+		switch {
+		case i < blur:
+			sources[i] = seedBlur
+		case i < blur+mean:
+			sources[i] = seedMean
+		}
+	}
+	return sources
+}
+
+// blurredImage returns img blurred by halving it a few times, which averages each
+// block of pixels, and scaling it back up.
+func blurredImage(img *image.RGBA) *image.RGBA {
+	pyramid := buildPyramid(img, blurSeedLevels)
+	bounds := img.Bounds()
+	return imageio.ToRGBA(imageio.ResizeTo(pyramid[len(pyramid)-1], bounds.Dx(), bounds.Dy()))
+}
+
+// meanColor returns the opaque average color of img.
+func meanColor(img *image.RGBA) color.RGBA {
+	mean := imageio.ImageStats(img).Mean
+	return color.RGBA{
+		R: uint8(math.Round(mean[0])),
+		G: uint8(math.Round(mean[1])),
+		B: uint8(math.Round(mean[2])),
+		A: 255,
+	}
+}
@@ -0,0 +1,50 @@
+package genetic
+
+import "testing"
+
+func TestParseSeedMix(t *testing.T) {
+	mix, err := ParseSeedMix("blur:0.3, mean:0.2,random:0.5")
+	if err != nil {
+		t.Fatalf("ParseSeedMix failed: %v", err)
+	}
+	if mix != (SeedMix{Blur: 0.3, Mean: 0.2, Random: 0.5}) {
+		t.Errorf("Unexpected mix %+v", mix)
+	}
+	if mix, err := ParseSeedMix(""); err != nil || mix != (SeedMix{}) {
+		t.Errorf("Expected an empty mix, got %+v, %v", mix, err)
+	}
+	for _, s := range []string{"blur:0.6,mean:0.5", "blur=0.3", "edge:0.1", "mean:-0.1", "blur:x"} {
+		if _, err := ParseSeedMix(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
+
+func TestSeedMixPopulationComposition(t *testing.T) {
+	target := createCheckerPattern(32, 32, 4)
+	mix := SeedMix{Blur: 0.3, Mean: 0.2}
+	ga, err := NewGeneticAlgorithm(target, 20, 1, 0.1, 2, WithSeed(3), WithSeedMix(mix))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	// Blurred seeds have no shape list; mean seeds have the target's average background
+	var blur, mean, random int
+	for _, ind := range ga.Population {
+		switch {
+		case ind.Shapes == nil:
+			blur++
+		case ind.Background == ga.targetMean:
+			mean++
+		default:
+			random++
+		}
+	}
+	if blur != 6 || mean != 4 || random != 10 {
+		t.Errorf("Expected 6 blurred, 4 mean and 10 random individuals, got %d, %d and %d", blur, mean, random)
+	}
+
+	if _, err := NewGeneticAlgorithm(target, 20, 1, 0.1, 2, WithSeedMix(mix), WithFixedShapes(5)); err == nil {
+		t.Error("Expected an error combining blurred seeds with a fixed shape budget")
+	}
+}
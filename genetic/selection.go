@@ -8,23 +8,54 @@ const (
 	numTournaments = 4 // Number of mini-tournaments to run in TournamentSelect
 )
 
-func TournamentSelect(population []*Individual, tournamentSize int) *Individual {
-	var best *Individual
+// defaultEliteSelectCount is how many of the fittest individuals elite selection picks from
+const defaultEliteSelectCount = 5
+
+func TournamentSelect(rng *rand.Rand, population []*Individual, tournamentSize int) *Individual {
+	return population[tournamentSelect(rng, population, tournamentSize, nil)]
+}
+
+// tournamentSelect returns the index of the individual TournamentSelect would pick,
+// comparing fitness[i] for population[i] instead when fitness is set, e.g. to select
+// on shared fitness.
+func tournamentSelect(rng *rand.Rand, population []*Individual, tournamentSize int, fitness []float64) int {
+	score := func(i int) float64 {
+		if fitness != nil {
+			return fitness[i]
+		}
+		return population[i].Fitness
+	}
+	best := -1
 
 	for i := 0; i < numTournaments; i++ {
-		tournamentBest := population[rand.Intn(len(population))]
+		tournamentBest := rng.Intn(len(population))
 
 		for j := 1; j < tournamentSize; j++ {
-			participant := population[rand.Intn(len(population))]
-			if participant.Fitness < tournamentBest.Fitness {
+			participant := rng.Intn(len(population))
+			if score(participant) < score(tournamentBest) {
 				tournamentBest = participant
 			}
 		}
 
-		if best == nil || tournamentBest.Fitness < best.Fitness {
+		if best < 0 || score(tournamentBest) < score(best) {
 			best = tournamentBest
 		}
 	}
 
 	return best
 }
+
+// selectParent picks a parent from population, which must be sorted by fitness. With
+// probability EliteSelectProbability it is one of the EliteSelectCount fittest individuals,
+// otherwise it is chosen by TournamentSelect.
+func (ga *GeneticAlgorithm) selectParent(rng *rand.Rand, population []*Individual) *Individual {
+	// Only draw when enabled so runs without elite selection keep their random sequence
+	if ga.EliteSelectProbability > 0 && rng.Float64() < ga.EliteSelectProbability {
+		return population[rng.Intn(min(ga.EliteSelectCount, len(population)))]
+	}
+	var fitness []float64
+	if len(ga.sharedFitness) == len(population) {
+		fitness = ga.sharedFitness
+	}
+	return population[tournamentSelect(rng, population, ga.TournamentSize, fitness)]
+}
@@ -0,0 +1,28 @@
+package genetic
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestEliteSelectAlwaysPicksTopK(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	ga, err := NewGeneticAlgorithm(target, 30, 1, 0.05, 3, WithSeed(1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	ga.EliteSelectProbability = 1
+	ga.EliteSelectCount = 3
+
+	elites := make(map[*Individual]bool)
+	for _, ind := range ga.Population[:ga.EliteSelectCount] {
+		elites[ind] = true
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 1000; i++ {
+		if parent := ga.selectParent(rng, ga.Population); !elites[parent] {
+			t.Fatalf("Selection %d returned an individual with fitness %f outside the top %d", i, parent.Fitness, ga.EliteSelectCount)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package genetic
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"sort"
+)
+
+// ShapeList is the serializable form of an individual drawn from shapes: the
+// background fill followed by the polygons in draw order.
+type ShapeList struct {
+	Width      int
+	Height     int
+	Background color.RGBA
+	Shapes     []Polygon
+	FillRule   FillRule
+}
+
+// ShapeList returns the individual's shape list and whether it has one. Individuals
+// produced by pixel-level operators can't be described by shapes.
+func (ind *Individual) ShapeList() (*ShapeList, bool) {
+	if !ind.hasShapes() {
+		return nil, false
+	}
+	bounds := ind.Image.Bounds()
+	return &ShapeList{
+		Width:      bounds.Dx(),
+		Height:     bounds.Dy(),
+		Background: ind.Background,
+		Shapes:     copyShapes(ind.Shapes),
+		FillRule:   ind.FillRule,
+	}, true
+}
+
+// Rasterize draws the shape list onto a new image.
+func (sl *ShapeList) Rasterize() *image.RGBA {
+	ind := &Individual{
+		Image:      image.NewRGBA(image.Rect(0, 0, sl.Width, sl.Height)),
+		Background: sl.Background,
+		Shapes:     sl.Shapes,
+		FillRule:   sl.FillRule,
+	}
+	ind.rasterize()
+	return ind.Image
+}
+
+// shapeListJSON and shapeJSON are the JSON layout of a ShapeList. Colors are [r, g, b]
+// with the alpha kept separate, and order is the polygon's position in the draw order.
+// The fill rule is left out when it is the default nonzero.
+type shapeListJSON struct {
+	Width      int         `json:"width"`
+	Height     int         `json:"height"`
+	Background [4]uint8    `json:"background"`
+	FillRule   string      `json:"fill_rule,omitempty"`
+	Shapes     []shapeJSON `json:"shapes"`
+}
+
+type shapeJSON struct {
+	Order  int      `json:"order"`
+	Points [][2]int `json:"points"`
+	Color  [3]uint8 `json:"color"`
+	Alpha  uint8    `json:"alpha"`
+}
+
+// MarshalJSON implements json.Marshaler.
+func (sl *ShapeList) MarshalJSON() ([]byte, error) {
+	bg := sl.Background
+	out := shapeListJSON{
+		Width:      sl.Width,
+		Height:     sl.Height,
+		Background: [4]uint8{bg.R, bg.G, bg.B, bg.A},
+		Shapes:     make([]shapeJSON, len(sl.Shapes)),
+	}
+	if sl.FillRule != FillNonZero {
+		out.FillRule = sl.FillRule.String()
+	}
+	for i, polygon := range sl.Shapes {
+		points := make([][2]int, len(polygon.Points))
+		for j, p := range polygon.Points {
+			points[j] = [2]int{p.X, p.Y}
+		}
+		c := polygon.Color
+		out.Shapes[i] = shapeJSON{Order: i, Points: points, Color: [3]uint8{c.R, c.G, c.B}, Alpha: c.A}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Shapes are put back in draw order.
+func (sl *ShapeList) UnmarshalJSON(data []byte) error {
+	var in shapeListJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+	if in.Width <= 0 || in.Height <= 0 {
+		return fmt.Errorf("shape list dimensions must be positive, got %dx%d", in.Width, in.Height)
+	}
+	fillRule := FillNonZero
+	if in.FillRule != "" {
+		var err error
+		if fillRule, err = ParseFillRule(in.FillRule); err != nil {
+			return err
+		}
+	}
+	sort.SliceStable(in.Shapes, func(i, j int) bool { return in.Shapes[i].Order < in.Shapes[j].Order })
+
+	bg := in.Background
+	*sl = ShapeList{
+		Width:      in.Width,
+		Height:     in.Height,
+		Background: color.RGBA{R: bg[0], G: bg[1], B: bg[2], A: bg[3]},
+		Shapes:     make([]Polygon, len(in.Shapes)),
+		FillRule:   fillRule,
+	}
+	for i, shape := range in.Shapes {
+		points := make([]image.Point, len(shape.Points))
+		for j, p := range shape.Points {
+			points[j] = image.Pt(p[0], p[1])
+		}
+		sl.Shapes[i] = Polygon{
+			Points: points,
+			Color:  color.RGBA{R: shape.Color[0], G: shape.Color[1], B: shape.Color[2], A: shape.Alpha},
+		}
+	}
+	return nil
+}
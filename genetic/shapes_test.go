@@ -0,0 +1,126 @@
+package genetic
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math/rand"
+	"testing"
+)
+
+func TestShapeListJSONRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	ind := NewIndividual(rng, 40, 30)
+
+	shapes, ok := ind.ShapeList()
+	if !ok {
+		t.Fatal("Expected a new individual to have a shape list")
+	}
+	data, err := json.Marshal(shapes)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	var decoded ShapeList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if len(decoded.Shapes) != len(ind.Shapes) {
+		t.Fatalf("Expected %d shapes, got %d", len(ind.Shapes), len(decoded.Shapes))
+	}
+
+	img := decoded.Rasterize()
+	if img.Bounds() != ind.Image.Bounds() {
+		t.Fatalf("Expected bounds %v, got %v", ind.Image.Bounds(), img.Bounds())
+	}
+	for i := range img.Pix {
+		diff := int(img.Pix[i]) - int(ind.Image.Pix[i])
+		if diff < -1 || diff > 1 {
+			t.Fatalf("Pixel byte %d differs: got %d, expected %d", i, img.Pix[i], ind.Image.Pix[i])
+		}
+	}
+}
+
+func TestShapeListUnmarshalRestoresDrawOrder(t *testing.T) {
+	data := `{"width":4,"height":4,"background":[0,0,0,255],"shapes":[
+		{"order":1,"points":[[0,0],[3,0],[3,3]],"color":[0,255,0],"alpha":255},
+		{"order":0,"points":[[0,0],[3,0],[0,3]],"color":[255,0,0],"alpha":128}]}`
+
+	var sl ShapeList
+	if err := json.Unmarshal([]byte(data), &sl); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if sl.Shapes[0].Color.R != 255 || sl.Shapes[0].Color.A != 128 {
+		t.Errorf("Expected the order 0 shape first, got %+v", sl.Shapes[0])
+	}
+	if sl.Shapes[1].Color.G != 255 {
+		t.Errorf("Expected the order 1 shape second, got %+v", sl.Shapes[1])
+	}
+}
+
+func TestShapeListUnmarshalRejectsEmptyImage(t *testing.T) {
+	var sl ShapeList
+	if err := json.Unmarshal([]byte(`{"width":0,"height":4}`), &sl); err == nil {
+		t.Error("Expected an error for zero width")
+	}
+}
+
+// pentagram is a five-pointed star drawn as one self-intersecting outline, whose
+// central pentagon is wound around twice.
+func pentagram() *ShapeList {
+	return &ShapeList{
+		Width:      40,
+		Height:     40,
+		Background: color.RGBA{A: 255},
+		Shapes: []Polygon{{
+			Points: []image.Point{{20, 2}, {31, 36}, {2, 14}, {38, 14}, {9, 36}},
+			Color:  color.RGBA{R: 255, G: 255, B: 255, A: 255},
+		}},
+	}
+}
+
+func TestFillRuleChangesSelfIntersectingPolygons(t *testing.T) {
+	star := pentagram()
+	nonZero := star.Rasterize()
+	star.FillRule = FillEvenOdd
+	evenOdd := star.Rasterize()
+
+	center := nonZero.PixOffset(20, 21)
+	if nonZero.Pix[center] != 255 {
+		t.Errorf("Expected nonzero to fill the star's center, got %d", nonZero.Pix[center])
+	}
+	if evenOdd.Pix[center] != 0 {
+		t.Errorf("Expected evenodd to leave the star's center hollow, got %d", evenOdd.Pix[center])
+	}
+	tip := nonZero.PixOffset(20, 6)
+	if nonZero.Pix[tip] != 255 || evenOdd.Pix[tip] != 255 {
+		t.Errorf("Expected both rules to fill the star's points, got %d and %d", nonZero.Pix[tip], evenOdd.Pix[tip])
+	}
+}
+
+func TestShapeListJSONKeepsFillRule(t *testing.T) {
+	star := pentagram()
+	data, err := json.Marshal(star)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("fill_rule")) {
+		t.Errorf("Expected the default fill rule to be left out, got %s", data)
+	}
+
+	star.FillRule = FillEvenOdd
+	if data, err = json.Marshal(star); err != nil {
+		t.Fatal(err)
+	}
+	var decoded ShapeList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if decoded.FillRule != FillEvenOdd {
+		t.Errorf("Expected the evenodd fill rule to survive a round trip, got %v", decoded.FillRule)
+	}
+	if err := json.Unmarshal([]byte(`{"width":4,"height":4,"fill_rule":"winding"}`), &decoded); err == nil {
+		t.Error("Expected an error for an unknown fill rule")
+	}
+}
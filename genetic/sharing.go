@@ -0,0 +1,95 @@
+package genetic
+
+import (
+	"image"
+	"math"
+)
+
+// signatureSize is the side of the thumbnail that stands in for an image when
+// individuals are compared for fitness sharing.
+const signatureSize = 8
+
+// signature holds the mean red, green and blue of each cell of a signatureSize grid
+// over an image, a cheap stand-in for the image when comparing individuals.
+type signature [signatureSize * signatureSize * 3]float64
+
+// imageSignature returns the signature of img, whose bounds must start at the origin.
+func imageSignature(img *image.RGBA) signature {
+	var sig signature
+	var counts [signatureSize * signatureSize]int
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	for y := 0; y < height; y++ {
+		row := img.Pix[y*img.Stride:]
+		cellRow := y * signatureSize / height * signatureSize
+		for x := 0; x < width; x++ {
+			cell := cellRow + x*signatureSize/width
+			for c := 0; c < 3; c++ {
+				sig[cell*3+c] += float64(row[x*4+c])
+			}
+			counts[cell]++
+		}
+	}
+	for cell, n := range counts {
+		for c := 0; c < 3 && n > 0; c++ {
+			sig[cell*3+c] /= float64(n)
+		}
+	}
+	return sig
+}
+
+// distance returns the root mean squared difference between two signatures, on the
+// same 0-255 scale as the channels.
+func (s *signature) distance(other *signature) float64 {
+	var sum float64
+	for i := range s {
+		d := s[i] - other[i]
+		sum += d * d
+	}
+	return math.Sqrt(sum / float64(len(s)))
+}
+
+// fitnessSharing holds the signatures of a generation's population, the niches that
+// fitness sharing measures crowding against.
+type fitnessSharing struct {
+	radius     float64
+	signatures []signature
+}
+
+// newFitnessSharing takes the signatures of population for sharing within radius.
+func newFitnessSharing(population []*Individual, radius float64) *fitnessSharing {
+	fs := &fitnessSharing{radius: radius, signatures: make([]signature, len(population))}
+	for i, ind := range population {
+		fs.signatures[i] = imageSignature(ind.Image)
+	}
+	return fs
+}
+
+// sharedFitness returns the fitness of ind multiplied by its niche count: the
+// individuals of the population within radius of it, each weighted from 1 when
+// identical down to 0 at radius. An individual of the population counts itself.
+// Crowded individuals therefore look less fit than they are.
+func (fs *fitnessSharing) sharedFitness(ind *Individual) float64 {
+	sig := imageSignature(ind.Image)
+	return ind.Fitness * fs.niche(&sig)
+}
+
+func (fs *fitnessSharing) niche(sig *signature) float64 {
+	var niche float64
+	for i := range fs.signatures {
+		if d := sig.distance(&fs.signatures[i]); d < fs.radius {
+			niche += 1 - d/fs.radius
+		}
+	}
+	return niche
+}
+
+// populationFitness returns the shared fitness of every individual of the population
+// the signatures were taken from, by index.
+func (fs *fitnessSharing) populationFitness(population []*Individual) []float64 {
+	shared := make([]float64, len(population))
+	for i, ind := range population {
+		shared[i] = ind.Fitness * fs.niche(&fs.signatures[i])
+	}
+	return shared
+}
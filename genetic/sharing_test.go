@@ -0,0 +1,80 @@
+package genetic
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// meanPairwiseDistance returns the mean signature distance over every pair of individuals.
+func meanPairwiseDistance(population []*Individual) float64 {
+	var total float64
+	var pairs int
+	for i := range population {
+		a := imageSignature(population[i].Image)
+		for j := i + 1; j < len(population); j++ {
+			b := imageSignature(population[j].Image)
+			total += a.distance(&b)
+			pairs++
+		}
+	}
+	return total / float64(pairs)
+}
+
+func TestImageSignature(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 20, 12))
+	for y := 0; y < 12; y++ {
+		for x := 10; x < 20; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: 200, A: 255})
+		}
+	}
+	sig := imageSignature(img)
+	if sig[0] != 0 || sig[(signatureSize-1)*3] != 200 {
+		t.Errorf("Expected a black left and red right edge, got %v and %v", sig[0], sig[(signatureSize-1)*3])
+	}
+
+	other := imageSignature(image.NewRGBA(image.Rect(0, 0, 20, 12)))
+	if d := sig.distance(&sig); d != 0 {
+		t.Errorf("Expected no distance to itself, got %f", d)
+	}
+	// Half of the red channel differs by 200: sqrt(32*200^2 / 192)
+	if d := sig.distance(&other); d < 81 || d > 82 {
+		t.Errorf("Expected a distance of about 81.6, got %f", d)
+	}
+}
+
+func TestSharedFitnessPenalizesCrowds(t *testing.T) {
+	black := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	white := image.NewRGBA(image.Rect(0, 0, 8, 8))
+	for i := range white.Pix {
+		white.Pix[i] = 255
+	}
+	population := []*Individual{
+		{Fitness: 10, Image: black}, {Fitness: 10, Image: black}, {Fitness: 10, Image: black},
+		{Fitness: 10, Image: white},
+	}
+	shared := newFitnessSharing(population, 20).populationFitness(population)
+	if shared[0] != 30 || shared[3] != 10 {
+		t.Errorf("Expected the crowd of three to share fitness 30 and the loner to keep 10, got %v", shared)
+	}
+}
+
+func TestFitnessSharingKeepsPopulationDiverse(t *testing.T) {
+	target := createCheckerPattern(32, 32, 4)
+	finalDiversity := func(radius float64) float64 {
+		ga, err := NewGeneticAlgorithm(target, 20, 60, 0.3, 4, WithSeed(9))
+		if err != nil {
+			t.Fatalf("Failed to create GA: %v", err)
+		}
+		ga.FitnessSharingRadius = radius
+		if _, err := ga.Run(nil, 1); err != nil {
+			t.Fatalf("Run failed: %v", err)
+		}
+		return meanPairwiseDistance(ga.Population)
+	}
+
+	without, with := finalDiversity(0), finalDiversity(30)
+	if with <= without {
+		t.Errorf("Expected sharing to keep the population more diverse, got %f with and %f without", with, without)
+	}
+}
@@ -0,0 +1,88 @@
+package genetic
+
+import "sync"
+
+// OperatorStats counts the offspring produced by one crossover operator or mutation kind.
+type OperatorStats struct {
+	// Offspring is the number of children the operator produced.
+	Offspring int
+	// Selected is how many of them survived into the next generation.
+	Selected int
+	// TotalImprovement sums, over the children, the fitter parent's fitness minus the
+	// child's, so positive values mean the children beat their parents.
+	TotalImprovement float64
+}
+
+// SelectionRate returns the share of the operator's offspring that survived.
+func (s OperatorStats) SelectionRate() float64 {
+	if s.Offspring == 0 {
+		return 0
+	}
+	return float64(s.Selected) / float64(s.Offspring)
+}
+
+// AverageImprovement returns the mean fitness improvement of the operator's offspring
+// over their fitter parent.
+func (s OperatorStats) AverageImprovement() float64 {
+	if s.Offspring == 0 {
+		return 0
+	}
+	return s.TotalImprovement / float64(s.Offspring)
+}
+
+// Stats is per-operator telemetry accumulated over every generation evolved by Run.
+// Crossover is keyed by the operator names used in ParseCrossoverWeights; Mutation by
+// "add", "translate", "scale", "vertices", "reorder", "recolor", and "none" for children
+// that weren't mutated.
+type Stats struct {
+	Crossover map[string]OperatorStats
+	Mutation  map[string]OperatorStats
+}
+
+// operatorStats accumulates Stats, guarded by mu so Stats can be called during Run.
+type operatorStats struct {
+	mu        sync.Mutex
+	crossover [numCrossoverOperators]OperatorStats
+	mutation  [numMutationKinds]OperatorStats
+}
+
+// offspringRecord describes how a pair of children was made and whether they survived.
+type offspringRecord struct {
+	crossover    crossoverOperator
+	mutations    [2]mutationKind
+	improvements [2]float64
+	selected     [2]bool
+}
+
+func (s *operatorStats) record(r offspringRecord) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range r.mutations {
+		for _, op := range []*OperatorStats{&s.crossover[r.crossover], &s.mutation[r.mutations[i]]} {
+			op.Offspring++
+			op.TotalImprovement += r.improvements[i]
+			if r.selected[i] {
+				op.Selected++
+			}
+		}
+	}
+}
+
+// Stats returns a snapshot of the per-operator telemetry. It is safe to call from
+// another goroutine while Run is executing.
+func (ga *GeneticAlgorithm) Stats() Stats {
+	ga.stats.mu.Lock()
+	defer ga.stats.mu.Unlock()
+
+	stats := Stats{
+		Crossover: make(map[string]OperatorStats, numCrossoverOperators),
+		Mutation:  make(map[string]OperatorStats, numMutationKinds),
+	}
+	for op, s := range ga.stats.crossover {
+		stats.Crossover[crossoverOperator(op).String()] = s
+	}
+	for kind, s := range ga.stats.mutation {
+		stats.Mutation[mutationKind(kind).String()] = s
+	}
+	return stats
+}
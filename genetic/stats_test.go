@@ -0,0 +1,38 @@
+package genetic
+
+import "testing"
+
+func TestStatsCountOffspring(t *testing.T) {
+	target := createCheckerPattern(16, 16, 4)
+	popSize, generations := 10, 5
+	ga, err := NewGeneticAlgorithm(target, popSize, generations, 0.5, 3, WithSeed(1))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if _, err := ga.Run(nil, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	stats := ga.Stats()
+	// Every generation replaces the population with children or surviving parents
+	want := popSize * generations
+	for group, ops := range map[string]map[string]OperatorStats{"crossover": stats.Crossover, "mutation": stats.Mutation} {
+		offspring, selected := 0, 0
+		for name, op := range ops {
+			if op.Selected > op.Offspring {
+				t.Errorf("%s %s: %d selected out of %d offspring", group, name, op.Selected, op.Offspring)
+			}
+			offspring += op.Offspring
+			selected += op.Selected
+		}
+		if offspring != want {
+			t.Errorf("%s: counted %d offspring, expected %d", group, offspring, want)
+		}
+		if selected == 0 || selected > want {
+			t.Errorf("%s: %d offspring selected, expected between 1 and %d", group, selected, want)
+		}
+	}
+	if stats.Mutation["none"].Offspring == 0 || stats.Mutation["none"].Offspring == want {
+		t.Errorf("With a mutation rate of 0.5 some but not all children should be mutated, got %d unmutated", stats.Mutation["none"].Offspring)
+	}
+}
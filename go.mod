@@ -2,9 +2,10 @@ module github.com/bishal0602/chaotic-canvas
 
 go 1.24.0
 
-require github.com/fogleman/gg v1.3.0
-
 require (
-	github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
-	golang.org/x/image v0.25.0 // indirect
+	github.com/fogleman/gg v1.3.0
+	github.com/gorilla/websocket v1.5.3
+	golang.org/x/image v0.25.0
 )
+
+require github.com/golang/freetype v0.0.0-20170609003504-e2365dfdc4a0 // indirect
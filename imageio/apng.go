@@ -0,0 +1,215 @@
+package imageio
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/draw"
+	"io"
+	"os"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+const (
+	pngSignature = "\x89PNG\r\n\x1a\n"
+
+	// Every frame is stored as 8-bit non-premultiplied RGBA, so frames never disagree
+	// with the header the way the standard encoder's per-image colour type choice could
+	pngColorTypeRGBA = 6
+	pngBytesPerPixel = 4
+
+	apngDisposeNone = 0
+	apngBlendSource = 0
+)
+
+// WriteAPNG writes frames to path as an animated PNG that loops forever, showing each
+// frame for delayMs milliseconds. Unlike GIF it keeps full colour. All frames must
+// have the size of the first, which viewers without APNG support show on its own.
+func WriteAPNG(path string, frames []image.Image, delayMs int) error {
+	if len(frames) == 0 {
+		return errors.New("an animated PNG needs at least one frame")
+	}
+	if delayMs < 0 || delayMs > 0xffff {
+		return fmt.Errorf("frame delay must be between 0 and %d ms, got %d", 0xffff, delayMs)
+	}
+	size := frames[0].Bounds().Size()
+	if size.X < 1 || size.Y < 1 {
+		return fmt.Errorf("frames must not be empty, got %dx%d", size.X, size.Y)
+	}
+	for i, frame := range frames {
+		if frame.Bounds().Size() != size {
+			return fmt.Errorf("frame %d is %v, expected %v like the first frame", i, frame.Bounds().Size(), size)
+		}
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	w := bufio.NewWriter(file)
+	if err := encodeAPNG(w, frames, size, delayMs); err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+func encodeAPNG(w io.Writer, frames []image.Image, size image.Point, delayMs int) error {
+	if _, err := io.WriteString(w, pngSignature); err != nil {
+		return err
+	}
+
+	header := make([]byte, 13)
+	binary.BigEndian.PutUint32(header[0:], uint32(size.X))
+	binary.BigEndian.PutUint32(header[4:], uint32(size.Y))
+	header[8] = 8 // bit depth
+	header[9] = pngColorTypeRGBA
+	if err := writePNGChunk(w, "IHDR", header); err != nil {
+		return err
+	}
+
+	control := make([]byte, 8)
+	binary.BigEndian.PutUint32(control[0:], uint32(len(frames)))
+	binary.BigEndian.PutUint32(control[4:], 0) // loop forever
+	if err := writePNGChunk(w, "acTL", control); err != nil {
+		return err
+	}
+
+	// fcTL and fdAT chunks share one sequence number counter
+	var sequence uint32
+	for i, frame := range frames {
+		fc := make([]byte, 26)
+		binary.BigEndian.PutUint32(fc[0:], sequence)
+		binary.BigEndian.PutUint32(fc[4:], uint32(size.X))
+		binary.BigEndian.PutUint32(fc[8:], uint32(size.Y))
+		// x and y offsets stay 0: every frame covers the whole canvas
+		binary.BigEndian.PutUint16(fc[20:], uint16(delayMs))
+		binary.BigEndian.PutUint16(fc[22:], 1000)
+		fc[24] = apngDisposeNone
+		fc[25] = apngBlendSource
+		if err := writePNGChunk(w, "fcTL", fc); err != nil {
+			return err
+		}
+		sequence++
+
+		data, err := compressFrame(frame)
+		if err != nil {
+			return err
+		}
+		// The first frame doubles as the still image, so it is stored as ordinary IDAT
+		if i == 0 {
+			err = writePNGChunk(w, "IDAT", data)
+		} else {
+			fd := make([]byte, 4, 4+len(data))
+			binary.BigEndian.PutUint32(fd, sequence)
+			err = writePNGChunk(w, "fdAT", append(fd, data...))
+			sequence++
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return writePNGChunk(w, "IEND", nil)
+}
+
+// writePNGChunk writes a chunk with its length and CRC.
+func writePNGChunk(w io.Writer, kind string, data []byte) error {
+	var header [8]byte
+	binary.BigEndian.PutUint32(header[:4], uint32(len(data)))
+	copy(header[4:], kind)
+	crc := crc32.NewIEEE()
+	crc.Write(header[4:])
+	crc.Write(data)
+	var trailer [4]byte
+	binary.BigEndian.PutUint32(trailer[:], crc.Sum32())
+
+	for _, b := range [][]byte{header[:], data, trailer[:]} {
+		if _, err := w.Write(b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// compressFrame returns the zlib-compressed, filtered scanlines of img as RGBA.
+func compressFrame(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	rowLen := nrgba.Rect.Dx() * pngBytesPerPixel
+	prev := make([]byte, rowLen)
+	// One candidate row per filter type, each prefixed with its type byte
+	var candidates [5][]byte
+	for f := range candidates {
+		candidates[f] = make([]byte, 1+rowLen)
+		candidates[f][0] = byte(f)
+	}
+	for y := 0; y < nrgba.Rect.Dy(); y++ {
+		row := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+rowLen]
+		if _, err := zw.Write(filterRow(candidates, row, prev)); err != nil {
+			return nil, err
+		}
+		prev = row
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// filterRow applies every PNG filter to row and returns the candidate with the
+// smallest sum of absolute differences, the usual heuristic for what compresses best.
+func filterRow(candidates [5][]byte, row, prev []byte) []byte {
+	best, bestSum := 0, -1
+	for f := range candidates {
+		out := candidates[f][1:]
+		sum := 0
+		for i, x := range row {
+			var a, c byte
+			if i >= pngBytesPerPixel {
+				a, c = row[i-pngBytesPerPixel], prev[i-pngBytesPerPixel]
+			}
+			b := prev[i]
+			switch f {
+			case 0:
+				out[i] = x
+			case 1:
+				out[i] = x - a
+			case 2:
+				out[i] = x - b
+			case 3:
+				out[i] = x - byte((int(a)+int(b))/2)
+			case 4:
+				out[i] = x - paeth(a, b, c)
+			}
+			sum += mathutil.Abs(int(int8(out[i])))
+		}
+		if bestSum < 0 || sum < bestSum {
+			best, bestSum = f, sum
+		}
+	}
+	return candidates[best]
+}
+
+// paeth returns whichever of the left, above and upper-left bytes is closest to
+// a + b - c.
+func paeth(a, b, c byte) byte {
+	p := int(a) + int(b) - int(c)
+	pa, pb, pc := mathutil.Abs(p-int(a)), mathutil.Abs(p-int(b)), mathutil.Abs(p-int(c))
+	if pa <= pb && pa <= pc {
+		return a
+	}
+	if pb <= pc {
+		return b
+	}
+	return c
+}
@@ -0,0 +1,150 @@
+package imageio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type pngChunk struct {
+	kind string
+	data []byte
+}
+
+// readPNGChunks splits a PNG file into chunks, checking the signature and every CRC.
+func readPNGChunks(t *testing.T, data []byte) []pngChunk {
+	t.Helper()
+	if !bytes.HasPrefix(data, []byte(pngSignature)) {
+		t.Fatal("Missing PNG signature")
+	}
+	var chunks []pngChunk
+	for rest := data[len(pngSignature):]; len(rest) > 0; {
+		n := binary.BigEndian.Uint32(rest)
+		kind, body := string(rest[4:8]), rest[8:8+n]
+		if crc := binary.BigEndian.Uint32(rest[8+n:]); crc != crc32.ChecksumIEEE(rest[4:8+n]) {
+			t.Fatalf("Bad CRC on %s chunk", kind)
+		}
+		chunks = append(chunks, pngChunk{kind, body})
+		rest = rest[12+n:]
+	}
+	return chunks
+}
+
+// decodeAPNGFrames rebuilds every frame of an APNG as a standalone PNG and decodes it.
+func decodeAPNGFrames(t *testing.T, chunks []pngChunk) []image.Image {
+	t.Helper()
+	var header []byte
+	var frames []image.Image
+	decode := func(data []byte) {
+		var buf bytes.Buffer
+		buf.WriteString(pngSignature)
+		for _, c := range []pngChunk{{"IHDR", header}, {"IDAT", data}, {"IEND", nil}} {
+			if err := writePNGChunk(&buf, c.kind, c.data); err != nil {
+				t.Fatal(err)
+			}
+		}
+		img, err := png.Decode(&buf)
+		if err != nil {
+			t.Fatalf("Frame %d doesn't decode: %v", len(frames), err)
+		}
+		frames = append(frames, img)
+	}
+	for _, c := range chunks {
+		switch c.kind {
+		case "IHDR":
+			header = c.data
+		case "IDAT":
+			decode(c.data)
+		case "fdAT":
+			decode(c.data[4:])
+		}
+	}
+	return frames
+}
+
+func TestWriteAPNG(t *testing.T) {
+	colors := []color.NRGBA{{255, 0, 0, 255}, {0, 200, 90, 255}, {20, 40, 250, 128}}
+	var frames []image.Image
+	for i, c := range colors {
+		img := image.NewNRGBA(image.Rect(0, 0, 9, 7))
+		for y := 0; y < 7; y++ {
+			for x := 0; x < 9; x++ {
+				img.SetNRGBA(x, y, c)
+			}
+		}
+		// A gradient row exercises the scanline filters
+		for x := 0; x < 9; x++ {
+			img.SetNRGBA(x, 3, color.NRGBA{uint8(x * 28), uint8(i * 60), 7, 255})
+		}
+		frames = append(frames, img)
+	}
+
+	path := filepath.Join(t.TempDir(), "evolution.png")
+	if err := WriteAPNG(path, frames, 100); err != nil {
+		t.Fatalf("WriteAPNG failed: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	chunks := readPNGChunks(t, data)
+	controls := 0
+	for _, c := range chunks {
+		switch c.kind {
+		case "acTL":
+			if n := binary.BigEndian.Uint32(c.data); n != uint32(len(frames)) {
+				t.Errorf("Expected acTL to announce %d frames, got %d", len(frames), n)
+			}
+		case "fcTL":
+			if delay := binary.BigEndian.Uint16(c.data[20:]); delay != 100 {
+				t.Errorf("Expected a delay of 100/1000s, got %d", delay)
+			}
+			controls++
+		}
+	}
+	if controls != len(frames) {
+		t.Errorf("Expected %d frame controls, got %d", len(frames), controls)
+	}
+
+	decoded := decodeAPNGFrames(t, chunks)
+	if len(decoded) != len(frames) {
+		t.Fatalf("Expected %d frames, decoded %d", len(frames), len(decoded))
+	}
+	for i := range frames {
+		for y := 0; y < 7; y++ {
+			for x := 0; x < 9; x++ {
+				want := frames[i].(*image.NRGBA).NRGBAAt(x, y)
+				if got := color.NRGBAModel.Convert(decoded[i].At(x, y)); got != want {
+					t.Fatalf("Frame %d pixel (%d,%d) is %v, expected %v", i, x, y, got, want)
+				}
+			}
+		}
+	}
+
+	// Viewers without APNG support see the first frame
+	still, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("APNG doesn't decode as a still PNG: %v", err)
+	}
+	if got := color.NRGBAModel.Convert(still.At(0, 0)); got != colors[0] {
+		t.Errorf("Expected the still image to be the first frame, got %v", got)
+	}
+}
+
+func TestWriteAPNGRejectsMismatchedFrames(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "evolution.png")
+	frames := []image.Image{image.NewRGBA(image.Rect(0, 0, 4, 4)), image.NewRGBA(image.Rect(0, 0, 5, 4))}
+	if err := WriteAPNG(path, frames, 100); err == nil {
+		t.Error("Expected an error for frames of different sizes")
+	}
+	if err := WriteAPNG(path, nil, 100); err == nil {
+		t.Error("Expected an error without frames")
+	}
+}
@@ -0,0 +1,71 @@
+package imageio
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+const separatorWidth = 4
+
+var separatorColor = color.RGBA{R: 40, G: 40, B: 40, A: 255}
+
+// SideBySide lays images out horizontally, top-aligned, with a thin separator between them.
+func SideBySide(imgs ...image.Image) (image.Image, error) {
+	if len(imgs) == 0 {
+		return nil, errors.New("no images to combine")
+	}
+
+	width, height := separatorWidth*(len(imgs)-1), 0
+	for i, img := range imgs {
+		if img == nil {
+			return nil, fmt.Errorf("image %d is nil", i)
+		}
+		width += img.Bounds().Dx()
+		height = mathutil.Max(height, img.Bounds().Dy())
+	}
+
+	combined := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(combined, combined.Bounds(), &image.Uniform{separatorColor}, image.Point{}, draw.Src)
+
+	x := 0
+	for _, img := range imgs {
+		bounds := img.Bounds()
+		draw.Draw(combined, image.Rect(x, 0, x+bounds.Dx(), bounds.Dy()), img, bounds.Min, draw.Src)
+		x += bounds.Dx() + separatorWidth
+	}
+
+	return combined, nil
+}
+
+// DiffHeatmap renders the per-pixel difference between two equally sized images,
+// from black (identical) through red to yellow (maximally different).
+func DiffHeatmap(a, b image.Image) (*image.RGBA, error) {
+	ba, bb := a.Bounds(), b.Bounds()
+	if ba.Dx() != bb.Dx() || ba.Dy() != bb.Dy() {
+		return nil, fmt.Errorf("image dimensions differ: %dx%d vs %dx%d", ba.Dx(), ba.Dy(), bb.Dx(), bb.Dy())
+	}
+
+	heatmap := image.NewRGBA(image.Rect(0, 0, ba.Dx(), ba.Dy()))
+	maxDistance := math.Sqrt(4 * 255 * 255)
+	for y := 0; y < ba.Dy(); y++ {
+		for x := 0; x < ba.Dx(); x++ {
+			r1, g1, b1, a1 := colorToFloat(a.At(ba.Min.X+x, ba.Min.Y+y))
+			r2, g2, b2, a2 := colorToFloat(b.At(bb.Min.X+x, bb.Min.Y+y))
+			distance := math.Sqrt((r1-r2)*(r1-r2) + (g1-g2)*(g1-g2) + (b1-b2)*(b1-b2) + (a1-a2)*(a1-a2))
+			heat := distance / maxDistance
+
+			heatmap.SetRGBA(x, y, color.RGBA{
+				R: mathutil.ClampUint8(heat * 2 * 255),
+				G: mathutil.ClampUint8((heat*2 - 1) * 255),
+				A: 255,
+			})
+		}
+	}
+	return heatmap, nil
+}
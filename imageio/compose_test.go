@@ -0,0 +1,52 @@
+package imageio
+
+import (
+	"image/color"
+	"testing"
+)
+
+func TestSideBySide_Dimensions(t *testing.T) {
+	a := createTestImage(30, 20, color.RGBA{R: 255, A: 255})
+	b := createTestImage(40, 50, color.RGBA{G: 255, A: 255})
+	c := createTestImage(10, 10, color.RGBA{B: 255, A: 255})
+
+	combined, err := SideBySide(a, b, c)
+	if err != nil {
+		t.Fatalf("SideBySide failed: %v", err)
+	}
+
+	expectedWidth := 30 + 40 + 10 + 2*separatorWidth
+	if combined.Bounds().Dx() != expectedWidth || combined.Bounds().Dy() != 50 {
+		t.Errorf("Expected %dx%d, got %dx%d", expectedWidth, 50, combined.Bounds().Dx(), combined.Bounds().Dy())
+	}
+
+	// Images are top-aligned at their offsets
+	if r, _, _, _ := combined.At(0, 0).RGBA(); r != 0xffff {
+		t.Errorf("Expected first image at the top-left corner")
+	}
+	if _, _, bl, _ := combined.At(30+40+2*separatorWidth, 0).RGBA(); bl != 0xffff {
+		t.Errorf("Expected third image top-aligned after two separators")
+	}
+}
+
+func TestSideBySide_NoImages(t *testing.T) {
+	if _, err := SideBySide(); err == nil {
+		t.Errorf("Expected an error when no images are given")
+	}
+}
+
+func TestDiffHeatmap(t *testing.T) {
+	a := createTestImage(10, 10, color.RGBA{R: 100, G: 100, B: 100, A: 255})
+
+	same, err := DiffHeatmap(a, a)
+	if err != nil {
+		t.Fatalf("DiffHeatmap failed: %v", err)
+	}
+	if c := same.RGBAAt(5, 5); c.R != 0 || c.G != 0 {
+		t.Errorf("Expected black for identical pixels, got %v", c)
+	}
+
+	if _, err := DiffHeatmap(a, createTestImage(5, 5, color.Black)); err == nil {
+		t.Errorf("Expected an error for mismatched dimensions")
+	}
+}
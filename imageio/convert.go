@@ -0,0 +1,23 @@
+package imageio
+
+import (
+	"image"
+	"image/draw"
+)
+
+// ToRGBA converts img to an RGBA image whose bounds start at the origin and whose rows are
+// contiguous (Stride == 4*Dx). An RGBA image that already satisfies both is returned as is.
+//
+// draw.Draw picks a specialized loop for the common decoder outputs (*image.YCbCr from
+// JPEG, *image.Paletted from GIF, *image.Gray, *image.NRGBA and others), which matters
+// for large photos.
+func ToRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	if rgba, ok := img.(*image.RGBA); ok && bounds.Min == (image.Point{}) && rgba.Stride == 4*bounds.Dx() {
+		return rgba
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+	return rgba
+}
@@ -0,0 +1,201 @@
+package imageio
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// decodedJPEG returns a noisy width x height image after a round trip through JPEG,
+// which decodes to *image.YCbCr.
+func decodedJPEG(tb testing.TB, width, height int) image.Image {
+	rng := rand.New(rand.NewSource(1))
+	src := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			src.SetRGBA(x, y, color.RGBA{uint8(x + rng.Intn(32)), uint8(y + rng.Intn(32)), uint8(x + y), 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, src, nil); err != nil {
+		tb.Fatal(err)
+	}
+	img, err := jpeg.Decode(&buf)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return img
+}
+
+// genericToRGBA converts img through the per-pixel At interface.
+func genericToRGBA(img image.Image) *image.RGBA {
+	bounds := img.Bounds()
+	rgba := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			rgba.Set(x, y, img.At(bounds.Min.X+x, bounds.Min.Y+y))
+		}
+	}
+	return rgba
+}
+
+// genericResizeBilinear is the bilinear resize reading the source through At.
+func genericResizeBilinear(src image.Image, newWidth, newHeight int) *image.RGBA {
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	srcBounds := src.Bounds()
+	xScale := float64(srcBounds.Dx()) / float64(newWidth)
+	yScale := float64(srcBounds.Dy()) / float64(newHeight)
+	for y := 0; y < newHeight; y++ {
+		srcY := float64(y)*yScale + 0.5*yScale - 0.5
+		y0 := int(math.Floor(srcY))
+		v := srcY - float64(y0)
+		y1 := mathutil.Min(srcBounds.Dy()-1, y0+1)
+		y0 = mathutil.Max(0, y0)
+		for x := 0; x < newWidth; x++ {
+			srcX := float64(x)*xScale + 0.5*xScale - 0.5
+			x0 := int(math.Floor(srcX))
+			u := srcX - float64(x0)
+			x1 := mathutil.Min(srcBounds.Dx()-1, x0+1)
+			x0 = mathutil.Max(0, x0)
+
+			r00, g00, b00, a00 := colorToFloat(src.At(srcBounds.Min.X+x0, srcBounds.Min.Y+y0))
+			r01, g01, b01, a01 := colorToFloat(src.At(srcBounds.Min.X+x1, srcBounds.Min.Y+y0))
+			r10, g10, b10, a10 := colorToFloat(src.At(srcBounds.Min.X+x0, srcBounds.Min.Y+y1))
+			r11, g11, b11, a11 := colorToFloat(src.At(srcBounds.Min.X+x1, srcBounds.Min.Y+y1))
+			dst.Set(x, y, color.NRGBA{
+				R: uint8(mathutil.Clamp(bilinear(r00, r01, r10, r11, u, v), 0, 255)),
+				G: uint8(mathutil.Clamp(bilinear(g00, g01, g10, g11, u, v), 0, 255)),
+				B: uint8(mathutil.Clamp(bilinear(b00, b01, b10, b11, u, v), 0, 255)),
+				A: uint8(mathutil.Clamp(bilinear(a00, a01, a10, a11, u, v), 0, 255)),
+			})
+		}
+	}
+	return dst
+}
+
+// maxChannelDiff returns the largest per-channel difference between two same-sized images.
+func maxChannelDiff(a, b *image.RGBA) int {
+	diff := 0
+	for i := range a.Pix {
+		diff = mathutil.Max(diff, mathutil.Abs(int(a.Pix[i])-int(b.Pix[i])))
+	}
+	return diff
+}
+
+func decoderOutputs(tb testing.TB) map[string]image.Image {
+	rng := rand.New(rand.NewSource(2))
+	rect := image.Rect(3, 5, 40, 30)
+	gray := image.NewGray(rect)
+	cmyk := image.NewCMYK(rect)
+	nrgba := image.NewNRGBA(rect)
+	rng.Read(gray.Pix)
+	rng.Read(cmyk.Pix)
+	rng.Read(nrgba.Pix)
+	// A GIF-like palette with a transparent entry
+	paletted := image.NewPaletted(rect, color.Palette{color.RGBA{}, color.RGBA{200, 30, 90, 255}, color.RGBA{10, 160, 240, 255}})
+	for i := range paletted.Pix {
+		paletted.Pix[i] = uint8(rng.Intn(len(paletted.Palette)))
+	}
+	return map[string]image.Image{
+		"paletted":    paletted,
+		"ycbcr":       decodedJPEG(tb, 37, 25),
+		"gray":        gray,
+		"cmyk":        cmyk,
+		"nrgba":       nrgba,
+		"rgba":        premultiplied(nrgba, rect),
+		"rgba origin": premultiplied(nrgba, rect.Sub(rect.Min)),
+	}
+}
+
+// premultiplied returns img as an RGBA image with the given bounds, which must be the
+// same size as img's.
+func premultiplied(img image.Image, bounds image.Rectangle) *image.RGBA {
+	rgba := image.NewRGBA(bounds)
+	draw.Draw(rgba, bounds, img, img.Bounds().Min, draw.Src)
+	return rgba
+}
+
+// photoRGBA returns a width x height RGBA image with photo-like smooth variation.
+func photoRGBA(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			i := img.PixOffset(x, y)
+			img.Pix[i] = uint8(x * 255 / width)
+			img.Pix[i+1] = uint8(y * 255 / height)
+			img.Pix[i+2] = uint8((x + y) % 256)
+			img.Pix[i+3] = 255
+		}
+	}
+	return img
+}
+
+func TestToRGBAMatchesGenericPath(t *testing.T) {
+	for name, img := range decoderOutputs(t) {
+		got := ToRGBA(img)
+		if got.Bounds() != image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()) {
+			t.Errorf("%s: unexpected bounds %v", name, got.Bounds())
+			continue
+		}
+		if diff := maxChannelDiff(got, genericToRGBA(img)); diff > 1 {
+			t.Errorf("%s: fast conversion differs from the generic path by %d", name, diff)
+		}
+	}
+}
+
+func TestResizeMatchesGenericPath(t *testing.T) {
+	for name, img := range decoderOutputs(t) {
+		got, ok := ResizeTo(img, 17, 11).(*image.RGBA)
+		if !ok {
+			t.Fatalf("%s: expected an RGBA result", name)
+		}
+		// The 8-bit conversion can round one level differently, which interpolation can turn into two
+		if diff := maxChannelDiff(got, genericResizeBilinear(img, 17, 11)); diff > 2 {
+			t.Errorf("%s: resize differs from the generic path by %d", name, diff)
+		}
+	}
+}
+
+func BenchmarkResizeJPEG(b *testing.B) {
+	img := decodedJPEG(b, 2400, 1600)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Resize(img, 540)
+	}
+}
+
+func BenchmarkResizeJPEGGeneric(b *testing.B) {
+	img := decodedJPEG(b, 2400, 1600)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		genericResizeBilinear(img, 540, 360)
+	}
+}
+
+// BenchmarkResizeRGBA resizes an in-memory RGBA image, which needs no conversion at all.
+func BenchmarkResizeRGBA(b *testing.B) {
+	img := photoRGBA(4000, 3000)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		Resize(img, 540)
+	}
+}
+
+func BenchmarkResizeRGBAGeneric(b *testing.B) {
+	img := photoRGBA(4000, 3000)
+	b.ResetTimer()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		genericResizeBilinear(img, 540, 405)
+	}
+}
@@ -0,0 +1,51 @@
+package imageio
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"strconv"
+	"strings"
+)
+
+// ParseRect parses a rectangle given as "x,y,w,h", with the top-left corner at (x, y),
+// such as a crop region.
+func ParseRect(s string) (image.Rectangle, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 4 {
+		return image.Rectangle{}, fmt.Errorf("invalid rectangle %q, expected x,y,w,h", s)
+	}
+	var values [4]int
+	for i, part := range parts {
+		v, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil {
+			return image.Rectangle{}, fmt.Errorf("invalid rectangle %q: %w", s, err)
+		}
+		values[i] = v
+	}
+	x, y, w, h := values[0], values[1], values[2], values[3]
+	if x < 0 || y < 0 || w <= 0 || h <= 0 {
+		return image.Rectangle{}, fmt.Errorf("invalid rectangle %q: the corner can't be negative and the size must be positive", s)
+	}
+	return image.Rect(x, y, x+w, y+h), nil
+}
+
+// Crop returns the region rect of img, with rect measured from img's top-left corner
+// rather than its bounds' origin. The region must lie within img. The result shares
+// pixels with img when img supports SubImage, and keeps its non-zero bounds either way.
+func Crop(img image.Image, rect image.Rectangle) (image.Image, error) {
+	bounds := img.Bounds()
+	region := rect.Add(bounds.Min)
+	if rect.Empty() || !region.In(bounds) {
+		return nil, fmt.Errorf("crop %dx%d at (%d,%d) doesn't fit in the %dx%d image",
+			rect.Dx(), rect.Dy(), rect.Min.X, rect.Min.Y, bounds.Dx(), bounds.Dy())
+	}
+	if sub, ok := img.(interface {
+		SubImage(image.Rectangle) image.Image
+	}); ok {
+		return sub.SubImage(region), nil
+	}
+	cropped := image.NewRGBA(region)
+	draw.Draw(cropped, region, img, region.Min, draw.Src)
+	return cropped, nil
+}
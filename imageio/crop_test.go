@@ -0,0 +1,46 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCrop(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 10, 8))
+	img.SetRGBA(4, 3, color.RGBA{R: 255, A: 255})
+
+	rect, err := ParseRect("3, 2, 5, 4")
+	if err != nil {
+		t.Fatalf("ParseRect: %v", err)
+	}
+	cropped, err := Crop(img, rect)
+	if err != nil {
+		t.Fatalf("Crop: %v", err)
+	}
+	if size := cropped.Bounds().Size(); size != image.Pt(5, 4) {
+		t.Fatalf("Expected a 5x4 crop, got %v", size)
+	}
+	// The marked pixel is at (1, 1) of the crop
+	rgba := ToRGBA(cropped)
+	if got := rgba.RGBAAt(1, 1); got.R != 255 {
+		t.Errorf("Expected the marked pixel at (1,1) of the crop, got %v", got)
+	}
+
+	// Crops of an image with a non-zero origin are relative to its corner
+	offset := img.SubImage(image.Rect(2, 2, 10, 8))
+	if _, err := Crop(offset, image.Rect(0, 0, 8, 6)); err != nil {
+		t.Errorf("Expected a crop covering the whole offset image to fit: %v", err)
+	}
+	if _, err := Crop(offset, image.Rect(1, 0, 9, 6)); err == nil {
+		t.Error("Expected an error for a crop past the offset image's edge")
+	}
+}
+
+func TestParseRectErrors(t *testing.T) {
+	for _, s := range []string{"", "1,2,3", "1,2,3,4,5", "a,0,1,1", "-1,0,4,4", "0,0,0,4", "0,0,4,-2"} {
+		if _, err := ParseRect(s); err == nil {
+			t.Errorf("Expected an error for %q", s)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package imageio
+
+import (
+	"image"
+	"math"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+const (
+	// ditherFlatThreshold is how far a pixel may differ from its 3x3 neighbourhood
+	// mean and still be treated as part of a smooth gradient.
+	ditherFlatThreshold = 1.0
+	// ditherMaxDelta bounds how much dithering may move any channel value.
+	ditherMaxDelta = 2
+)
+
+// Dither masks 8-bit banding in smooth gradients using Floyd–Steinberg error diffusion.
+// In flat regions each channel is replaced by its 3x3 neighbourhood mean, which carries
+// the sub-8-bit precision lost to banding, and the result is diffused back to 8 bits.
+// Edges are left alone and no channel moves by more than ditherMaxDelta. Alpha is preserved.
+func Dither(img *image.RGBA) *image.RGBA {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	dst := image.NewRGBA(bounds)
+	copy(dst.Pix, img.Pix)
+
+	// Error buffers for the current and next row, per colour channel
+	curErr := make([]float64, width*3)
+	nextErr := make([]float64, width*3)
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			idx := y*img.Stride + x*4
+			for c := 0; c < 3; c++ {
+				orig := float64(img.Pix[idx+c])
+				want := orig
+				if mean := neighbourhoodMean(img, x, y, c); math.Abs(mean-orig) < ditherFlatThreshold {
+					want = mean
+				}
+
+				value := want + curErr[x*3+c]
+				quantized := math.Round(value)
+				quantized = mathutil.Clamp(quantized, orig-ditherMaxDelta, orig+ditherMaxDelta)
+				quantized = mathutil.Clamp(quantized, 0, 255)
+				dst.Pix[idx+c] = uint8(quantized)
+
+				// Distribute the quantization error to unprocessed neighbours
+				e := value - quantized
+				if x+1 < width {
+					curErr[(x+1)*3+c] += e * 7 / 16
+					nextErr[(x+1)*3+c] += e * 1 / 16
+				}
+				if x > 0 {
+					nextErr[(x-1)*3+c] += e * 3 / 16
+				}
+				nextErr[x*3+c] += e * 5 / 16
+			}
+		}
+		curErr, nextErr = nextErr, curErr
+		clear(nextErr)
+	}
+
+	return dst
+}
+
+// neighbourhoodMean returns the mean of channel c over the 3x3 neighbourhood of (x, y),
+// clipped to the image.
+func neighbourhoodMean(img *image.RGBA, x, y, c int) float64 {
+	bounds := img.Bounds()
+	var sum float64
+	var n int
+	for ny := mathutil.Max(y-1, 0); ny <= mathutil.Min(y+1, bounds.Dy()-1); ny++ {
+		for nx := mathutil.Max(x-1, 0); nx <= mathutil.Min(x+1, bounds.Dx()-1); nx++ {
+			sum += float64(img.Pix[ny*img.Stride+nx*4+c])
+			n++
+		}
+	}
+	return sum / float64(n)
+}
@@ -0,0 +1,52 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDither_SmallChangesOnGradient(t *testing.T) {
+	// A slow gradient that bands heavily at 8 bits
+	width, height := 200, 20
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(100 + x/20)
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+
+	dithered := Dither(img)
+	if dithered.Bounds() != img.Bounds() {
+		t.Fatalf("Expected bounds %v, got %v", img.Bounds(), dithered.Bounds())
+	}
+
+	changed := 0
+	for i := range img.Pix {
+		diff := int(dithered.Pix[i]) - int(img.Pix[i])
+		if diff < -2 || diff > 2 {
+			t.Fatalf("Byte %d changed by %d, expected at most ±2", i, diff)
+		}
+		if diff != 0 {
+			changed++
+		}
+		if i%4 == 3 && diff != 0 {
+			t.Fatalf("Alpha changed at byte %d", i)
+		}
+	}
+	if changed == 0 {
+		t.Errorf("Expected dithering to break up the bands")
+	}
+}
+
+func TestDither_SolidImageUnchanged(t *testing.T) {
+	img := createTestImage(30, 30, color.RGBA{R: 40, G: 80, B: 120, A: 255}).(*image.RGBA)
+
+	dithered := Dither(img)
+	for i := range img.Pix {
+		if dithered.Pix[i] != img.Pix[i] {
+			t.Fatalf("Solid image changed at byte %d: %d -> %d", i, img.Pix[i], dithered.Pix[i])
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package imageio
+
+import (
+	"math"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// linearLevels is the resolution of the linear-to-sRGB table. Linear light needs more
+// than 8 bits to keep the darkest sRGB levels apart.
+const linearLevels = 4096
+
+var (
+	// srgbToLinear maps an 8-bit sRGB value to linear light on a 0-255 scale.
+	srgbToLinear [256]float64
+	// linearToSRGBTable maps linear light quantized to linearLevels steps to 8-bit sRGB.
+	linearToSRGBTable [linearLevels]uint8
+)
+
+func init() {
+	for i := range srgbToLinear {
+		srgbToLinear[i] = 255 * decodeSRGB(float64(i)/255)
+	}
+	for i := range linearToSRGBTable {
+		linearToSRGBTable[i] = mathutil.ClampUint8(math.Round(255 * encodeSRGB(float64(i)/(linearLevels-1))))
+	}
+}
+
+// SRGBToLinear returns the linear light of an 8-bit sRGB value, in [0, 1].
+func SRGBToLinear(v uint8) float64 {
+	return srgbToLinear[v] / 255
+}
+
+// linearToSRGB converts linear light on a 0-255 scale back to an sRGB value.
+func linearToSRGB(v float64) float64 {
+	i := mathutil.Clamp(int(math.Round(v/255*(linearLevels-1))), 0, linearLevels-1)
+	return float64(linearToSRGBTable[i])
+}
+
+// decodeSRGB applies the sRGB transfer function's inverse to a value in [0, 1].
+func decodeSRGB(c float64) float64 {
+	if c <= 0.04045 {
+		return c / 12.92
+	}
+	return math.Pow((c+0.055)/1.055, 2.4)
+}
+
+// encodeSRGB applies the sRGB transfer function to linear light in [0, 1].
+func encodeSRGB(c float64) float64 {
+	if c <= 0.0031308 {
+		return c * 12.92
+	}
+	return 1.055*math.Pow(c, 1/2.4) - 0.055
+}
@@ -0,0 +1,42 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestSRGBTablesRoundTrip(t *testing.T) {
+	for v := 0; v < 256; v++ {
+		if got := linearToSRGB(srgbToLinear[v]); got != float64(v) {
+			t.Errorf("sRGB %d came back as %v", v, got)
+		}
+	}
+}
+
+func TestGammaCorrectResizeOfCheckerboard(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			if (x+y)%2 == 0 {
+				img.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+
+	// Half the light of white is linear 0.5, which sRGB encodes as about 188
+	want := math.Round(255 * encodeSRGB(0.5))
+	linear := ToRGBA(ResizeWithOptions(img, 32, ResizeOptions{GammaCorrect: true}))
+	naive := ToRGBA(Resize(img, 32))
+	for i := 0; i < len(linear.Pix); i += 4 {
+		if got := float64(linear.Pix[i]); math.Abs(got-want) > 1 {
+			t.Fatalf("Pixel %d: expected gamma-correct gray %v, got %v", i/4, want, got)
+		}
+		if got := naive.Pix[i]; got < 126 || got > 129 {
+			t.Fatalf("Pixel %d: expected the sRGB average near 128 without gamma correction, got %d", i/4, got)
+		}
+	}
+}
@@ -1,19 +1,114 @@
 package imageio
 
 import (
+	"fmt"
 	"image"
+	"image/color/palette"
+	"image/draw"
+	"image/gif"
 	"image/png"
+	"io"
 	"os"
+	"path/filepath"
+	"strings"
+
+	// Registers the WebP decoder with image.Decode so results saved as WebP can be read back
+	_ "golang.org/x/image/webp"
+)
+
+// ColorModel selects how colour is stored in a saved image.
+type ColorModel int
+
+const (
+	// ColorTruecolor stores full 8-bit RGBA.
+	ColorTruecolor ColorModel = iota
+	// ColorPaletted quantizes to a 256-colour palette with Quantize.
+	ColorPaletted
+	// ColorGrayscale stores 8-bit luminance only, dropping colour and alpha.
+	ColorGrayscale
 )
 
+// ParseColorModel converts a colour model name (truecolor, paletted or grayscale) to a ColorModel.
+func ParseColorModel(name string) (ColorModel, error) {
+	switch name {
+	case "truecolor":
+		return ColorTruecolor, nil
+	case "paletted":
+		return ColorPaletted, nil
+	case "grayscale":
+		return ColorGrayscale, nil
+	}
+	return ColorTruecolor, fmt.Errorf("unknown color model %q, expected truecolor, paletted or grayscale", name)
+}
+
+// SaveOptions controls how Save encodes an image.
+type SaveOptions struct {
+	// CompressionLevel is passed to the PNG encoder.
+	CompressionLevel png.CompressionLevel
+	// Paletted quantizes the image to a 256-colour palette before encoding,
+	// which greatly reduces file size where exactness doesn't matter.
+	Paletted bool
+	// ColorModel converts the image before encoding. Paletted is the same as
+	// ColorPaletted.
+	ColorModel ColorModel
+}
+
+// Save encodes img in the format given by the file extension, .webp for WebP and
+// anything else for PNG, with default options.
 func Save(filePath string, img image.Image) error {
+	return SaveWithOptions(filePath, img, SaveOptions{})
+}
+
+// SaveAs is Save with the image stored in the given colour model.
+func SaveAs(filePath string, img image.Image, model ColorModel) error {
+	return SaveWithOptions(filePath, img, SaveOptions{ColorModel: model})
+}
+
+// SaveWithOptions encodes img according to opts in the format given by the file
+// extension, .webp for WebP and anything else for PNG.
+func SaveWithOptions(filePath string, img image.Image, opts SaveOptions) error {
 	file, err := os.Create(filePath)
 	if err != nil {
 		return err
 	}
 	defer file.Close()
 
-	return png.Encode(file, img)
+	switch {
+	case opts.Paletted || opts.ColorModel == ColorPaletted:
+		img = Quantize(img)
+	case opts.ColorModel == ColorGrayscale:
+		gray := image.NewGray(img.Bounds())
+		draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+		img = gray
+	}
+	if strings.EqualFold(filepath.Ext(filePath), ".webp") {
+		return encodeWebP(file, img)
+	}
+	encoder := png.Encoder{CompressionLevel: opts.CompressionLevel}
+	return encoder.Encode(file, img)
+}
+
+// Quantize maps img onto a fixed 256-colour palette using the nearest colour for each pixel.
+func Quantize(img image.Image) *image.Paletted {
+	bounds := img.Bounds()
+	paletted := image.NewPaletted(bounds, palette.Plan9)
+	draw.Draw(paletted, bounds, img, bounds.Min, draw.Src)
+	return paletted
+}
+
+// ParseCompressionLevel converts a compression name (default, best, fast or none) to a PNG compression level.
+func ParseCompressionLevel(name string) (png.CompressionLevel, error) {
+	switch name {
+	case "default":
+		return png.DefaultCompression, nil
+	case "best":
+		return png.BestCompression, nil
+	case "fast":
+		return png.BestSpeed, nil
+	case "none":
+		return png.NoCompression, nil
+	}
+	return png.DefaultCompression, fmt.Errorf("unknown compression level %q, expected default, best, fast or none", name)
 }
 
 // Read reads an image from a file and returns the decoded image and its format.
@@ -30,3 +125,70 @@ func Read(filePath string) (image.Image, error) {
 	}
 	return img, nil
 }
+
+// ReadAll reads every frame of an image file. Animated GIF frames are composited the way
+// a viewer would show them, so each one is a complete image; other formats return a
+// single frame.
+func ReadAll(filePath string) ([]image.Image, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	_, format, err := image.DecodeConfig(file)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if format != "gif" {
+		img, _, err := image.Decode(file)
+		if err != nil {
+			return nil, err
+		}
+		return []image.Image{img}, nil
+	}
+
+	anim, err := gif.DecodeAll(file)
+	if err != nil {
+		return nil, err
+	}
+	return compositeFrames(anim), nil
+}
+
+// compositeFrames draws each frame of anim over the canvas left by the previous ones,
+// applying the frames' disposal methods, and returns a copy of the canvas per frame.
+func compositeFrames(anim *gif.GIF) []image.Image {
+	bounds := image.Rect(0, 0, anim.Config.Width, anim.Config.Height)
+	if bounds.Empty() && len(anim.Image) > 0 {
+		bounds = anim.Image[0].Bounds()
+	}
+	canvas := image.NewRGBA(bounds)
+	frames := make([]image.Image, len(anim.Image))
+	for i, frame := range anim.Image {
+		disposal := byte(0)
+		if i < len(anim.Disposal) {
+			disposal = anim.Disposal[i]
+		}
+		var previous *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			previous = image.NewRGBA(bounds)
+			copy(previous.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		out := image.NewRGBA(bounds)
+		copy(out.Pix, canvas.Pix)
+		frames[i] = out
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = previous
+		}
+	}
+	return frames
+}
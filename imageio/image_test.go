@@ -0,0 +1,174 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"image/gif"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// noisyImage returns opaque random colours, which don't compress well as truecolor.
+func noisyImage(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	seed := uint32(1)
+	for i := 0; i < len(img.Pix); i++ {
+		seed = seed*1664525 + 1013904223
+		img.Pix[i] = uint8(seed >> 24)
+		if i%4 == 3 {
+			img.Pix[i] = 255
+		}
+	}
+	return img
+}
+
+func TestSaveWithOptions_PalettedIsSmaller(t *testing.T) {
+	img := noisyImage(128)
+
+	dir := t.TempDir()
+	truecolorPath := filepath.Join(dir, "truecolor.png")
+	palettedPath := filepath.Join(dir, "paletted.png")
+	if err := SaveWithOptions(truecolorPath, img, SaveOptions{}); err != nil {
+		t.Fatalf("Failed to save truecolor PNG: %v", err)
+	}
+	if err := SaveWithOptions(palettedPath, img, SaveOptions{Paletted: true}); err != nil {
+		t.Fatalf("Failed to save paletted PNG: %v", err)
+	}
+
+	decoded, err := Read(palettedPath)
+	if err != nil {
+		t.Fatalf("Failed to decode paletted PNG: %v", err)
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Errorf("Expected bounds %v, got %v", img.Bounds(), decoded.Bounds())
+	}
+	if _, ok := decoded.(*image.Paletted); !ok {
+		t.Errorf("Expected a paletted image, got %T", decoded)
+	}
+
+	truecolorInfo, _ := os.Stat(truecolorPath)
+	palettedInfo, _ := os.Stat(palettedPath)
+	if palettedInfo.Size() >= truecolorInfo.Size() {
+		t.Errorf("Paletted PNG (%d bytes) is not smaller than truecolor (%d bytes)", palettedInfo.Size(), truecolorInfo.Size())
+	}
+}
+
+func TestSaveAs(t *testing.T) {
+	img := noisyImage(64)
+	dir := t.TempDir()
+
+	palettedPath := filepath.Join(dir, "paletted.png")
+	if err := SaveAs(palettedPath, img, ColorPaletted); err != nil {
+		t.Fatalf("Failed to save paletted PNG: %v", err)
+	}
+	decoded, err := Read(palettedPath)
+	if err != nil {
+		t.Fatalf("Failed to decode paletted PNG: %v", err)
+	}
+	colors := make(map[color.RGBA]bool)
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			colors[color.RGBAModel.Convert(decoded.At(x, y)).(color.RGBA)] = true
+		}
+	}
+	if len(colors) > 256 {
+		t.Errorf("Expected at most 256 colours, got %d", len(colors))
+	}
+
+	grayPath := filepath.Join(dir, "gray.png")
+	if err := SaveAs(grayPath, img, ColorGrayscale); err != nil {
+		t.Fatalf("Failed to save grayscale PNG: %v", err)
+	}
+	decoded, err = Read(grayPath)
+	if err != nil {
+		t.Fatalf("Failed to decode grayscale PNG: %v", err)
+	}
+	if decoded.Bounds() != img.Bounds() {
+		t.Errorf("Expected bounds %v, got %v", img.Bounds(), decoded.Bounds())
+	}
+	for y := 0; y < 64; y++ {
+		for x := 0; x < 64; x++ {
+			r, g, b, _ := decoded.At(x, y).RGBA()
+			if r != g || g != b {
+				t.Fatalf("Pixel (%d,%d) is not gray: %d %d %d", x, y, r, g, b)
+			}
+		}
+	}
+}
+
+func TestParseCompressionLevel(t *testing.T) {
+	for _, name := range []string{"default", "best", "fast", "none"} {
+		if _, err := ParseCompressionLevel(name); err != nil {
+			t.Errorf("ParseCompressionLevel(%q) returned error: %v", name, err)
+		}
+	}
+	if _, err := ParseCompressionLevel("maximum"); err == nil {
+		t.Errorf("Expected an error for an unknown compression level")
+	}
+}
+
+func TestReadAllGIFFrames(t *testing.T) {
+	pal := color.Palette{color.RGBA{A: 255}, color.RGBA{R: 255, A: 255}, color.RGBA{G: 255, A: 255}, color.RGBA{B: 255, A: 255}}
+	fill := func(rect image.Rectangle, idx uint8) *image.Paletted {
+		frame := image.NewPaletted(rect, pal)
+		for i := range frame.Pix {
+			frame.Pix[i] = idx
+		}
+		return frame
+	}
+	anim := &gif.GIF{
+		Image: []*image.Paletted{
+			fill(image.Rect(0, 0, 8, 6), 1),
+			// A partial frame that only covers the left half
+			fill(image.Rect(0, 0, 4, 6), 2),
+			fill(image.Rect(0, 0, 8, 6), 3),
+		},
+		Delay:  []int{10, 10, 10},
+		Config: image.Config{Width: 8, Height: 6, ColorModel: pal},
+	}
+
+	path := filepath.Join(t.TempDir(), "anim.gif")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.EncodeAll(file, anim); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	frames, err := ReadAll(path)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(frames) != 3 {
+		t.Fatalf("Expected 3 frames, got %d", len(frames))
+	}
+
+	expect := func(frame, x, y int, want color.RGBA) {
+		t.Helper()
+		if got := color.RGBAModel.Convert(frames[frame].At(x, y)); got != want {
+			t.Errorf("Frame %d at (%d, %d): got %v, expected %v", frame, x, y, got, want)
+		}
+	}
+	expect(0, 6, 3, color.RGBA{R: 255, A: 255})
+	expect(1, 1, 3, color.RGBA{G: 255, A: 255})
+	// The partial frame leaves the previous frame visible on the right
+	expect(1, 6, 3, color.RGBA{R: 255, A: 255})
+	expect(2, 1, 3, color.RGBA{B: 255, A: 255})
+}
+
+func TestReadAllSingleFrame(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "still.png")
+	if err := Save(path, image.NewRGBA(image.Rect(0, 0, 5, 4))); err != nil {
+		t.Fatal(err)
+	}
+	frames, err := ReadAll(path)
+	if err != nil {
+		t.Fatalf("ReadAll failed: %v", err)
+	}
+	if len(frames) != 1 || frames[0].Bounds().Dx() != 5 {
+		t.Errorf("Expected a single 5x4 frame, got %d frame(s)", len(frames))
+	}
+}
@@ -0,0 +1,107 @@
+package imageio
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/fogleman/gg"
+)
+
+const (
+	plotWidth     = 800
+	plotHeight    = 500
+	plotMargin    = 60
+	plotGridLines = 5
+)
+
+// PlotFitness renders best and average fitness per generation as a line chart and saves it as a PNG.
+func PlotFitness(path string, gens []int, best, avg []float64) error {
+	if len(gens) == 0 {
+		return errors.New("no generations to plot")
+	}
+	if len(best) != len(gens) || len(avg) != len(gens) {
+		return fmt.Errorf("mismatched series lengths: %d generations, %d best, %d average", len(gens), len(best), len(avg))
+	}
+
+	minGen, maxGen := float64(gens[0]), float64(gens[len(gens)-1])
+	if maxGen == minGen {
+		maxGen = minGen + 1
+	}
+	minFit, maxFit := math.Inf(1), math.Inf(-1)
+	for i := range gens {
+		minFit = math.Min(minFit, math.Min(best[i], avg[i]))
+		maxFit = math.Max(maxFit, math.Max(best[i], avg[i]))
+	}
+	if maxFit == minFit {
+		maxFit = minFit + 1
+	}
+
+	plotW := float64(plotWidth - 2*plotMargin)
+	plotH := float64(plotHeight - 2*plotMargin)
+	toX := func(gen int) float64 {
+		return plotMargin + (float64(gen)-minGen)/(maxGen-minGen)*plotW
+	}
+	toY := func(fitness float64) float64 {
+		return plotMargin + (1-(fitness-minFit)/(maxFit-minFit))*plotH
+	}
+
+	dc := gg.NewContext(plotWidth, plotHeight)
+	dc.SetRGB(1, 1, 1)
+	dc.Clear()
+
+	// Gridlines and axis labels
+	dc.SetLineWidth(1)
+	for i := 0; i <= plotGridLines; i++ {
+		frac := float64(i) / plotGridLines
+		y := plotMargin + frac*plotH
+		x := plotMargin + frac*plotW
+
+		dc.SetRGB(0.85, 0.85, 0.85)
+		dc.DrawLine(plotMargin, y, plotMargin+plotW, y)
+		dc.DrawLine(x, plotMargin, x, plotMargin+plotH)
+		dc.Stroke()
+
+		dc.SetRGB(0.2, 0.2, 0.2)
+		dc.DrawStringAnchored(fmt.Sprintf("%.1f", maxFit-frac*(maxFit-minFit)), plotMargin-6, y, 1, 0.5)
+		dc.DrawStringAnchored(fmt.Sprintf("%.0f", minGen+frac*(maxGen-minGen)), x, plotMargin+plotH+6, 0.5, 1)
+	}
+
+	// Axes
+	dc.SetRGB(0, 0, 0)
+	dc.SetLineWidth(2)
+	dc.DrawLine(plotMargin, plotMargin, plotMargin, plotMargin+plotH)
+	dc.DrawLine(plotMargin, plotMargin+plotH, plotMargin+plotW, plotMargin+plotH)
+	dc.Stroke()
+	dc.DrawStringAnchored("Generation", plotMargin+plotW/2, plotHeight-plotMargin/3, 0.5, 0.5)
+	dc.DrawStringAnchored("Fitness", plotMargin, plotMargin/2, 0.5, 0.5)
+
+	// Series
+	drawSeries := func(values []float64, r, g, b float64) {
+		dc.SetRGB(r, g, b)
+		dc.SetLineWidth(2)
+		for i := range gens {
+			if i == 0 {
+				dc.MoveTo(toX(gens[i]), toY(values[i]))
+			} else {
+				dc.LineTo(toX(gens[i]), toY(values[i]))
+			}
+		}
+		dc.Stroke()
+	}
+	drawSeries(avg, 0.9, 0.5, 0.1)
+	drawSeries(best, 0.1, 0.4, 0.9)
+
+	// Legend
+	legendX := float64(plotWidth - plotMargin - 120)
+	dc.SetRGB(0.1, 0.4, 0.9)
+	dc.DrawLine(legendX, plotMargin/2, legendX+20, plotMargin/2)
+	dc.Stroke()
+	dc.DrawStringAnchored("Best", legendX+26, plotMargin/2, 0, 0.5)
+	dc.SetRGB(0.9, 0.5, 0.1)
+	dc.DrawLine(legendX+65, plotMargin/2, legendX+85, plotMargin/2)
+	dc.Stroke()
+	dc.DrawStringAnchored("Average", legendX+91, plotMargin/2, 0, 0.5)
+
+	return dc.SavePNG(path)
+}
@@ -0,0 +1,32 @@
+package imageio
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestPlotFitness(t *testing.T) {
+	gens := []int{1, 100, 200, 300, 400}
+	best := []float64{90, 60, 45, 40, 38}
+	avg := []float64{120, 80, 60, 52, 49}
+
+	path := filepath.Join(t.TempDir(), "fitness_plot.png")
+	if err := PlotFitness(path, gens, best, avg); err != nil {
+		t.Fatalf("PlotFitness failed: %v", err)
+	}
+
+	img, err := Read(path)
+	if err != nil {
+		t.Fatalf("Failed to read plot: %v", err)
+	}
+	if img.Bounds().Dx() != plotWidth || img.Bounds().Dy() != plotHeight {
+		t.Errorf("Expected plot of %dx%d, got %dx%d", plotWidth, plotHeight, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+}
+
+func TestPlotFitness_MismatchedSeries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "fitness_plot.png")
+	if err := PlotFitness(path, []int{1, 2}, []float64{1}, []float64{1, 2}); err == nil {
+		t.Errorf("Expected an error for mismatched series lengths")
+	}
+}
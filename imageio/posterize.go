@@ -0,0 +1,29 @@
+package imageio
+
+import (
+	"image"
+	"image/draw"
+	"math"
+)
+
+// Posterize reduces every color channel of img to the given number of evenly spaced
+// levels between 0 and 255, producing bold flat-color regions. Alpha is preserved.
+// levels must be at least 2.
+func Posterize(img image.Image, levels int) *image.RGBA {
+	bounds := img.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), img, bounds.Min, draw.Src)
+
+	var lut [256]uint8
+	step := 255.0 / float64(levels-1)
+	for v := range lut {
+		lut[v] = uint8(math.Round(math.Round(float64(v)/step) * step))
+	}
+
+	for i := 0; i < len(dst.Pix); i += 4 {
+		dst.Pix[i] = lut[dst.Pix[i]]
+		dst.Pix[i+1] = lut[dst.Pix[i+1]]
+		dst.Pix[i+2] = lut[dst.Pix[i+2]]
+	}
+	return dst
+}
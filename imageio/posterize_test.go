@@ -0,0 +1,48 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestPosterize_TwoLevels(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 256; x++ {
+			img.SetRGBA(x, y, color.RGBA{R: uint8(x), G: uint8(255 - x), B: uint8(x / 2), A: 255})
+		}
+	}
+
+	posterized := Posterize(img, 2)
+	if posterized.Bounds().Dx() != 256 || posterized.Bounds().Dy() != 4 {
+		t.Fatalf("Expected 256x4, got %v", posterized.Bounds())
+	}
+	for i, v := range posterized.Pix {
+		if i%4 == 3 {
+			continue
+		}
+		if v != 0 && v != 255 {
+			t.Fatalf("Byte %d has value %d, expected 0 or 255", i, v)
+		}
+	}
+	if c := posterized.RGBAAt(10, 0); c.R != 0 || c.G != 255 {
+		t.Errorf("Expected dark red and bright green channels to snap to 0 and 255, got %v", c)
+	}
+}
+
+func TestPosterize_LevelCount(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 256, 1))
+	for x := 0; x < 256; x++ {
+		img.SetRGBA(x, 0, color.RGBA{R: uint8(x), A: 255})
+	}
+
+	seen := map[uint8]bool{}
+	posterized := Posterize(img, 4)
+	for x := 0; x < 256; x++ {
+		seen[posterized.RGBAAt(x, 0).R] = true
+	}
+	if len(seen) != 4 {
+		t.Errorf("Expected 4 distinct levels, got %d", len(seen))
+	}
+}
@@ -8,8 +8,20 @@ import (
 	"github.com/bishal0602/chaotic-canvas/mathutil"
 )
 
+// ResizeOptions controls how Resize resamples an image.
+type ResizeOptions struct {
+	// GammaCorrect interpolates in linear light instead of directly on sRGB values,
+	// which otherwise darkens fine detail and high-contrast edges when downscaling.
+	GammaCorrect bool
+}
+
 // Resize limits the maximum width and height to maxDim while preserving the aspect ratio.
 func Resize(img image.Image, maxDim int) image.Image {
+	return ResizeWithOptions(img, maxDim, ResizeOptions{})
+}
+
+// ResizeWithOptions is Resize with resampling controlled by opts.
+func ResizeWithOptions(img image.Image, maxDim int, opts ResizeOptions) image.Image {
 	bounds := img.Bounds()
 	width := bounds.Dx()
 	height := bounds.Dy()
@@ -30,16 +42,24 @@ func Resize(img image.Image, maxDim int) image.Image {
 	}
 
 	// Use a high-quality resampling algorithm.
-	resizedImg := resizeBilinear(img, newWidth, newHeight)
+	resizedImg := resizeBilinear(img, newWidth, newHeight, opts.GammaCorrect)
 	return resizedImg
 }
 
+// ResizeTo resizes img to exactly width x height, ignoring the aspect ratio.
+func ResizeTo(img image.Image, width, height int) image.Image {
+	return resizeBilinear(img, width, height, false)
+}
+
 // resizeBilinear resizes the input image to the given width and height using bilinear interpolation.
-func resizeBilinear(src image.Image, newWidth, newHeight int) image.Image {
+// The source is converted to RGBA once up front so pixels are read straight from its
+// buffer instead of through the At interface. With gammaCorrect, the colour channels
+// are interpolated in linear light; alpha is always interpolated as is.
+func resizeBilinear(src image.Image, newWidth, newHeight int, gammaCorrect bool) image.Image {
 	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
-	srcBounds := src.Bounds()
-	srcWidth := srcBounds.Dx()
-	srcHeight := srcBounds.Dy()
+	rgba := ToRGBA(src)
+	srcWidth := rgba.Bounds().Dx()
+	srcHeight := rgba.Bounds().Dy()
 
 	xScale := float64(srcWidth) / float64(newWidth)
 	yScale := float64(srcHeight) / float64(newHeight)
@@ -63,41 +83,59 @@ func resizeBilinear(src image.Image, newWidth, newHeight int) image.Image {
 			x0 = mathutil.Max(0, x0)
 			x1 = mathutil.Min(srcWidth-1, x1)
 
-			// Get the four surrounding pixels.
-			c00 := src.At(srcBounds.Min.X+x0, srcBounds.Min.Y+y0)
-			c01 := src.At(srcBounds.Min.X+x1, srcBounds.Min.Y+y0)
-			c10 := src.At(srcBounds.Min.X+x0, srcBounds.Min.Y+y1)
-			c11 := src.At(srcBounds.Min.X+x1, srcBounds.Min.Y+y1)
+			// Get the four surrounding pixels as floating point values on a 0-255 scale.
+			r00, g00, b00, a00 := pixelToFloat(rgba, x0, y0)
+			r01, g01, b01, a01 := pixelToFloat(rgba, x1, y0)
+			r10, g10, b10, a10 := pixelToFloat(rgba, x0, y1)
+			r11, g11, b11, a11 := pixelToFloat(rgba, x1, y1)
 
-			// Convert the colors to floating point values on a 0-255 scale.
-			r00, g00, b00, a00 := colorToFloat(c00)
-			r01, g01, b01, a01 := colorToFloat(c01)
-			r10, g10, b10, a10 := colorToFloat(c10)
-			r11, g11, b11, a11 := colorToFloat(c11)
+			if gammaCorrect {
+				r00, g00, b00 = srgbToLinear[int(r00)], srgbToLinear[int(g00)], srgbToLinear[int(b00)]
+				r01, g01, b01 = srgbToLinear[int(r01)], srgbToLinear[int(g01)], srgbToLinear[int(b01)]
+				r10, g10, b10 = srgbToLinear[int(r10)], srgbToLinear[int(g10)], srgbToLinear[int(b10)]
+				r11, g11, b11 = srgbToLinear[int(r11)], srgbToLinear[int(g11)], srgbToLinear[int(b11)]
+			}
 
 			// Interpolate each channel.
 			r := bilinear(r00, r01, r10, r11, u, v)
 			g := bilinear(g00, g01, g10, g11, u, v)
 			b := bilinear(b00, b01, b10, b11, u, v)
 			a := bilinear(a00, a01, a10, a11, u, v)
-
-			// Set the new pixel in the destination image.
-			dst.Set(x, y, color.NRGBA{
-				R: uint8(mathutil.Clamp(r, 0, 255)),
-				G: uint8(mathutil.Clamp(g, 0, 255)),
-				B: uint8(mathutil.Clamp(b, 0, 255)),
-				A: uint8(mathutil.Clamp(a, 0, 255)),
-			})
+			if gammaCorrect {
+				r, g, b = linearToSRGB(r), linearToSRGB(g), linearToSRGB(b)
+			}
+
+			// Set the new pixel in the destination image, treating it as non-premultiplied.
+			setNRGBA(dst, x, y,
+				mathutil.ClampUint8(r),
+				mathutil.ClampUint8(g),
+				mathutil.ClampUint8(b),
+				mathutil.ClampUint8(a),
+			)
 		}
 	}
 	return dst
 }
 
-// colorToFloat converts a color.Color to its RGBA components as float64 values in 0-255 range.
-func colorToFloat(c color.Color) (float64, float64, float64, float64) {
-	r, g, b, a := c.RGBA()
-	// Division by 257 correctly scales a uint16 (0-65535) to a float64 (0.0-255.0)
-	return float64(r) / 257.0, float64(g) / 257.0, float64(b) / 257.0, float64(a) / 257.0
+// setNRGBA stores the non-premultiplied color (r, g, b, a) at (x, y), premultiplying it
+// exactly as dst.Set(x, y, color.NRGBA{r, g, b, a}) would, without the interface allocation.
+func setNRGBA(dst *image.RGBA, x, y int, r, g, b, a uint8) {
+	i := y*dst.Stride + x*4
+	if a == 0xff {
+		dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = r, g, b, a
+		return
+	}
+	a16 := uint32(a) * 0x101
+	premultiply := func(c uint8) uint8 {
+		return uint8((uint32(c) * 0x101 * a16 / 0xffff) >> 8)
+	}
+	dst.Pix[i], dst.Pix[i+1], dst.Pix[i+2], dst.Pix[i+3] = premultiply(r), premultiply(g), premultiply(b), a
+}
+
+// pixelToFloat returns the RGBA components of the pixel at (x, y) as float64 values in 0-255 range.
+func pixelToFloat(img *image.RGBA, x, y int) (float64, float64, float64, float64) {
+	i := y*img.Stride + x*4
+	return float64(img.Pix[i]), float64(img.Pix[i+1]), float64(img.Pix[i+2]), float64(img.Pix[i+3])
 }
 
 // bilinear performs bilinear interpolation given the four corner values and interpolation factors u and v.
@@ -107,3 +145,10 @@ func bilinear(c00, c01, c10, c11, u, v float64) float64 {
 		(1-u)*v*c10 +
 		u*v*c11
 }
+
+// colorToFloat converts a color.Color to its RGBA components as float64 values in 0-255 range.
+func colorToFloat(c color.Color) (float64, float64, float64, float64) {
+	r, g, b, a := c.RGBA()
+	// Division by 257 correctly scales a uint16 (0-65535) to a float64 (0.0-255.0)
+	return float64(r) / 257.0, float64(g) / 257.0, float64(b) / 257.0, float64(a) / 257.0
+}
@@ -0,0 +1,70 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"testing"
+)
+
+// channelVariance returns the variance of the red channel over the given rectangle.
+func channelVariance(img *image.RGBA, r image.Rectangle) float64 {
+	var sum, sumSq float64
+	n := float64(r.Dx() * r.Dy())
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			v := float64(img.RGBAAt(x, y).R)
+			sum += v
+			sumSq += v * v
+		}
+	}
+	mean := sum / n
+	return sumSq/n - mean*mean
+}
+
+func TestSmoothReducesNoiseAndKeepsEdges(t *testing.T) {
+	// Left half: low-contrast noise around grey. Right half: near white.
+	width, height := 40, 20
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			v := uint8(100 + 12*((x+y)%2))
+			if x >= width/2 {
+				v = 240
+			}
+			img.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 200})
+		}
+	}
+
+	smoothed := Smooth(img, 1.5)
+
+	flat := image.Rect(2, 2, width/2-4, height-2)
+	before, after := channelVariance(img, flat), channelVariance(smoothed, flat)
+	if after >= before/4 {
+		t.Errorf("Expected smoothing to reduce variance in the flat region, got %.2f from %.2f", after, before)
+	}
+
+	for y := 0; y < height; y++ {
+		dark, light := smoothed.RGBAAt(width/2-1, y), smoothed.RGBAAt(width/2, y)
+		if light.R < 230 || dark.R > 120 {
+			t.Fatalf("Edge at row %d blurred: %d | %d", y, dark.R, light.R)
+		}
+		if dark.A != 200 || light.A != 200 {
+			t.Fatalf("Alpha changed at row %d", y)
+		}
+	}
+}
+
+func TestSmoothZeroStrength(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 3, 3))
+	img.SetRGBA(1, 1, color.RGBA{R: 255, A: 255})
+
+	smoothed := Smooth(img, 0)
+	if smoothed == img {
+		t.Fatal("Expected a copy")
+	}
+	for i := range img.Pix {
+		if smoothed.Pix[i] != img.Pix[i] {
+			t.Fatalf("Byte %d changed with zero strength", i)
+		}
+	}
+}
@@ -0,0 +1,46 @@
+package imageio
+
+import (
+	"image"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+// ColorStats holds the per-channel mean and variance of an image's colours in 8-bit
+// channel units, in red, green, blue order.
+type ColorStats struct {
+	Mean     [3]float64
+	Variance [3]float64
+}
+
+// TotalVariance returns the sum of the channel variances, a rough measure of how much
+// the image varies at all.
+func (cs ColorStats) TotalVariance() float64 {
+	return cs.Variance[0] + cs.Variance[1] + cs.Variance[2]
+}
+
+// ImageStats returns the mean and variance of each colour channel over img. An empty
+// image has zero stats.
+func ImageStats(img image.Image) ColorStats {
+	rgba := ToRGBA(img)
+	var stats ColorStats
+	n := float64(len(rgba.Pix) / 4)
+	if n == 0 {
+		return stats
+	}
+
+	var sum, sumSq [3]float64
+	for i := 0; i < len(rgba.Pix); i += 4 {
+		for c := 0; c < 3; c++ {
+			v := float64(rgba.Pix[i+c])
+			sum[c] += v
+			sumSq[c] += v * v
+		}
+	}
+	for c := 0; c < 3; c++ {
+		stats.Mean[c] = sum[c] / n
+		// Rounding can leave a tiny negative for a solid image
+		stats.Variance[c] = mathutil.Max(sumSq[c]/n-stats.Mean[c]*stats.Mean[c], 0.0)
+	}
+	return stats
+}
@@ -0,0 +1,43 @@
+package imageio
+
+import (
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+func TestImageStats(t *testing.T) {
+	// Half black, half white red channel; green fixed; blue empty
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 4; x++ {
+			r := uint8(0)
+			if x >= 2 {
+				r = 200
+			}
+			img.SetRGBA(x, y, color.RGBA{R: r, G: 50, A: 255})
+		}
+	}
+
+	stats := ImageStats(img)
+	wantMean := [3]float64{100, 50, 0}
+	wantVariance := [3]float64{10000, 0, 0}
+	for c := 0; c < 3; c++ {
+		if math.Abs(stats.Mean[c]-wantMean[c]) > 1e-9 {
+			t.Errorf("Channel %d: expected mean %v, got %v", c, wantMean[c], stats.Mean[c])
+		}
+		if math.Abs(stats.Variance[c]-wantVariance[c]) > 1e-9 {
+			t.Errorf("Channel %d: expected variance %v, got %v", c, wantVariance[c], stats.Variance[c])
+		}
+	}
+	if got := stats.TotalVariance(); got != 10000 {
+		t.Errorf("Expected total variance 10000, got %v", got)
+	}
+}
+
+func TestImageStatsEmpty(t *testing.T) {
+	if stats := ImageStats(image.NewRGBA(image.Rect(0, 0, 0, 0))); stats != (ColorStats{}) {
+		t.Errorf("Expected zero stats for an empty image, got %+v", stats)
+	}
+}
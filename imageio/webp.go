@@ -0,0 +1,596 @@
+package imageio
+
+import (
+	"container/heap"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math/bits"
+
+	"github.com/bishal0602/chaotic-canvas/mathutil"
+)
+
+const (
+	// maxWebPDimension is the largest width or height a VP8L header can describe.
+	maxWebPDimension = 1 << 14
+
+	vp8lSignature        = 0x2f
+	vp8lPredictor        = 0
+	vp8lSubtractGreen    = 2
+	vp8lPredictorBits    = 4
+	vp8lMaxCodeLength    = 15
+	vp8lMaxCodeLenLength = 7
+	vp8lGreenAlphabet    = 256 + 24
+	vp8lColorAlphabet    = 256
+	vp8lDistanceAlphabet = 40
+
+	// Backward references copy earlier pixels of the (transformed) image
+	lz77MinLength   = 3
+	lz77MaxLength   = 4096
+	lz77MaxDistance = 1<<20 - 120
+	lz77ChainDepth  = 16
+	lz77HashBits    = 16
+)
+
+// vp8lDistanceTable lists the two-dimensional neighbour offsets that VP8L distance
+// codes 1 to 120 stand for, as dy<<4 | (8-dx).
+var vp8lDistanceTable = [120]uint8{
+	0x18, 0x07, 0x17, 0x19, 0x28, 0x06, 0x27, 0x29, 0x16, 0x1a,
+	0x26, 0x2a, 0x38, 0x05, 0x37, 0x39, 0x15, 0x1b, 0x36, 0x3a,
+	0x25, 0x2b, 0x48, 0x04, 0x47, 0x49, 0x14, 0x1c, 0x35, 0x3b,
+	0x46, 0x4a, 0x24, 0x2c, 0x58, 0x45, 0x4b, 0x34, 0x3c, 0x03,
+	0x57, 0x59, 0x13, 0x1d, 0x56, 0x5a, 0x23, 0x2d, 0x44, 0x4c,
+	0x55, 0x5b, 0x33, 0x3d, 0x68, 0x02, 0x67, 0x69, 0x12, 0x1e,
+	0x66, 0x6a, 0x22, 0x2e, 0x54, 0x5c, 0x43, 0x4d, 0x65, 0x6b,
+	0x32, 0x3e, 0x78, 0x01, 0x77, 0x79, 0x53, 0x5d, 0x11, 0x1f,
+	0x64, 0x6c, 0x42, 0x4e, 0x76, 0x7a, 0x21, 0x2f, 0x75, 0x7b,
+	0x31, 0x3f, 0x63, 0x6d, 0x52, 0x5e, 0x00, 0x74, 0x7c, 0x41,
+	0x4f, 0x10, 0x20, 0x62, 0x6e, 0x30, 0x73, 0x7d, 0x51, 0x5f,
+	0x40, 0x72, 0x7e, 0x61, 0x6f, 0x50, 0x71, 0x7f, 0x60, 0x70,
+}
+
+// vp8lCodeLengthOrder is the order in which code length code lengths are stored.
+var vp8lCodeLengthOrder = [19]int{17, 18, 0, 1, 2, 3, 4, 5, 16, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15}
+
+// vp8lPredictorModes are the spatial predictors tried for each tile, by VP8L mode number.
+var vp8lPredictorModes = []int{1, 2, 3, 4, 7, 12}
+
+// encodeWebP writes img to w as a WebP image using the lossless VP8L format.
+func encodeWebP(w io.Writer, img image.Image) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width < 1 || height < 1 || width > maxWebPDimension || height > maxWebPDimension {
+		return fmt.Errorf("webp images must be between 1 and %d pixels wide and high, got %dx%d", maxWebPDimension, width, height)
+	}
+
+	// VP8L stores non-premultiplied colour
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+	pix := nrgba.Pix
+	opaque := nrgba.Opaque()
+
+	var bw bitWriter
+	bw.write(vp8lSignature, 8)
+	bw.write(uint32(width-1), 14)
+	bw.write(uint32(height-1), 14)
+	if opaque {
+		bw.write(0, 1)
+	} else {
+		bw.write(1, 1)
+	}
+	bw.write(0, 3) // version
+
+	// The decoder undoes transforms in reverse, so subtract green goes first
+	bw.write(1, 1)
+	bw.write(vp8lSubtractGreen, 2)
+	subtractGreen(pix)
+
+	bw.write(1, 1)
+	bw.write(vp8lPredictor, 2)
+	bw.write(vp8lPredictorBits-2, 3)
+	modes, residuals := predict(pix, width, height)
+	tileSize := 1 << vp8lPredictorBits
+	writeImageData(&bw, modes, (width+tileSize-1)/tileSize, false)
+
+	bw.write(0, 1) // no more transforms
+	writeImageData(&bw, residuals, width, true)
+
+	data := bw.bytes()
+	chunkSize := len(data)
+	if len(data)%2 == 1 {
+		data = append(data, 0)
+	}
+	file := []byte("RIFF")
+	file = binary.LittleEndian.AppendUint32(file, uint32(4+8+len(data)))
+	file = append(file, "WEBPVP8L"...)
+	file = binary.LittleEndian.AppendUint32(file, uint32(chunkSize))
+	file = append(file, data...)
+	_, err := w.Write(file)
+	return err
+}
+
+// subtractGreen applies the VP8L subtract green transform in place.
+func subtractGreen(pix []byte) {
+	for i := 0; i < len(pix); i += 4 {
+		pix[i+0] -= pix[i+1]
+		pix[i+2] -= pix[i+1]
+	}
+}
+
+// predict applies the VP8L predictor transform, choosing for each tile the mode with the
+// smallest residuals. It returns the tile mode sub-image and the residual pixels, both
+// in RGBA order. Like the decoder, it reads neighbours by offset into the flat pixel
+// slice, so the top-right neighbour of the last column is the row's first pixel.
+func predict(pix []byte, width, height int) (modes, residuals []byte) {
+	tileSize := 1 << vp8lPredictorBits
+	tilesX, tilesY := (width+tileSize-1)/tileSize, (height+tileSize-1)/tileSize
+	modes = make([]byte, 4*tilesX*tilesY)
+	residuals = make([]byte, len(pix))
+	stride := 4 * width
+
+	var prediction [4]byte
+	residual := func(p int, mode int) {
+		predictPixel(pix, p, stride, mode, &prediction)
+		for c := 0; c < 4; c++ {
+			residuals[p+c] = pix[p+c] - prediction[c]
+		}
+	}
+
+	for ty := 0; ty < tilesY; ty++ {
+		for tx := 0; tx < tilesX; tx++ {
+			x0, y0 := tx*tileSize, ty*tileSize
+			x1, y1 := mathutil.Min(x0+tileSize, width), mathutil.Min(y0+tileSize, height)
+
+			// The first row and column have fixed predictors, so only the rest of the
+			// tile decides its mode
+			bestMode, bestCost := vp8lPredictorModes[0], -1
+			for _, mode := range vp8lPredictorModes {
+				cost := 0
+				for y := mathutil.Max(y0, 1); y < y1; y++ {
+					for x := mathutil.Max(x0, 1); x < x1; x++ {
+						p := y*stride + 4*x
+						predictPixel(pix, p, stride, mode, &prediction)
+						for c := 0; c < 4; c++ {
+							cost += mathutil.Abs(int(int8(pix[p+c] - prediction[c])))
+						}
+					}
+				}
+				if bestCost < 0 || cost < bestCost {
+					bestMode, bestCost = mode, cost
+				}
+			}
+			modes[4*(ty*tilesX+tx)+1] = uint8(bestMode)
+
+			for y := y0; y < y1; y++ {
+				for x := x0; x < x1; x++ {
+					mode := bestMode
+					switch {
+					case x == 0 && y == 0:
+						mode = 0
+					case y == 0:
+						mode = 1
+					case x == 0:
+						mode = 2
+					}
+					residual(y*stride+4*x, mode)
+				}
+			}
+		}
+	}
+	return modes, residuals
+}
+
+// predictPixel sets prediction to the VP8L predictor mode's guess for the pixel at p.
+func predictPixel(pix []byte, p, stride, mode int, prediction *[4]byte) {
+	top := p - stride
+	for c := 0; c < 4; c++ {
+		switch mode {
+		case 0:
+			prediction[c] = 0
+			if c == 3 {
+				prediction[c] = 0xff
+			}
+		case 1:
+			prediction[c] = pix[p-4+c]
+		case 2:
+			prediction[c] = pix[top+c]
+		case 3:
+			prediction[c] = pix[top+4+c]
+		case 4:
+			prediction[c] = pix[top-4+c]
+		case 7:
+			prediction[c] = uint8((int(pix[p-4+c]) + int(pix[top+c])) / 2)
+		case 12:
+			v := int(pix[p-4+c]) + int(pix[top+c]) - int(pix[top-4+c])
+			prediction[c] = uint8(mathutil.Clamp(v, 0, 255))
+		}
+	}
+}
+
+// lz77Token is either a literal pixel or, when length is non-zero, a backward reference
+// copying length pixels from distance code distCode.
+type lz77Token struct {
+	pixel    uint32
+	length   int
+	distCode int
+}
+
+// writeImageData entropy-codes RGBA pixels of an image width pixels wide, using
+// backward references but no colour cache, under a single group of prefix codes.
+func writeImageData(bw *bitWriter, pix []byte, width int, topLevel bool) {
+	bw.write(0, 1) // no colour cache
+	if topLevel {
+		bw.write(0, 1) // no meta prefix codes
+	}
+
+	tokens := findBackwardReferences(pix, width)
+	green := make([]uint32, vp8lGreenAlphabet)
+	red := make([]uint32, vp8lColorAlphabet)
+	blue := make([]uint32, vp8lColorAlphabet)
+	alpha := make([]uint32, vp8lColorAlphabet)
+	distance := make([]uint32, vp8lDistanceAlphabet)
+	for _, t := range tokens {
+		if t.length > 0 {
+			lengthSymbol, _, _ := lz77Prefix(t.length)
+			distSymbol, _, _ := lz77Prefix(t.distCode)
+			green[256+lengthSymbol]++
+			distance[distSymbol]++
+			continue
+		}
+		red[t.pixel>>24]++
+		green[t.pixel>>16&0xff]++
+		blue[t.pixel>>8&0xff]++
+		alpha[t.pixel&0xff]++
+	}
+	codes := [5]prefixCode{
+		writePrefixCode(bw, green),
+		writePrefixCode(bw, red),
+		writePrefixCode(bw, blue),
+		writePrefixCode(bw, alpha),
+		writePrefixCode(bw, distance),
+	}
+
+	for _, t := range tokens {
+		if t.length > 0 {
+			symbol, extraBits, extra := lz77Prefix(t.length)
+			codes[0].write(bw, 256+symbol)
+			bw.write(extra, extraBits)
+			symbol, extraBits, extra = lz77Prefix(t.distCode)
+			codes[4].write(bw, symbol)
+			bw.write(extra, extraBits)
+			continue
+		}
+		codes[0].write(bw, int(t.pixel>>16&0xff))
+		codes[1].write(bw, int(t.pixel>>24))
+		codes[2].write(bw, int(t.pixel>>8&0xff))
+		codes[3].write(bw, int(t.pixel&0xff))
+	}
+}
+
+// lz77Prefix splits a backward reference length or distance code, which start at 1,
+// into its prefix symbol and the extra bits that follow it.
+func lz77Prefix(value int) (symbol int, extraBits uint, extra uint32) {
+	v := value - 1
+	if v < 4 {
+		return v, 0, 0
+	}
+	highest := bits.Len(uint(v)) - 1
+	second := v >> (highest - 1) & 1
+	extraBits = uint(highest - 1)
+	return 2*highest + second, extraBits, uint32(v) & (1<<extraBits - 1)
+}
+
+// findBackwardReferences greedily replaces runs of pixels that appeared earlier in the
+// image with backward references, trying the pixels to the left and above before a
+// hash chain of earlier positions.
+func findBackwardReferences(pix []byte, width int) []lz77Token {
+	n := len(pix) / 4
+	pixels := make([]uint32, n)
+	for i := range pixels {
+		pixels[i] = binary.BigEndian.Uint32(pix[4*i:])
+	}
+
+	// Distances to nearby pixels have short codes of their own
+	distCodes := make(map[int]int, len(vp8lDistanceTable))
+	for code := len(vp8lDistanceTable); code >= 1; code-- {
+		offset := int(vp8lDistanceTable[code-1])
+		if d := offset>>4*width + 8 - offset&0xf; d >= 1 {
+			distCodes[d] = code
+		}
+	}
+	distCode := func(d int) int {
+		if code, ok := distCodes[d]; ok {
+			return code
+		}
+		return d + len(vp8lDistanceTable)
+	}
+
+	head := make([]int32, 1<<lz77HashBits)
+	for i := range head {
+		head[i] = -1
+	}
+	chain := make([]int32, n)
+	hash := func(i int) uint32 {
+		return (pixels[i]*0x1e35a7bd + pixels[i+1]) * 0x9e3779b1 >> (32 - lz77HashBits)
+	}
+	insert := func(i int) {
+		if i+1 < n {
+			h := hash(i)
+			chain[i], head[h] = head[h], int32(i)
+		}
+	}
+	matchLength := func(i, candidate int) int {
+		limit := mathutil.Min(n-i, lz77MaxLength)
+		length := 0
+		for length < limit && pixels[candidate+length] == pixels[i+length] {
+			length++
+		}
+		return length
+	}
+
+	var tokens []lz77Token
+	for i := 0; i < n; {
+		bestLength, bestDistance := 0, 0
+		try := func(candidate int) {
+			d := i - candidate
+			if candidate < 0 || d < 1 || d > lz77MaxDistance {
+				return
+			}
+			if length := matchLength(i, candidate); length > bestLength {
+				bestLength, bestDistance = length, d
+			}
+		}
+		try(i - 1)
+		try(i - width)
+		if i+1 < n {
+			candidate := int(head[hash(i)])
+			for depth := 0; candidate >= 0 && depth < lz77ChainDepth; depth++ {
+				try(candidate)
+				candidate = int(chain[candidate])
+			}
+		}
+
+		if bestLength < lz77MinLength {
+			tokens = append(tokens, lz77Token{pixel: pixels[i]})
+			insert(i)
+			i++
+			continue
+		}
+		tokens = append(tokens, lz77Token{length: bestLength, distCode: distCode(bestDistance)})
+		for end := i + bestLength; i < end; i++ {
+			insert(i)
+		}
+	}
+	return tokens
+}
+
+// prefixCode is a canonical Huffman code. codes hold each symbol's code with its bits
+// reversed, since VP8L reads codes a bit at a time from the least significant end.
+type prefixCode struct {
+	lengths []uint8
+	codes   []uint16
+	// single marks a code with one symbol, which takes no bits to write
+	single bool
+}
+
+func (pc prefixCode) write(bw *bitWriter, symbol int) {
+	if pc.single {
+		return
+	}
+	bw.write(uint32(pc.codes[symbol]), uint(pc.lengths[symbol]))
+}
+
+// writePrefixCode builds a prefix code for the histogram, writes its description and
+// returns it.
+func writePrefixCode(bw *bitWriter, histogram []uint32) prefixCode {
+	var used []int
+	for symbol, count := range histogram {
+		if count > 0 {
+			used = append(used, symbol)
+		}
+	}
+
+	// One or two small symbols fit the simple code, which needs no code lengths.
+	// Single symbols take no bits at all to write.
+	if len(used) <= 2 && (len(used) == 0 || used[len(used)-1] < 256) {
+		if len(used) == 0 {
+			used = []int{0}
+		}
+		bw.write(1, 1)
+		bw.write(uint32(len(used)-1), 1)
+		if used[0] > 1 {
+			bw.write(1, 1)
+			bw.write(uint32(used[0]), 8)
+		} else {
+			bw.write(0, 1)
+			bw.write(uint32(used[0]), 1)
+		}
+		if len(used) == 2 {
+			bw.write(uint32(used[1]), 8)
+		}
+		pc := prefixCode{lengths: make([]uint8, len(histogram)), codes: make([]uint16, len(histogram))}
+		if len(used) == 2 {
+			pc.lengths[used[0]], pc.lengths[used[1]] = 1, 1
+			pc.codes[used[1]] = 1
+		}
+		return pc
+	}
+
+	pc := newPrefixCode(histogram, vp8lMaxCodeLength)
+
+	// The code lengths are themselves written with a prefix code over the lengths 0-15
+	lengthHistogram := make([]uint32, len(vp8lCodeLengthOrder))
+	for _, length := range pc.lengths {
+		lengthHistogram[length]++
+	}
+	lengthCode := newPrefixCode(lengthHistogram, vp8lMaxCodeLenLength)
+	count := 4
+	for i, symbol := range vp8lCodeLengthOrder {
+		if lengthCode.lengths[symbol] > 0 {
+			count = mathutil.Max(count, i+1)
+		}
+	}
+	bw.write(0, 1)
+	bw.write(uint32(count-4), 4)
+	for _, symbol := range vp8lCodeLengthOrder[:count] {
+		bw.write(uint32(lengthCode.lengths[symbol]), 3)
+	}
+	bw.write(0, 1) // code lengths run to the end of the alphabet
+	for _, length := range pc.lengths {
+		lengthCode.write(bw, int(length))
+	}
+	return pc
+}
+
+// newPrefixCode builds a canonical Huffman code for the histogram whose codes are at
+// most maxLength bits. A lone symbol gets a one-bit length, which VP8L decoders treat
+// as a code of zero bits.
+func newPrefixCode(histogram []uint32, maxLength int) prefixCode {
+	lengths := huffmanLengths(histogram, maxLength)
+	pc := prefixCode{lengths: lengths, codes: make([]uint16, len(lengths))}
+
+	var used int
+	var countPerLength [vp8lMaxCodeLength + 1]int
+	for _, length := range lengths {
+		if length > 0 {
+			used++
+			countPerLength[length]++
+		}
+	}
+	if used < 2 {
+		pc.single = true
+		return pc
+	}
+	var next [vp8lMaxCodeLength + 1]int
+	code := 0
+	for length := 1; length <= vp8lMaxCodeLength; length++ {
+		code = (code + countPerLength[length-1]) << 1
+		next[length] = code
+	}
+	for symbol, length := range lengths {
+		if length == 0 {
+			continue
+		}
+		c := next[length]
+		next[length]++
+		var reversed uint16
+		for i := 0; i < int(length); i++ {
+			reversed = reversed<<1 | uint16(c>>i&1)
+		}
+		pc.codes[symbol] = reversed
+	}
+	return pc
+}
+
+// huffmanLengths returns Huffman code lengths for the histogram, flattening the counts
+// until no code is longer than maxLength.
+func huffmanLengths(histogram []uint32, maxLength int) []uint8 {
+	counts := append([]uint32(nil), histogram...)
+	for {
+		lengths, longest := huffmanTreeLengths(counts)
+		if longest <= maxLength {
+			return lengths
+		}
+		for i, count := range counts {
+			if count > 0 {
+				counts[i] = count/2 + 1
+			}
+		}
+	}
+}
+
+// huffmanTreeLengths builds an unconstrained Huffman tree and returns each symbol's
+// depth and the greatest depth.
+func huffmanTreeLengths(counts []uint32) ([]uint8, int) {
+	lengths := make([]uint8, len(counts))
+	h := &huffmanHeap{}
+	var parents []int
+	for symbol, count := range counts {
+		if count > 0 {
+			heap.Push(h, huffmanNode{count: count, index: len(parents), symbol: symbol})
+			parents = append(parents, -1)
+		}
+	}
+	leaves := len(parents)
+	if leaves == 1 {
+		lengths[(*h)[0].symbol] = 1
+		return lengths, 1
+	}
+	for h.Len() > 1 {
+		a, b := heap.Pop(h).(huffmanNode), heap.Pop(h).(huffmanNode)
+		parent := len(parents)
+		parents = append(parents, -1)
+		parents[a.index], parents[b.index] = parent, parent
+		heap.Push(h, huffmanNode{count: a.count + b.count, index: parent, symbol: -1})
+	}
+
+	longest := 0
+	leaf := 0
+	for symbol, count := range counts {
+		if count == 0 {
+			continue
+		}
+		depth := 0
+		for n := leaf; parents[n] >= 0; n = parents[n] {
+			depth++
+		}
+		lengths[symbol] = uint8(mathutil.Min(depth, 255))
+		longest = mathutil.Max(longest, depth)
+		leaf++
+	}
+	return lengths, longest
+}
+
+type huffmanNode struct {
+	count  uint32
+	index  int
+	symbol int
+}
+
+// huffmanHeap is a min-heap of nodes by count, breaking ties by index so that trees are
+// deterministic.
+type huffmanHeap []huffmanNode
+
+func (h huffmanHeap) Len() int { return len(h) }
+func (h huffmanHeap) Less(i, j int) bool {
+	if h[i].count != h[j].count {
+		return h[i].count < h[j].count
+	}
+	return h[i].index < h[j].index
+}
+func (h huffmanHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+func (h *huffmanHeap) Push(x any)   { *h = append(*h, x.(huffmanNode)) }
+func (h *huffmanHeap) Pop() any {
+	old := *h
+	n := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return n
+}
+
+// bitWriter packs values least significant bit first, as VP8L expects.
+type bitWriter struct {
+	buf   []byte
+	acc   uint64
+	nBits uint
+}
+
+func (bw *bitWriter) write(v uint32, n uint) {
+	bw.acc |= uint64(v) << bw.nBits
+	bw.nBits += n
+	for bw.nBits >= 8 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc >>= 8
+		bw.nBits -= 8
+	}
+}
+
+// bytes flushes any partial byte and returns everything written.
+func (bw *bitWriter) bytes() []byte {
+	if bw.nBits > 0 {
+		bw.buf = append(bw.buf, byte(bw.acc))
+		bw.acc, bw.nBits = 0, 0
+	}
+	return bw.buf
+}
@@ -0,0 +1,112 @@
+package imageio
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// webpTestImage draws translucent overlapping gradients, roughly what the evolved
+// images look like.
+func webpTestImage(width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			c := color.NRGBA{R: uint8(x * 255 / width), G: uint8(y * 255 / height), B: 90, A: 255}
+			if (x/7+y/5)%3 == 0 {
+				c.B = uint8(x * y)
+			}
+			if x > width/2 && y > height/2 {
+				c.A = uint8(100 + x%50)
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func TestSaveWebPLosslessRoundTrip(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {3, 2}, {37, 21}, {64, 48}} {
+		img := webpTestImage(size.X, size.Y)
+		path := filepath.Join(t.TempDir(), "result.webp")
+		if err := Save(path, img); err != nil {
+			t.Fatalf("%v: failed to save WebP: %v", size, err)
+		}
+
+		decoded, err := Read(path)
+		if err != nil {
+			t.Fatalf("%v: failed to decode WebP: %v", size, err)
+		}
+		if decoded.Bounds() != img.Bounds() {
+			t.Fatalf("%v: expected bounds %v, got %v", size, img.Bounds(), decoded.Bounds())
+		}
+		for y := 0; y < size.Y; y++ {
+			for x := 0; x < size.X; x++ {
+				want := img.NRGBAAt(x, y)
+				if got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA); got != want {
+					t.Fatalf("%v: pixel (%d,%d) is %v, want %v", size, x, y, got, want)
+				}
+			}
+		}
+	}
+}
+
+// randomWebPImage mixes noise, flat runs and repeated rows, so that both literal pixels
+// and backward references get encoded, with some fully transparent pixels.
+func randomWebPImage(rng *rand.Rand, width, height int) *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, width, height))
+	var c color.NRGBA
+	for y := 0; y < height; y++ {
+		if y > 0 && rng.Intn(4) == 0 {
+			copy(img.Pix[y*img.Stride:(y+1)*img.Stride], img.Pix[(y-1)*img.Stride:y*img.Stride])
+			continue
+		}
+		for x := 0; x < width; x++ {
+			if x == 0 || rng.Intn(3) == 0 {
+				c = color.NRGBA{R: uint8(rng.Intn(256)), G: uint8(rng.Intn(256)), B: uint8(rng.Intn(256)), A: uint8(rng.Intn(256))}
+				if rng.Intn(8) == 0 {
+					c.A = 0
+				}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func FuzzWebPRoundTrip(f *testing.F) {
+	f.Add(uint16(1), uint16(1), int64(1))
+	f.Add(uint16(37), uint16(21), int64(2))
+	f.Add(uint16(130), uint16(3), int64(3))
+	f.Add(uint16(64), uint16(90), int64(4))
+	f.Add(uint16(5), uint16(200), int64(5))
+	f.Fuzz(func(t *testing.T, width, height uint16, seed int64) {
+		width, height = width%300+1, height%300+1
+		img := randomWebPImage(rand.New(rand.NewSource(seed)), int(width), int(height))
+
+		var buf bytes.Buffer
+		if err := encodeWebP(&buf, img); err != nil {
+			t.Fatalf("%dx%d: failed to encode: %v", width, height, err)
+		}
+		decoded, err := webp.Decode(&buf)
+		if err != nil {
+			t.Fatalf("%dx%d: failed to decode: %v", width, height, err)
+		}
+		if decoded.Bounds() != img.Bounds() {
+			t.Fatalf("%dx%d: got bounds %v", width, height, decoded.Bounds())
+		}
+		for y := 0; y < int(height); y++ {
+			for x := 0; x < int(width); x++ {
+				want := img.NRGBAAt(x, y)
+				if got := color.NRGBAModel.Convert(decoded.At(x, y)).(color.NRGBA); got != want {
+					t.Fatalf("%dx%d: pixel (%d,%d) is %v, want %v", width, height, x, y, got, want)
+				}
+			}
+		}
+	})
+}
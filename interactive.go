@@ -0,0 +1,33 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// stdin, promptOutput and stdinIsTerminal are variables so that tests can script the
+// interactive prompt.
+var (
+	stdin        io.Reader = os.Stdin
+	promptOutput io.Writer = os.Stderr
+
+	stdinIsTerminal = func() bool {
+		info, err := os.Stdin.Stat()
+		return err == nil && info.Mode()&os.ModeCharDevice != 0
+	}
+)
+
+// confirmContinue asks whether to run n more generations after reaching generation gen
+// and reads the answer from in. Anything but y or yes declines, as does the end of input.
+func confirmContinue(in *bufio.Reader, out io.Writer, gen, n int) bool {
+	fmt.Fprintf(out, "Reached generation %d. Continue for %d more generations? [y/N] ", gen, n)
+	answer, _ := in.ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// scriptStdin makes the interactive prompt read input as if typed at a terminal, and
+// returns the buffer its questions are written to.
+func scriptStdin(t *testing.T, input string) *bytes.Buffer {
+	t.Helper()
+	oldStdin, oldOutput, oldIsTerminal := stdin, promptOutput, stdinIsTerminal
+	t.Cleanup(func() { stdin, promptOutput, stdinIsTerminal = oldStdin, oldOutput, oldIsTerminal })
+
+	var prompts bytes.Buffer
+	stdin, promptOutput = strings.NewReader(input), &prompts
+	stdinIsTerminal = func() bool { return true }
+	return &prompts
+}
+
+func TestConfirmContinue(t *testing.T) {
+	for input, want := range map[string]bool{"y\n": true, " YES \n": true, "n\n": false, "\n": false, "": false} {
+		var out bytes.Buffer
+		if got := confirmContinue(bufio.NewReader(strings.NewReader(input)), &out, 10, 5); got != want {
+			t.Errorf("Answer %q: expected %t, got %t", input, want, got)
+		}
+		if !strings.Contains(out.String(), "Continue for 5 more generations? [y/N]") {
+			t.Errorf("Unexpected prompt %q", out.String())
+		}
+	}
+}
+
+func TestRunInteractiveExtendsRun(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.Generations = defaultProgressUpdateFrequency
+	cfg.Interactive = true
+	prompts := scriptStdin(t, "y\nn\n")
+
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if n := strings.Count(prompts.String(), "[y/N]"); n != 2 {
+		t.Errorf("Expected to be asked twice, got %d prompts: %q", n, prompts.String())
+	}
+	// Snapshot numbering carries on through the extension
+	last := filepath.Join(cfg.OutDir, "best_gen_200.png")
+	if _, err := os.Stat(last); err != nil {
+		t.Errorf("Expected a snapshot from the extended generations: %v", err)
+	}
+}
+
+func TestRunInteractiveNeedsTerminal(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.Interactive = true
+	prompts := scriptStdin(t, "y\n")
+	stdinIsTerminal = func() bool { return false }
+
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if prompts.Len() != 0 {
+		t.Errorf("Expected no prompt without a terminal, got %q", prompts.String())
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"log"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+)
+
+// logLevel is how much progress run logs. Errors and warnings are logged at every level.
+type logLevel int
+
+const (
+	levelQuiet logLevel = iota
+	levelNormal
+	levelVerbose
+)
+
+// verbosity is the level of the current run, set from its config.
+var verbosity = levelNormal
+
+// setVerbosity sets verbosity from the -quiet and -verbose flags.
+func setVerbosity(cfg *config.Config) {
+	verbosity = levelNormal
+	if cfg.Quiet {
+		verbosity = levelQuiet
+	} else if cfg.Verbose {
+		verbosity = levelVerbose
+	}
+}
+
+// infof logs progress that -quiet suppresses.
+func infof(format string, args ...any) {
+	if verbosity >= levelNormal {
+		log.Printf(format, args...)
+	}
+}
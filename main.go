@@ -1,13 +1,15 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
 	"fmt"
-
+	"image"
+	"io"
 	"log"
-	"net/http"
-	_ "net/http/pprof"
 	"os"
 	"path/filepath"
+	"sort"
 	"time"
 
 	"github.com/bishal0602/chaotic-canvas/config"
@@ -16,27 +18,85 @@ import (
 )
 
 const (
-	compressedImageDimension       int    = 540
-	defaultProgressUpdateFrequency int    = 100
-	pprofAddr                      string = "localhost:6060"
+	compressedImageDimension       int = 540
+	defaultProgressUpdateFrequency int = 100
+	snapshotBufferSize             int = 4
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "compare" {
+		if err := runCompare(os.Args[2:], os.Stdout); err != nil {
+			log.Fatalf("Error comparing images: %v\n", err)
+		}
+		return
+	}
+
 	cfg, err := config.Load()
 	if err != nil {
 		log.Fatalf("Error loading config: %v\n", err)
 	}
+	if cfg.DumpConfig {
+		if err := dumpConfig(os.Stdout, cfg); err != nil {
+			log.Fatalf("Error dumping config: %v\n", err)
+		}
+		return
+	}
+	if err := run(cfg); err != nil {
+		log.Fatal(err)
+	}
+}
 
+// dumpConfig writes cfg as indented JSON with absolute paths, to record exactly what a
+// run would use.
+func dumpConfig(w io.Writer, cfg *config.Config) error {
+	resolved, err := cfg.AbsolutePaths()
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(resolved, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", data)
+	return err
+}
+
+// run evolves an image as described by cfg and writes the results to cfg.OutDir.
+func run(cfg *config.Config) error {
+	setVerbosity(cfg)
 	if cfg.EnablePprof {
-		go func() {
-			log.Printf("starting pprof on http://%s/debug/pprof", pprofAddr)
-			if err := http.ListenAndServe(pprofAddr, nil); err != nil {
-				log.Fatalf("pprof server failed to start %v", err)
-			}
-		}()
+		listener, err := startPprofServer(cfg.PprofAddr)
+		if err != nil {
+			return fmt.Errorf("pprof server failed to start: %w", err)
+		}
+		defer listener.Close()
+		infof("starting pprof on http://%s/debug/pprof", listener.Addr())
+	}
+
+	// One preview serves every target, so an open browser follows batch and -all-frames runs
+	var hub *previewHub
+	if cfg.ServeAddr != "" {
+		hub = newPreviewHub()
+		defer hub.close()
+		listener, err := startPreviewServer(cfg.ServeAddr, hub)
+		if err != nil {
+			return fmt.Errorf("preview server failed to start: %w", err)
+		}
+		defer listener.Close()
+		infof("serving live preview on http://%s", listener.Addr())
 	}
 
-	log.Printf(`Starting image evolution with:
+	if cfg.TargetDir != "" {
+		return runBatch(cfg, hub)
+	}
+	return evolveFile(cfg, hub)
+}
+
+// evolveFile evolves the image at cfg.TargetImagePath, or each of its frames with
+// -all-frames, and writes the results to cfg.OutDir. Snapshots are also published to hub if
+// it isn't nil.
+func evolveFile(cfg *config.Config, hub *previewHub) error {
+	infof(`Starting image evolution with:
 - Target image: %s
 - Output Directory: %s
 - Population size: %d
@@ -47,50 +107,460 @@ func main() {
 		cfg.TargetImagePath, cfg.OutDir, cfg.PopulationSize, cfg.Generations, cfg.MutationRate, cfg.TournamentSize, !cfg.NoCompress,
 	)
 
-	img, err := imageio.Read(cfg.TargetImagePath)
+	frames, err := imageio.ReadAll(cfg.TargetImagePath)
+	if err != nil {
+		return fmt.Errorf("error reading target image: %w", err)
+	}
+	if !cfg.AllFrames {
+		if cfg.Frame >= len(frames) {
+			return fmt.Errorf("frame %d requested but %s has %d frame(s)", cfg.Frame, cfg.TargetImagePath, len(frames))
+		}
+		return evolveTarget(cfg, frames[cfg.Frame], cfg.OutDir, cfg.FramesDir, hub)
+	}
+
+	infof("Evolving %d frames separately\n", len(frames))
+	for i, frame := range frames {
+		subdir := fmt.Sprintf("frame_%d", i)
+		outDir := filepath.Join(cfg.OutDir, subdir)
+		framesDir := ""
+		if cfg.FramesDir != "" {
+			framesDir = filepath.Join(cfg.FramesDir, subdir)
+		}
+		infof("Frame %d of %d, writing to %s\n", i+1, len(frames), outDir)
+		if err := evolveTarget(cfg, frame, outDir, framesDir, hub); err != nil {
+			return fmt.Errorf("frame %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// evolveTarget evolves an image towards img and writes the results to outDir, and
+// the snapshots as sequential video frames to framesDir if it is set, and to hub if it
+// isn't nil.
+func evolveTarget(cfg *config.Config, img image.Image, outDir, framesDir string, hub *previewHub) error {
+	originalSize := img.Bounds().Size()
+	img, err := prepareTarget(cfg, img)
+	if err != nil {
+		return err
+	}
+
+	popSchedule, err := genetic.ParsePopulationSchedule(cfg.PopSchedule)
 	if err != nil {
-		log.Fatalf("error reading target image: %v", err)
+		return fmt.Errorf("error parsing population schedule: %w", err)
 	}
-	if !cfg.NoCompress {
-		img = imageio.Resize(img, compressedImageDimension)
+	// A schedule that grows the population needs the memory of its largest size
+	estimate := estimateRun(img.Bounds().Dx(), img.Bounds().Dy(), max(cfg.PopulationSize, popSchedule.MaxSize()), cfg.Generations)
+	infof("Estimated %s\n", estimate)
+	if err := estimate.check(cfg.NoCompress); err != nil {
+		if cfg.Strict {
+			return fmt.Errorf("refusing to start: %w", err)
+		}
+		log.Printf("Warning: %v\n", err)
+	}
+	if cfg.ValidateTarget {
+		if err := checkTarget(img); err != nil {
+			log.Printf("Warning: %v\n", err)
+		}
 	}
 	// Create output directory for images
-	if err := os.MkdirAll(cfg.OutDir, 0755); err != nil {
-		log.Fatalf("error creating output directory: %v", err)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return fmt.Errorf("error creating output directory: %w", err)
+	}
+	if framesDir != "" {
+		if err := os.MkdirAll(framesDir, 0755); err != nil {
+			return fmt.Errorf("error creating frames directory: %w", err)
+		}
 	}
 
-	recv := make(chan genetic.ImageResult)
-	go func() {
-		for result := range recv {
-			outPath := filepath.Join(cfg.OutDir, fmt.Sprintf("best_gen_%d.png", result.Generation))
-			if err := imageio.Save(outPath, result.Img); err != nil {
-				log.Printf("Error saving image (gen %d): %v\n", result.Generation, err)
-			} else {
-				log.Printf("Generation %d - Best fitness: %.2f - Mutation Rate: %.2f", result.Generation, result.Fitness, result.MutationRate)
+	snapshotCompression, err := imageio.ParseCompressionLevel(cfg.SnapshotCompression)
+	if err != nil {
+		return fmt.Errorf("error parsing snapshot compression: %w", err)
+	}
+	output := snapshotOutput{
+		dir: outDir,
+		options: imageio.SaveOptions{
+			CompressionLevel: snapshotCompression,
+			Paletted:         cfg.SnapshotPalette,
+		},
+		ext:         "." + cfg.OutputFormat,
+		previewSize: cfg.PreviewScale,
+		rolling:     cfg.RollingOutput,
+		keepHistory: cfg.KeepHistory,
+		dumpShapes:  cfg.DumpShapes,
+		template:    cfg.FilenameTemplate,
+		genDigits:   generationDigits(cfg.Generations),
+		framesDir:   framesDir,
+	}
+
+	backgroundInit, err := genetic.ParseBackgroundInit(cfg.BackgroundInit)
+	if err != nil {
+		return fmt.Errorf("error parsing background init: %w", err)
+	}
+	initColors, err := genetic.ParseInitColors(cfg.InitColors)
+	if err != nil {
+		return fmt.Errorf("error parsing init colors: %w", err)
+	}
+	crossoverWeights, err := genetic.ParseCrossoverWeights(cfg.CrossoverWeights)
+	if err != nil {
+		return fmt.Errorf("error parsing crossover weights: %w", err)
+	}
+	var reportMetric genetic.Metric
+	if cfg.ReportMetric != "" {
+		if reportMetric, err = genetic.ParseMetric(cfg.ReportMetric); err != nil {
+			return fmt.Errorf("error parsing report metric: %w", err)
+		}
+	}
+	distance, err := genetic.ParseDistance(cfg.Distance)
+	if err != nil {
+		return fmt.Errorf("error parsing distance: %w", err)
+	}
+	seedMix, err := genetic.ParseSeedMix(cfg.SeedMix)
+	if err != nil {
+		return fmt.Errorf("error parsing seed mix: %w", err)
+	}
+	shapes := cfg.InitShapes()
+	if shapes.FillRule, err = genetic.ParseFillRule(cfg.FillRule); err != nil {
+		return fmt.Errorf("error parsing fill rule: %w", err)
+	}
 
+	opts := []genetic.Option{
+		genetic.WithBackgroundInit(backgroundInit),
+		genetic.WithInitColors(initColors),
+		genetic.WithInitShapes(shapes),
+		genetic.WithContrastWeight(cfg.ContrastWeight),
+		genetic.WithHistogramWeight(cfg.HistogramWeight),
+		genetic.WithPyramidLevels(cfg.PyramidLevels),
+		genetic.WithFitnessDeadband(cfg.FitnessDeadband),
+		genetic.WithDistance(distance),
+		genetic.WithSeedMix(seedMix),
+		genetic.WithFitnessSample(cfg.FitnessSample),
+		genetic.WithFixedShapes(cfg.FixedShapes),
+	}
+	if cfg.Seed != 0 {
+		opts = append(opts, genetic.WithSeed(cfg.Seed))
+	}
+	var seed image.Image
+	if cfg.SeedImagePath != "" {
+		if seed, err = loadMatchingImage("seed image", cfg.SeedImagePath, img, cfg.AutoResize); err != nil {
+			return err
+		}
+	}
+	if cfg.PaletteFrom != "" {
+		palette, err := imageio.Read(cfg.PaletteFrom)
+		if err != nil {
+			return fmt.Errorf("error reading palette image: %w", err)
+		}
+		opts = append(opts, genetic.WithPaletteImage(palette))
+	}
+	var avoid image.Image
+	if cfg.AvoidImagePath != "" {
+		if avoid, err = loadMatchingImage("avoid image", cfg.AvoidImagePath, img, cfg.AutoResize); err != nil {
+			return err
+		}
+	}
+	if cfg.Freeze != "" {
+		frozen, err := frozenRegion(cfg, originalSize, img.Bounds().Size())
+		if err != nil {
+			return err
+		}
+		infof("Freezing %v of the %dx%d target\n", frozen, img.Bounds().Dx(), img.Bounds().Dy())
+	}
+	if cfg.MaxMemoryMB > 0 {
+		opts = append(opts, genetic.WithMemoryLimit(uint64(cfg.MaxMemoryMB)<<20))
+	}
+	// targetOptions returns the options whose images and regions have to line up with the
+	// target, scaled to target: img itself, or the downscaled copy autotune's trials evolve
+	targetOptions := func(target image.Image) ([]genetic.Option, error) {
+		var opts []genetic.Option
+		if seed != nil {
+			opts = append(opts, genetic.WithSeedImage(resizeToMatch(seed, target), cfg.SeedShapesCount))
+		}
+		if avoid != nil {
+			opts = append(opts, genetic.WithAvoidImage(resizeToMatch(avoid, target), cfg.AvoidWeight))
+		}
+		if cfg.Freeze != "" {
+			frozen, err := frozenRegion(cfg, originalSize, target.Bounds().Size())
+			if err != nil {
+				return nil, err
 			}
+			opts = append(opts, genetic.WithFrozenRegion(frozen))
+		}
+		return opts, nil
+	}
+	configure := func(ga *genetic.GeneticAlgorithm) {
+		if cfg.FixedMutation {
+			ga.MutationStrategy = genetic.NewFixedMutationStrategy(ga.MutationRate)
 		}
-	}()
+		ga.MutationStrength = cfg.MutationStrength
+		ga.MaxFillsPerGeneration = cfg.MaxFills
+		ga.GuidedMutation = cfg.GuidedMutation
+		ga.FitnessSharingRadius = cfg.SharingRadius
+		ga.RestartAfter = cfg.RestartAfter
+		ga.RestartBestOnly = cfg.RestartBestOnly
+		if cfg.FixedStrength {
+			ga.StrengthStrategy = genetic.NewFixedMutationStrategy(cfg.MutationStrength)
+		}
+		ga.WarmupGenerations = cfg.Warmup
+		ga.WarmupMutationRate = cfg.WarmupRate
+		ga.PatchSize = cfg.PatchSize
+		ga.PatchSwapProbability = cfg.PatchSwapProbability
+		ga.CrossoverWeights = crossoverWeights
+		ga.CrossoverPoints = cfg.CrossoverPoints
+		ga.GaussianNoiseScale = cfg.GaussianNoiseScale
+		ga.BlendAlphaSpread = cfg.BlendAlphaSpread
+		ga.EliteSelectProbability = cfg.EliteSelectProbability
+		ga.EliteSelectCount = cfg.EliteSelectCount
+		ga.DebugInvariants = cfg.DebugInvariants
+	}
 
-	algorithm, err := genetic.NewGeneticAlgorithm(img, cfg.PopulationSize, cfg.Generations, cfg.MutationRate, cfg.TournamentSize)
+	popSize, mutationRate := cfg.PopulationSize, cfg.MutationRate
+	if cfg.Autotune {
+		infof("Autotuning population size and mutation rate...\n")
+		chosen, trials, err := autotune(img, defaultTuneGrid(), autotuneEvaluationBudget, cfg.TournamentSize, opts, targetOptions, configure)
+		if err != nil {
+			return fmt.Errorf("error autotuning: %w", err)
+		}
+		for _, trial := range trials {
+			infof("- pop %d, mut %.2f: %.1f%% improvement\n", trial.PopulationSize, trial.MutationRate, trial.Improvement*100)
+		}
+		infof("Autotune chose population size %d and mutation rate %.2f\n", chosen.PopulationSize, chosen.MutationRate)
+		popSize, mutationRate = chosen.PopulationSize, chosen.MutationRate
+	}
+
+	sizedOpts, err := targetOptions(img)
+	if err != nil {
+		return err
+	}
+	// Like the generation-based settings below, the schedule only applies to the real run
+	sizedOpts = append(sizedOpts, genetic.WithPopulationSchedule(popSchedule))
+	algorithm, err := genetic.NewGeneticAlgorithm(img, popSize, cfg.Generations, mutationRate, cfg.TournamentSize, append(opts, sizedOpts...)...)
 	if err != nil {
-		log.Fatalf("Error initializing genetic algorithm: %v\n", err)
+		return fmt.Errorf("error initializing genetic algorithm: %w", err)
+	}
+	infof("Using seed %d\n", algorithm.Seed())
+	defer releaseAlgorithm(algorithm, cfg.MaxIdleMemoryMB)
+	configure(algorithm)
+	// Autotune trials are too short for generation-based settings, so they only apply to the real run
+	algorithm.LockPaletteAt = cfg.LockPaletteAt
+	algorithm.SnapshotMinImprovement = cfg.SnapshotOnImprovement
+	algorithm.SnapshotMaxInterval = cfg.SnapshotMaxInterval
+	algorithm.ReportMetric = reportMetric
+
+	stopCPUProfile := func() {}
+	if cfg.CPUProfilePath != "" {
+		stopCPUProfile, err = startCPUProfile(cfg.CPUProfilePath)
+		if err != nil {
+			return fmt.Errorf("error starting CPU profile: %w", err)
+		}
+	}
+
+	// evolve runs the algorithm up to its generation cap. recv is buffered and drained
+	// by a coalescing writer so slow disk I/O never backpressures the evolution loop.
+	var elapsed time.Duration
+	var dropped int
+	// animation collects the saved snapshots for -apng
+	animation := newAnimationFrames(maxAnimationFrames)
+	evolve := func() (*genetic.Individual, error) {
+		recv := make(chan genetic.ImageResult, snapshotBufferSize)
+		var snapshots <-chan genetic.ImageResult = recv
+		if hub != nil {
+			snapshots = hub.tee(recv)
+		}
+		writer := newSnapshotWriter(snapshots, func(result genetic.ImageResult) error {
+			if err := output.save(result); err != nil {
+				return err
+			}
+			if cfg.APNG {
+				animation.add(result.Img)
+			}
+			if reportMetric != nil {
+				infof("Generation %d - Best fitness: %.2f - %s: %.4f - Mutation Rate: %.2f - %.1f gen/s", result.Generation, result.Fitness, cfg.ReportMetric, result.Report, result.MutationRate, result.GenPerSec)
+			} else {
+				infof("Generation %d - Best fitness: %.2f - Mutation Rate: %.2f - %.1f gen/s", result.Generation, result.Fitness, result.MutationRate, result.GenPerSec)
+			}
+			if verbosity >= levelVerbose {
+				logOperatorStats(algorithm.Stats())
+			}
+			return nil
+		}, func(result genetic.ImageResult, err error) {
+			log.Printf("Error saving image (gen %d): %v\n", result.Generation, err)
+		})
+
+		// Run closes recv, so the writer finishes even when Run fails
+		startTime := time.Now()
+		best, err := algorithm.Run(recv, defaultProgressUpdateFrequency)
+		elapsed += time.Since(startTime)
+		dropped += writer.Wait()
+		return best, err
 	}
 
-	startTime := time.Now()
-	bestIndividual, err := algorithm.Run(recv, defaultProgressUpdateFrequency)
+	bestIndividual, err := evolve()
+	if err == nil && cfg.Interactive && stdinIsTerminal() {
+		// Each extension continues from the current population, so the run keeps its progress
+		in := bufio.NewReader(stdin)
+		for err == nil && confirmContinue(in, promptOutput, algorithm.Generations, cfg.Generations) {
+			algorithm.Generations += cfg.Generations
+			if cfg.ResumeRate > 0 {
+				// Shake up a converged run instead of carrying on with its low rate
+				if cfg.FixedMutation {
+					algorithm.MutationStrategy = genetic.NewFixedMutationStrategy(cfg.ResumeRate)
+				}
+				err = algorithm.ResetMutationRate(cfg.ResumeRate)
+			}
+			if err == nil {
+				bestIndividual, err = evolve()
+			}
+		}
+	}
+	stopCPUProfile()
 	if err != nil {
-		log.Fatalf("Error running genetic algorithm: %v\n", err)
+		return fmt.Errorf("error running genetic algorithm: %w", err)
+	}
+
+	if cfg.MemProfilePath != "" {
+		if err := writeMemProfile(cfg.MemProfilePath); err != nil {
+			log.Printf("Error writing heap profile: %v\n", err)
+		}
 	}
-	elapsed := time.Since(startTime)
+	if dropped > 0 {
+		infof("Skipped %d snapshots while the writer was busy\n", dropped)
+	}
+	logOperatorStats(algorithm.Stats())
 
 	// Save the final best individual
-	outPath := filepath.Join(cfg.OutDir, "final_result.png")
-	if err := imageio.Save(outPath, bestIndividual.Image); err != nil {
-		log.Fatalf("Error saving final image: %v\n", err)
+	// Post-processing only touches the saved result, never the evolving population
+	finalImg := bestIndividual.Image
+	if cfg.Smooth > 0 {
+		finalImg = imageio.Smooth(finalImg, cfg.Smooth)
+	}
+	if cfg.Dither {
+		finalImg = imageio.Dither(finalImg)
+	}
+	outPath := filepath.Join(outDir, "final_result."+cfg.OutputFormat)
+	colorModel, err := imageio.ParseColorModel(cfg.ColorModel)
+	if err != nil {
+		return err
+	}
+	finalOptions := imageio.SaveOptions{ColorModel: colorModel}
+	if err := imageio.SaveWithOptions(outPath, finalImg, finalOptions); err != nil {
+		return fmt.Errorf("error saving final image: %w", err)
+	}
+
+	if keep := cfg.KeepBestN; keep > 0 {
+		if keep > len(algorithm.Population) {
+			log.Printf("Warning: keep-best-n is %d but the final population has %d individuals; saving all of them\n", keep, len(algorithm.Population))
+			keep = len(algorithm.Population)
+		}
+		if err := saveTopIndividuals(outDir, "."+cfg.OutputFormat, algorithm.Population, keep); err != nil {
+			log.Printf("Error saving top individuals: %v\n", err)
+		} else {
+			infof("Top %d individuals saved to: %s\n", keep, outDir)
+		}
+	}
+
+	if cfg.Compare {
+		comparePath := filepath.Join(outDir, "comparison.png")
+		if err := saveComparison(comparePath, finalImg, img); err != nil {
+			log.Printf("Error saving comparison: %v\n", err)
+		} else {
+			infof("Comparison saved to: %s\n", comparePath)
+		}
+	}
+
+	if cfg.APNG {
+		animationPath := filepath.Join(outDir, animationName)
+		if err := imageio.WriteAPNG(animationPath, append(animation.frames, finalImg), animationFrameDelay); err != nil {
+			log.Printf("Error saving animation: %v\n", err)
+		} else {
+			infof("Animation of %d frames saved to: %s\n", len(animation.frames)+1, animationPath)
+		}
+	}
+
+	if cfg.Plot {
+		gens := make([]int, len(algorithm.History))
+		best := make([]float64, len(algorithm.History))
+		avg := make([]float64, len(algorithm.History))
+		for i, stats := range algorithm.History {
+			gens[i], best[i], avg[i] = stats.Generation, stats.BestFitness, stats.AvgFitness
+		}
+		plotPath := filepath.Join(outDir, "fitness_plot.png")
+		if err := imageio.PlotFitness(plotPath, gens, best, avg); err != nil {
+			log.Printf("Error saving fitness plot: %v\n", err)
+		} else {
+			infof("Fitness plot saved to: %s\n", plotPath)
+		}
 	}
 
-	log.Printf("Evolution completed in %v\n", elapsed)
-	log.Printf("Final fitness: %.2f\n", bestIndividual.Fitness)
-	log.Printf("Final image saved to: %s\n", outPath)
+	// The report metric describes the saved result, including any post-processing
+	var finalReport float64
+	if reportMetric != nil {
+		finalReport = reportMetric(toRGBA(finalImg), toRGBA(img))
+	}
+
+	if cfg.Summary {
+		summaryPath := filepath.Join(outDir, "summary.json")
+		// The fitness settings can add penalties, so similarity uses the plain pixel fitness
+		plainFitness, err := compareImages(img, finalImg, false)
+		if err != nil {
+			return fmt.Errorf("error measuring similarity: %w", err)
+		}
+		genPerSec, slowest := generationTiming(algorithm.History)
+		summary := runSummary{
+			FinalFitness:             bestIndividual.Fitness,
+			Similarity:               similarity(plainFitness),
+			GenerationsRun:           len(algorithm.History),
+			GenerationCap:            algorithm.Generations,
+			ElapsedSeconds:           elapsed.Seconds(),
+			GenPerSec:                genPerSec,
+			SlowestGeneration:        slowest.Generation,
+			SlowestGenerationSeconds: slowest.Duration.Seconds(),
+			TargetWidth:              img.Bounds().Dx(),
+			TargetHeight:             img.Bounds().Dy(),
+			Seed:                     algorithm.Seed(),
+			PopulationSize:           popSize,
+			MutationRate:             mutationRate,
+			Parameters:               cfg,
+		}
+		if reportMetric != nil {
+			summary.Report = &metricReport{Metric: cfg.ReportMetric, Value: finalReport}
+		}
+		if err := saveSummary(summaryPath, summary); err != nil {
+			log.Printf("Error saving summary: %v\n", err)
+		} else {
+			infof("Summary saved to: %s\n", summaryPath)
+		}
+	}
+
+	infof("Evolution completed in %v\n", elapsed)
+	infof("Final fitness: %.2f\n", bestIndividual.Fitness)
+	if reportMetric != nil {
+		infof("Final %s: %.4f\n", cfg.ReportMetric, finalReport)
+	}
+	infof("Final image saved to: %s\n", outPath)
+	return nil
+}
+
+// logOperatorStats logs how often each operator's offspring survived and how much they
+// improved on their parents, to help tune the crossover weights and mutation settings.
+func logOperatorStats(stats genetic.Stats) {
+	for _, group := range []struct {
+		name string
+		ops  map[string]genetic.OperatorStats
+	}{{"Crossover", stats.Crossover}, {"Mutation", stats.Mutation}} {
+		names := make([]string, 0, len(group.ops))
+		for name := range group.ops {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		infof("%s operators:\n", group.name)
+		for _, name := range names {
+			op := group.ops[name]
+			if op.Offspring == 0 {
+				continue
+			}
+			infof("- %s: %d offspring, %.1f%% selected, average improvement %.3f\n",
+				name, op.Offspring, op.SelectionRate()*100, op.AverageImprovement())
+		}
+	}
 }
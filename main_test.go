@@ -0,0 +1,291 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/png"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+	"github.com/bishal0602/chaotic-canvas/targets"
+)
+
+// tinyConfig returns the flag defaults adjusted for a quick, reproducible run on a small
+// generated target.
+func tinyConfig(t *testing.T) *config.Config {
+	t.Helper()
+	dir := t.TempDir()
+	targetPath := filepath.Join(dir, "target.png")
+	target := targets.GradientTarget(16, 12, color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255})
+	if err := imageio.Save(targetPath, target); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := config.Defaults()
+	cfg.TargetImagePath = targetPath
+	cfg.OutDir = filepath.Join(dir, "out")
+	cfg.PopulationSize = 8
+	cfg.Generations = 5
+	cfg.TournamentSize = 2
+	cfg.Seed = 1
+	return cfg
+}
+
+func TestRunWritesFinalResult(t *testing.T) {
+	cfg := tinyConfig(t)
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	for _, name := range []string{"final_result.png", "best_gen_1.png"} {
+		if _, err := os.Stat(filepath.Join(cfg.OutDir, name)); err != nil {
+			t.Errorf("Expected %s in the output directory: %v", name, err)
+		}
+	}
+}
+
+func TestRunLogLevels(t *testing.T) {
+	defer log.SetOutput(os.Stderr)
+	logs := func(quiet, verbose bool) string {
+		cfg := tinyConfig(t)
+		cfg.Quiet, cfg.Verbose = quiet, verbose
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+		if err := run(cfg); err != nil {
+			t.Fatalf("run failed: %v", err)
+		}
+		return buf.String()
+	}
+
+	normal := logs(false, false)
+	if !strings.Contains(normal, "Generation 1 - Best fitness") {
+		t.Errorf("Expected generation lines by default, got:\n%s", normal)
+	}
+	if got := strings.Count(normal, "Mutation operators:"); got != 1 {
+		t.Errorf("Expected the operator statistics once by default, got %d times", got)
+	}
+
+	if quiet := logs(true, false); quiet != "" {
+		t.Errorf("Expected no output with -quiet, got:\n%s", quiet)
+	}
+
+	verbose := logs(false, true)
+	generations := strings.Count(verbose, "Best fitness:")
+	if got := strings.Count(verbose, "Mutation operators:"); got != generations+1 {
+		t.Errorf("Expected the operator statistics with each of %d generation lines and at the end, got %d times", generations, got)
+	}
+}
+
+func TestRunReturnsErrors(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.TargetImagePath = filepath.Join(t.TempDir(), "missing.png")
+	if err := run(cfg); err == nil {
+		t.Error("Expected an error for a missing target")
+	}
+
+	cfg = tinyConfig(t)
+	cfg.PatchSize = 0
+	if err := run(cfg); err == nil {
+		t.Error("Expected an error for an invalid operator setting")
+	}
+}
+
+func TestRunWebPOutput(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.OutputFormat = "webp"
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	for _, name := range []string{"final_result.webp", "best_gen_1.webp"} {
+		img, err := imageio.Read(filepath.Join(cfg.OutDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if size := img.Bounds().Size(); size != image.Pt(16, 12) {
+			t.Errorf("%s: expected 16x12, got %v", name, size)
+		}
+	}
+}
+
+func TestRunClampsKeepBestN(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.PopSchedule = "3:4"
+	cfg.KeepBestN = 6
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutDir, "best_4.png")); err != nil {
+		t.Errorf("Expected the whole shrunk population to be saved: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutDir, "best_5.png")); err == nil {
+		t.Errorf("Did not expect more individuals than the final population holds")
+	}
+}
+
+func TestRunAPNG(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.APNG = true
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	data, err := os.ReadFile(filepath.Join(cfg.OutDir, animationName))
+	if err != nil {
+		t.Fatalf("Expected an animation: %v", err)
+	}
+	// The snapshot of generation 1 and the final result
+	if frames := bytes.Count(data, []byte("fcTL")); frames != 2 {
+		t.Errorf("Expected 2 frames, got %d", frames)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Animation doesn't decode as a PNG: %v", err)
+	}
+	if size := img.Bounds().Size(); size != image.Pt(16, 12) {
+		t.Errorf("Expected 16x12 frames, got %v", size)
+	}
+}
+
+func TestRunPreviewScale(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.PreviewScale = 8
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	for name, want := range map[string]image.Point{
+		"best_gen_1.png":   image.Pt(8, 6),
+		"final_result.png": image.Pt(16, 12),
+	} {
+		img, err := imageio.Read(filepath.Join(cfg.OutDir, name))
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", name, err)
+		}
+		if size := img.Bounds().Size(); size != want {
+			t.Errorf("%s: expected %v, got %v", name, want, size)
+		}
+	}
+}
+
+func TestRunCrop(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.Crop = "3,2,10,7"
+	// The comparison reads the cropped target, whose bounds don't start at the origin
+	cfg.Compare = true
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	result, err := imageio.Read(filepath.Join(cfg.OutDir, "final_result.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if size := result.Bounds().Size(); size != image.Pt(10, 7) {
+		t.Errorf("Expected a 10x7 result matching the crop, got %v", size)
+	}
+
+	cfg = tinyConfig(t)
+	cfg.Crop = "10,0,10,7"
+	if err := run(cfg); err == nil {
+		t.Error("Expected an error for a crop past the 16x12 target's edge")
+	}
+}
+
+func TestRunGIFFrames(t *testing.T) {
+	cfg := tinyConfig(t)
+	pal := color.Palette{color.RGBA{R: 255, A: 255}, color.RGBA{B: 255, A: 255}}
+	anim := &gif.GIF{Delay: []int{10, 10}}
+	for idx := range pal {
+		frame := image.NewPaletted(image.Rect(0, 0, 8, 8), pal)
+		for i := range frame.Pix {
+			frame.Pix[i] = uint8(idx)
+		}
+		anim.Image = append(anim.Image, frame)
+	}
+	cfg.TargetImagePath = filepath.Join(t.TempDir(), "anim.gif")
+	file, err := os.Create(cfg.TargetImagePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := gif.EncodeAll(file, anim); err != nil {
+		t.Fatal(err)
+	}
+	file.Close()
+
+	cfg.Frame = 2
+	if err := run(cfg); err == nil {
+		t.Error("Expected an error for a frame past the end of the animation")
+	}
+
+	cfg.Frame = 1
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(cfg.OutDir, "final_result.png")); err != nil {
+		t.Errorf("Expected a final result for the selected frame: %v", err)
+	}
+
+	cfg.AllFrames = true
+	if err := run(cfg); err != nil {
+		t.Fatalf("run with all frames failed: %v", err)
+	}
+	for _, dir := range []string{"frame_0", "frame_1"} {
+		if _, err := os.Stat(filepath.Join(cfg.OutDir, dir, "final_result.png")); err != nil {
+			t.Errorf("Expected a final result in %s: %v", dir, err)
+		}
+	}
+}
+
+func TestDumpConfig(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.TargetImagePath = "examples/starry_night.png"
+	cfg.OutDir = "out"
+	cfg.DumpConfig = true
+	var buf bytes.Buffer
+	if err := dumpConfig(&buf, cfg); err != nil {
+		t.Fatalf("dumpConfig failed: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &fields); err != nil {
+		t.Fatalf("Dump isn't valid JSON: %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	resolved := map[string]string{
+		"TargetImagePath": filepath.Join(wd, "examples", "starry_night.png"),
+		"OutDir":          filepath.Join(wd, "out"),
+	}
+
+	// Round trip every other value through JSON the way the dump does
+	v := reflect.ValueOf(*cfg)
+	for i := 0; i < v.NumField(); i++ {
+		name := v.Type().Field(i).Name
+		got, ok := fields[name]
+		if name == "DumpConfig" {
+			if ok {
+				t.Error("Expected DumpConfig to be left out of the dump")
+			}
+			continue
+		}
+		if !ok {
+			t.Errorf("Dump is missing %s", name)
+			continue
+		}
+		want := any(resolved[name])
+		if _, isPath := resolved[name]; !isPath {
+			data, _ := json.Marshal(v.Field(i).Interface())
+			json.Unmarshal(data, &want)
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: expected %v, got %v", name, want, got)
+		}
+	}
+}
@@ -28,6 +28,18 @@ func Clamp[T Number](value, min, max T) T {
 	return value
 }
 
+// ClampUint8 converts a pixel value to uint8, truncating like a plain conversion but
+// saturating at 0 and 255 instead of wrapping around. NaN maps to 0.
+func ClampUint8(value float64) uint8 {
+	if !(value > 0) {
+		return 0
+	}
+	if value >= 255 {
+		return 255
+	}
+	return uint8(value)
+}
+
 func Abs[T Number](value T) T {
 	if value < 0 {
 		return -value
@@ -1,6 +1,7 @@
 package mathutil
 
 import (
+	"math"
 	"testing"
 )
 
@@ -20,6 +21,10 @@ func TestFloorPowerOfTen(t *testing.T) {
 		{987654321, 100000000},   // 100,000,000-999,999,999 → 100,000,000
 		{9876543210, 1000000000}, // 1,000,000,000-9,999,999,999 → 1,000,000,000
 		{-10, 1},                 // Negative numbers default to 1
+		{10, 10},                 // Exact powers map to themselves
+		{1000000, 1000000},       // Largest value in the lookup table
+		{9999999, 1000000},       // Last value answered by the lookup table
+		{10000000, 10000000},     // First value past it, answered by division
 	}
 
 	for _, tt := range tests {
@@ -29,3 +34,47 @@ func TestFloorPowerOfTen(t *testing.T) {
 		}
 	}
 }
+
+func TestClampUint8(t *testing.T) {
+	tests := []struct {
+		input    float64
+		expected uint8
+	}{
+		{255.3, 255},
+		{-0.2, 0},
+		{256, 255},
+		{-300, 0},
+		{0, 0},
+		{127.9, 127},
+		{254.99, 254},
+		{math.NaN(), 0},
+		{math.Inf(1), 255},
+	}
+
+	for _, test := range tests {
+		if result := ClampUint8(test.input); result != test.expected {
+			t.Errorf("ClampUint8(%v) = %d; expected %d", test.input, result, test.expected)
+		}
+	}
+}
+
+func TestMinMaxClampAbs(t *testing.T) {
+	if got := Min(3, -2); got != -2 {
+		t.Errorf("Min(3, -2) = %d; want -2", got)
+	}
+	if got := Max(1.5, 2.5); got != 2.5 {
+		t.Errorf("Max(1.5, 2.5) = %v; want 2.5", got)
+	}
+	clamps := []struct{ value, want int }{{-5, 0}, {0, 0}, {7, 7}, {10, 10}, {11, 10}}
+	for _, c := range clamps {
+		if got := Clamp(c.value, 0, 10); got != c.want {
+			t.Errorf("Clamp(%d, 0, 10) = %d; want %d", c.value, got, c.want)
+		}
+	}
+	if got := Abs(-4); got != 4 {
+		t.Errorf("Abs(-4) = %d; want 4", got)
+	}
+	if got := Abs(-0.5); got != 0.5 {
+		t.Errorf("Abs(-0.5) = %v; want 0.5", got)
+	}
+}
@@ -9,3 +9,41 @@ func RandomBetween(a, b int) int {
 	}
 	return rand.Intn(b-a+1) + a
 }
+
+// RandomBetweenR returns a random integer between a and b (inclusive) drawn from r.
+func RandomBetweenR(r *rand.Rand, a, b int) int {
+	if a > b {
+		a, b = b, a // Swap if a > b to avoid errors
+	}
+	return r.Intn(b-a+1) + a
+}
+
+// SplitMix64 is a small, fast rand.Source64. Unlike rand.NewSource it is cheap
+// to create, so a fresh seeded source can be made for every unit of parallel work.
+type SplitMix64 struct {
+	state uint64
+}
+
+// NewSplitMix64 returns a SplitMix64 source seeded with seed.
+func NewSplitMix64(seed int64) *SplitMix64 {
+	return &SplitMix64{state: uint64(seed)}
+}
+
+// Seed resets the source to seed.
+func (s *SplitMix64) Seed(seed int64) {
+	s.state = uint64(seed)
+}
+
+// Uint64 returns the next pseudo-random 64-bit value.
+func (s *SplitMix64) Uint64() uint64 {
+	s.state += 0x9E3779B97F4A7C15
+	z := s.state
+	z = (z ^ (z >> 30)) * 0xBF58476D1CE4E5B9
+	z = (z ^ (z >> 27)) * 0x94D049BB133111EB
+	return z ^ (z >> 31)
+}
+
+// Int63 returns the next pseudo-random non-negative 63-bit value.
+func (s *SplitMix64) Int63() int64 {
+	return int64(s.Uint64() >> 1)
+}
@@ -1,6 +1,7 @@
 package mathutil
 
 import (
+	"math/rand"
 	"testing"
 )
 
@@ -18,3 +19,16 @@ func TestRandomBetween(t *testing.T) {
 		}
 	}
 }
+
+func TestRandomBetweenRDeterministic(t *testing.T) {
+	r1, r2 := rand.New(rand.NewSource(42)), rand.New(rand.NewSource(42))
+	for i := 0; i < 100; i++ {
+		a, b := RandomBetweenR(r1, 20, -10), RandomBetweenR(r2, 20, -10)
+		if a != b {
+			t.Fatalf("Call %d: same seed produced %d and %d", i, a, b)
+		}
+		if a < -10 || a > 20 {
+			t.Fatalf("RandomBetweenR(20,-10) produced %d; out of range", a)
+		}
+	}
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+const (
+	frameNameFormat     = "frame_%06d"
+	topManifestName     = "best_manifest.csv"
+	rollingSnapshotName = "best"
+	currentSnapshotName = "current"
+	rollingShapesName   = "best.json"
+	animationName       = "evolution.png"
+	// animationFrameDelay is how long each frame of the -apng animation shows, in ms
+	animationFrameDelay = 100
+	// maxAnimationFrames caps the snapshots -apng holds in memory until the run ends;
+	// it must be even for animationFrames to keep them evenly spaced
+	maxAnimationFrames = 100
+	defaultImageExt    = ".png"
+)
+
+// snapshotOutput decides where progress snapshots are written.
+type snapshotOutput struct {
+	dir     string
+	options imageio.SaveOptions
+	// ext is the image file extension, which picks the format; empty means defaultImageExt
+	ext string
+	// previewSize, if positive, downscales snapshot images so their larger side fits it
+	previewSize int
+	// rolling overwrites best.png and current.png instead of writing best_gen_N.png files
+	rolling bool
+	// bestFitness is the fitness of the snapshot in best.png, once seenBest is set
+	bestFitness float64
+	seenBest    bool
+	// keepHistory also writes the numbered files in rolling mode
+	keepHistory bool
+	// dumpShapes writes the best individual's shape list as JSON next to each PNG
+	dumpShapes bool
+	// template names numbered snapshots, without extension; empty uses config.DefaultFilenameTemplate
+	template string
+	// genDigits is the width the generation is zero-padded to
+	genDigits int
+	// framesDir, if set, also receives every snapshot as frame_000001.png, frame_000002.png, ...
+	framesDir string
+	// frames counts the frames written to framesDir
+	frames int
+}
+
+// generationDigits returns the zero-padding width that makes snapshot names for every
+// generation of a run sort in generation order.
+func generationDigits(generations int) int {
+	return len(strconv.Itoa(generations))
+}
+
+// snapshotName returns the file name, without extension, of the snapshot for gen.
+func (o snapshotOutput) snapshotName(gen int) string {
+	template := o.template
+	if template == "" {
+		template = config.DefaultFilenameTemplate
+	}
+	return strings.ReplaceAll(template, config.GenerationPlaceholder, fmt.Sprintf("%0*d", o.genDigits, gen))
+}
+
+// imageExt returns the extension snapshot images are written with.
+func (o snapshotOutput) imageExt() string {
+	if o.ext == "" {
+		return defaultImageExt
+	}
+	return o.ext
+}
+
+// save writes result as configured. In rolling mode current.png always holds the latest
+// snapshot and best.png the fittest one so far. Both are replaced atomically so a file
+// watcher never sees a partially written image. It must not be called concurrently.
+func (o *snapshotOutput) save(result genetic.ImageResult) error {
+	img := result.Img
+	if o.previewSize > 0 {
+		img = imageio.Resize(img, o.previewSize)
+	}
+	if o.framesDir != "" {
+		// Frames are numbered by snapshot rather than generation so that they stay
+		// contiguous for video encoders, whatever the snapshot interval
+		path := filepath.Join(o.framesDir, fmt.Sprintf(frameNameFormat, o.frames+1)+o.imageExt())
+		if err := imageio.SaveWithOptions(path, img, o.options); err != nil {
+			return err
+		}
+		o.frames++
+	}
+	if !o.rolling || o.keepHistory {
+		name := o.snapshotName(result.Generation)
+		path := filepath.Join(o.dir, name+o.imageExt())
+		if err := imageio.SaveWithOptions(path, img, o.options); err != nil {
+			return err
+		}
+		if o.dumpShapes && result.Shapes != nil {
+			path := filepath.Join(o.dir, name+".json")
+			if err := saveShapes(path, result.Shapes); err != nil {
+				return err
+			}
+		}
+	}
+	if !o.rolling {
+		return nil
+	}
+	if err := o.replace(currentSnapshotName, img); err != nil {
+		return err
+	}
+	if o.seenBest && result.Fitness > o.bestFitness {
+		return nil
+	}
+	o.bestFitness, o.seenBest = result.Fitness, true
+	if o.dumpShapes && result.Shapes != nil {
+		path := filepath.Join(o.dir, rollingShapesName)
+		if err := saveShapes(path+".tmp", result.Shapes); err != nil {
+			return err
+		}
+		if err := os.Rename(path+".tmp", path); err != nil {
+			return err
+		}
+	}
+	return o.replace(rollingSnapshotName, img)
+}
+
+// replace atomically overwrites the image named name in the output directory.
+func (o snapshotOutput) replace(name string, img image.Image) error {
+	// The temporary file keeps the extension, which decides the format
+	path := filepath.Join(o.dir, name+o.imageExt())
+	tmp := filepath.Join(o.dir, name+".tmp"+o.imageExt())
+	if err := imageio.SaveWithOptions(tmp, img, o.options); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// animationFrames keeps an evenly spaced selection of at most limit snapshots for -apng,
+// so that memory stays bounded however long the run is. Once full, it drops every other
+// frame and from then on keeps only every other snapshot, which still spans the run.
+type animationFrames struct {
+	limit  int
+	frames []image.Image
+	// step keeps every step-th snapshot offered, counted by seen
+	step, seen int
+}
+
+func newAnimationFrames(limit int) *animationFrames {
+	return &animationFrames{limit: limit, step: 1}
+}
+
+// add offers the next snapshot.
+func (a *animationFrames) add(img image.Image) {
+	keep := a.seen%a.step == 0
+	a.seen++
+	if !keep {
+		return
+	}
+	if len(a.frames) == a.limit {
+		kept := a.frames[:0]
+		for i := 0; i < len(a.frames); i += 2 {
+			kept = append(kept, a.frames[i])
+		}
+		clear(a.frames[len(kept):])
+		a.frames, a.step = kept, a.step*2
+	}
+	a.frames = append(a.frames, img)
+}
+
+// saveShapes writes a shape list as indented JSON.
+func saveShapes(path string, shapes *genetic.ShapeList) error {
+	data, err := json.MarshalIndent(shapes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
+
+// saveComparison saves the result, the target and their difference heatmap side by side.
+func saveComparison(path string, result, target image.Image) error {
+	heatmap, err := imageio.DiffHeatmap(result, target)
+	if err != nil {
+		return err
+	}
+	combined, err := imageio.SideBySide(result, target, heatmap)
+	if err != nil {
+		return err
+	}
+	return imageio.Save(path, combined)
+}
+
+// saveTopIndividuals saves the first n individuals of the sorted population as
+// best_1<ext>..best_n<ext> and writes their fitness to a CSV manifest.
+func saveTopIndividuals(dir, ext string, population []*genetic.Individual, n int) error {
+	if n > len(population) {
+		return fmt.Errorf("cannot save %d individuals from a population of %d", n, len(population))
+	}
+
+	manifest, err := os.Create(filepath.Join(dir, topManifestName))
+	if err != nil {
+		return err
+	}
+	defer manifest.Close()
+
+	if _, err := fmt.Fprintln(manifest, "rank,file,fitness"); err != nil {
+		return err
+	}
+	for i, ind := range population[:n] {
+		name := fmt.Sprintf("best_%d%s", i+1, ext)
+		if err := imageio.Save(filepath.Join(dir, name), ind.Image); err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(manifest, "%d,%s,%f\n", i+1, name, ind.Fitness); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+func TestSaveTopIndividuals(t *testing.T) {
+	ga, err := genetic.NewGeneticAlgorithm(image.NewRGBA(image.Rect(0, 0, 12, 12)), 10, 5, 0.05, 3)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if _, err := ga.Run(make(chan genetic.ImageResult, ga.Generations), 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+
+	dir := t.TempDir()
+	const n = 4
+	if err := saveTopIndividuals(dir, ".png", ga.Population, n); err != nil {
+		t.Fatalf("saveTopIndividuals failed: %v", err)
+	}
+
+	for i := 1; i <= n; i++ {
+		if _, err := os.Stat(filepath.Join(dir, "best_"+strconv.Itoa(i)+".png")); err != nil {
+			t.Errorf("Expected best_%d.png: %v", i, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "best_"+strconv.Itoa(n+1)+".png")); err == nil {
+		t.Errorf("Did not expect best_%d.png", n+1)
+	}
+	webpDir := t.TempDir()
+	if err := saveTopIndividuals(webpDir, ".webp", ga.Population, 1); err != nil {
+		t.Fatalf("saveTopIndividuals failed for webp: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(webpDir, "best_1.webp")); err != nil {
+		t.Errorf("Expected best_1.webp: %v", err)
+	}
+
+	file, err := os.Open(filepath.Join(dir, topManifestName))
+	if err != nil {
+		t.Fatalf("Failed to open manifest: %v", err)
+	}
+	defer file.Close()
+	records, err := csv.NewReader(file).ReadAll()
+	if err != nil {
+		t.Fatalf("Failed to parse manifest: %v", err)
+	}
+	if len(records) != n+1 {
+		t.Fatalf("Expected %d manifest rows, got %d", n+1, len(records))
+	}
+	prev := -1.0
+	for _, record := range records[1:] {
+		fitness, err := strconv.ParseFloat(record[2], 64)
+		if err != nil {
+			t.Fatalf("Invalid fitness %q: %v", record[2], err)
+		}
+		if fitness < prev {
+			t.Errorf("Fitness decreased in rank order: %f after %f", fitness, prev)
+		}
+		prev = fitness
+	}
+}
+
+func TestSaveTopIndividualsTooMany(t *testing.T) {
+	population := []*genetic.Individual{genetic.NewIndividual(rand.New(rand.NewSource(1)), 4, 4)}
+	if err := saveTopIndividuals(t.TempDir(), ".png", population, 2); err == nil {
+		t.Errorf("Expected an error when asking for more individuals than the population holds")
+	}
+}
+
+func TestSnapshotOutputRolling(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	save := func(output snapshotOutput) []string {
+		for gen := 1; gen <= 3; gen++ {
+			if err := output.save(genetic.ImageResult{Img: img, Generation: gen}); err != nil {
+				t.Fatalf("save failed: %v", err)
+			}
+		}
+		entries, err := os.ReadDir(output.dir)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	rolling := save(snapshotOutput{dir: t.TempDir(), rolling: true})
+	if want := []string{rollingSnapshotName + defaultImageExt, currentSnapshotName + defaultImageExt}; !slices.Equal(rolling, want) {
+		t.Errorf("Rolling mode: expected only %v, got %v", want, rolling)
+	}
+
+	numbered := save(snapshotOutput{dir: t.TempDir()})
+	if len(numbered) != 3 {
+		t.Errorf("Default mode: expected 3 numbered snapshots, got %v", numbered)
+	}
+
+	both := save(snapshotOutput{dir: t.TempDir(), rolling: true, keepHistory: true})
+	if len(both) != 5 {
+		t.Errorf("Rolling with history: expected 3 numbered snapshots, %s and %s, got %v", rollingSnapshotName, currentSnapshotName, both)
+	}
+}
+
+func TestSnapshotOutputRollingKeepsBest(t *testing.T) {
+	output := snapshotOutput{dir: t.TempDir(), rolling: true}
+	good := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	worse := solidImage(4, 4, color.RGBA{255, 0, 0, 255})
+	if err := output.save(genetic.ImageResult{Img: good, Generation: 1, Fitness: 10}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if err := output.save(genetic.ImageResult{Img: worse, Generation: 2, Fitness: 20}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	for name, want := range map[string]image.Image{rollingSnapshotName: good, currentSnapshotName: worse} {
+		img, err := imageio.Read(filepath.Join(output.dir, name+defaultImageExt))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff, err := compareImages(img, want, false); err != nil || diff != 0 {
+			t.Errorf("%s%s doesn't hold the expected snapshot: difference %f, %v", name, defaultImageExt, diff, err)
+		}
+	}
+}
+
+func TestSnapshotOutputDumpShapes(t *testing.T) {
+	ind := genetic.NewIndividual(rand.New(rand.NewSource(1)), 20, 16)
+	shapes, _ := ind.ShapeList()
+	output := snapshotOutput{dir: t.TempDir(), dumpShapes: true}
+	if err := output.save(genetic.ImageResult{Img: ind.Image, Generation: 7, Shapes: shapes}); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(output.dir, "best_gen_7.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded genetic.ShapeList
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	fitness, err := compareImages(decoded.Rasterize(), ind.Image, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fitness > 1 {
+		t.Errorf("Rasterized shapes differ from the snapshot: fitness %f", fitness)
+	}
+}
+
+func TestSnapshotNamePadding(t *testing.T) {
+	tests := []struct {
+		generations int
+		want        string
+	}{
+		{9, "best_gen_7"},
+		{100, "best_gen_007"},
+		{10000, "best_gen_00007"},
+	}
+	for _, tt := range tests {
+		output := snapshotOutput{genDigits: generationDigits(tt.generations)}
+		if got := output.snapshotName(7); got != tt.want {
+			t.Errorf("%d generations: got %q, want %q", tt.generations, got, tt.want)
+		}
+	}
+
+	output := snapshotOutput{template: "frame-{gen}-best", genDigits: generationDigits(100)}
+	if got := output.snapshotName(42); got != "frame-042-best" {
+		t.Errorf("Custom template: got %q, want %q", got, "frame-042-best")
+	}
+}
+
+func TestSnapshotOutputFrames(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	output := snapshotOutput{dir: t.TempDir(), framesDir: t.TempDir()}
+	for _, gen := range []int{100, 200, 300, 1000} {
+		if err := output.save(genetic.ImageResult{Img: img, Generation: gen}); err != nil {
+			t.Fatalf("save failed: %v", err)
+		}
+	}
+
+	entries, err := os.ReadDir(output.framesDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 4 {
+		t.Fatalf("Expected 4 frames, got %d", len(entries))
+	}
+	for i, entry := range entries {
+		if want := fmt.Sprintf("frame_%06d.png", i+1); entry.Name() != want {
+			t.Errorf("Frame %d: got %s, want %s", i, entry.Name(), want)
+		}
+	}
+}
+
+func TestAnimationFramesStayEvenlySpaced(t *testing.T) {
+	animation := newAnimationFrames(10)
+	// Snapshot i is i+1 pixels wide, so the kept frames show which snapshots they were
+	const snapshots = 1000
+	for i := 0; i < snapshots; i++ {
+		animation.add(image.NewGray(image.Rect(0, 0, i+1, 1)))
+	}
+
+	if n := len(animation.frames); n > 10 || n < 5 {
+		t.Fatalf("Expected between 5 and 10 frames, got %d", n)
+	}
+	for k, frame := range animation.frames {
+		if got, want := frame.Bounds().Dx()-1, k*animation.step; got != want {
+			t.Errorf("Frame %d is snapshot %d, want %d", k, got, want)
+		}
+	}
+	if last := (len(animation.frames) - 1) * animation.step; last < snapshots-2*animation.step {
+		t.Errorf("Last frame is snapshot %d of %d, expected the frames to span the run", last, snapshots)
+	}
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"log"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/fogleman/gg"
+	"github.com/gorilla/websocket"
+)
+
+// previewPage renders the frames pushed over /ws.
+const previewPage = `<!DOCTYPE html>
+<html>
+<head><title>chaotic-canvas</title></head>
+<body style="background:#222;margin:0;display:flex;justify-content:center;align-items:center;height:100vh">
+<img id="frame" alt="waiting for the first frame">
+<script>
+const ws = new WebSocket((location.protocol === "https:" ? "wss://" : "ws://") + location.host + "/ws");
+ws.binaryType = "blob";
+const img = document.getElementById("frame");
+ws.onmessage = (e) => {
+	const url = URL.createObjectURL(e.data);
+	img.onload = () => URL.revokeObjectURL(url);
+	img.src = url;
+};
+</script>
+</body>
+</html>
+`
+
+var previewUpgrader = websocket.Upgrader{}
+
+// previewHub streams the best image of a run to WebSocket clients as PNG frames
+// annotated with the generation and fitness.
+//
+// Publishing never blocks the caller: frames are encoded on the hub's own goroutine,
+// and each client holds at most one unsent frame, so a slow client drops
+// intermediate frames instead of stalling the run or other clients.
+type previewHub struct {
+	mu      sync.Mutex
+	pending *genetic.ImageResult
+	latest  []byte
+	clients map[chan []byte]struct{}
+
+	wake      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+func newPreviewHub() *previewHub {
+	h := &previewHub{
+		clients: make(map[chan []byte]struct{}),
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go h.loop()
+	return h
+}
+
+// close stops encoding frames and disconnects every client. Results published afterwards
+// are dropped.
+func (h *previewHub) close() {
+	h.closeOnce.Do(func() { close(h.done) })
+}
+
+// tee publishes every result from in and forwards it to the returned channel, which is
+// closed once in is closed.
+func (h *previewHub) tee(in <-chan genetic.ImageResult) <-chan genetic.ImageResult {
+	out := make(chan genetic.ImageResult)
+	go func() {
+		defer close(out)
+		for result := range in {
+			h.publish(result)
+			out <- result
+		}
+	}()
+	return out
+}
+
+// publish queues result to be sent to the clients, replacing any result that hasn't been encoded yet.
+func (h *previewHub) publish(result genetic.ImageResult) {
+	h.mu.Lock()
+	h.pending = &result
+	h.mu.Unlock()
+	select {
+	case h.wake <- struct{}{}:
+	default:
+	}
+}
+
+// loop encodes pending results and broadcasts them until the hub is closed.
+func (h *previewHub) loop() {
+	for {
+		select {
+		case <-h.wake:
+		case <-h.done:
+			return
+		}
+		h.mu.Lock()
+		result := h.pending
+		h.pending = nil
+		h.mu.Unlock()
+		if result == nil {
+			continue
+		}
+
+		text := fmt.Sprintf("gen %d  fitness %.2f", result.Generation, result.Fitness)
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, annotate(result.Img, text)); err != nil {
+			log.Printf("Error encoding preview frame (gen %d): %v\n", result.Generation, err)
+			continue
+		}
+		h.broadcast(buf.Bytes())
+	}
+}
+
+// broadcast sends frame to every client, replacing any frame a client hasn't sent yet.
+func (h *previewHub) broadcast(frame []byte) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.latest = frame
+	for frames := range h.clients {
+		select {
+		case <-frames:
+		default:
+		}
+		frames <- frame
+	}
+}
+
+// subscribe registers a client, queuing the latest frame if there is one. The returned
+// function unregisters it.
+func (h *previewHub) subscribe() (<-chan []byte, func()) {
+	frames := make(chan []byte, 1)
+	h.mu.Lock()
+	h.clients[frames] = struct{}{}
+	if h.latest != nil {
+		frames <- h.latest
+	}
+	h.mu.Unlock()
+
+	return frames, func() {
+		h.mu.Lock()
+		delete(h.clients, frames)
+		h.mu.Unlock()
+	}
+}
+
+// wsHandler upgrades the connection and pushes every new frame as a binary message.
+func (h *previewHub) wsHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := previewUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	frames, unsubscribe := h.subscribe()
+	defer unsubscribe()
+
+	// The client never sends anything; reading only detects when it goes away
+	gone := make(chan struct{})
+	go func() {
+		defer close(gone)
+		for {
+			if _, _, err := conn.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case frame := <-frames:
+			if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+				return
+			}
+		case <-gone:
+			return
+		case <-h.done:
+			conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+			return
+		}
+	}
+}
+
+// routes serves the preview page at / and the frame stream at /ws.
+func (h *previewHub) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprint(w, previewPage)
+	})
+	mux.HandleFunc("/ws", h.wsHandler)
+	return mux
+}
+
+// startPreviewServer serves the hub's preview on addr in the background.
+// The listener is opened before returning so address errors are reported to the caller.
+func startPreviewServer(addr string, hub *previewHub) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := http.Serve(listener, hub.routes()); err != nil {
+			log.Printf("preview server stopped: %v", err)
+		}
+	}()
+	return listener, nil
+}
+
+// annotate returns a copy of img with text drawn on a dark strip along the bottom edge.
+func annotate(img image.Image, text string) image.Image {
+	const padding = 4.0
+	dc := gg.NewContextForImage(img)
+	width, height := float64(dc.Width()), float64(dc.Height())
+	_, textHeight := dc.MeasureString(text)
+
+	dc.SetRGBA(0, 0, 0, 0.6)
+	dc.DrawRectangle(0, height-textHeight-2*padding, width, textHeight+2*padding)
+	dc.Fill()
+	dc.SetRGB(1, 1, 1)
+	dc.DrawString(text, padding, height-padding)
+	return dc.Image()
+}
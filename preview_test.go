@@ -0,0 +1,129 @@
+package main
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/gorilla/websocket"
+)
+
+func TestAnnotate(t *testing.T) {
+	img := solidImage(120, 40, color.RGBA{255, 255, 255, 255})
+	out := annotate(img, "gen 100  fitness 12.34")
+
+	if out.Bounds() != img.Bounds() {
+		t.Fatalf("Annotated bounds %v, expected %v", out.Bounds(), img.Bounds())
+	}
+	if r, _, _, _ := out.At(2, 38).RGBA(); r == 0xffff {
+		t.Errorf("Expected the overlay strip to darken the bottom edge")
+	}
+	if r, _, _, _ := out.At(60, 2).RGBA(); r != 0xffff {
+		t.Errorf("Expected the top of the image to be untouched")
+	}
+	if img.RGBAAt(2, 38) != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("annotate modified the source image")
+	}
+}
+
+func TestPreviewHubDropsFramesForSlowClients(t *testing.T) {
+	hub := &previewHub{clients: make(map[chan []byte]struct{})}
+	frames, unsubscribe := hub.subscribe()
+	defer unsubscribe()
+
+	for _, frame := range []string{"a", "b", "c"} {
+		hub.broadcast([]byte(frame))
+	}
+	if got := string(<-frames); got != "c" {
+		t.Errorf("Expected only the latest frame, got %q", got)
+	}
+	select {
+	case frame := <-frames:
+		t.Errorf("Expected intermediate frames to be dropped, got %q", frame)
+	default:
+	}
+
+	// Late subscribers start from the latest frame
+	late, unsubscribeLate := hub.subscribe()
+	defer unsubscribeLate()
+	if got := string(<-late); got != "c" {
+		t.Errorf("Expected a new client to receive the latest frame, got %q", got)
+	}
+}
+
+func TestPreviewWebSocketReceivesFrames(t *testing.T) {
+	hub := newPreviewHub()
+	defer hub.close()
+	server := httptest.NewServer(hub.routes())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	in := make(chan genetic.ImageResult)
+	out := hub.tee(in)
+	go func() {
+		for range out {
+		}
+	}()
+
+	// Wait until the handler has subscribed before publishing
+	for i := 0; ; i++ {
+		hub.mu.Lock()
+		n := len(hub.clients)
+		hub.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		if i == 500 {
+			t.Fatal("Client never subscribed")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	in <- genetic.ImageResult{Img: solidImage(64, 32, color.RGBA{0, 128, 255, 255}), Generation: 7, Fitness: 3.5}
+	close(in)
+
+	kind, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("ReadMessage failed: %v", err)
+	}
+	if kind != websocket.BinaryMessage {
+		t.Errorf("Expected a binary frame, got message type %d", kind)
+	}
+	frame, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("Frame isn't a PNG: %v", err)
+	}
+	if frame.Bounds() != image.Rect(0, 0, 64, 32) {
+		t.Errorf("Frame bounds %v, expected 64x32", frame.Bounds())
+	}
+}
+
+func TestPreviewHubCloseDisconnectsClients(t *testing.T) {
+	hub := newPreviewHub()
+	server := httptest.NewServer(hub.routes())
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws", nil)
+	if err != nil {
+		t.Fatalf("Dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	hub.close()
+	hub.close()
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNormalClosure) {
+		t.Errorf("Expected the hub to close the connection, got %v", err)
+	}
+}
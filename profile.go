@@ -0,0 +1,68 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/pprof"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+)
+
+// startPprofServer serves the net/http/pprof handlers on addr in the background.
+// The listener is opened before returning so address errors are reported to the caller.
+func startPprofServer(addr string) (net.Listener, error) {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		if err := http.Serve(listener, nil); err != nil {
+			log.Printf("pprof server stopped: %v", err)
+		}
+	}()
+	return listener, nil
+}
+
+// startCPUProfile starts writing a CPU profile to path and returns a function that stops it.
+func startCPUProfile(path string) (func(), error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err := pprof.StartCPUProfile(file); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return func() {
+		pprof.StopCPUProfile()
+		file.Close()
+	}, nil
+}
+
+// writeMemProfile writes a heap profile to path after forcing a GC so it reflects live memory.
+func writeMemProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	runtime.GC()
+	return pprof.WriteHeapProfile(file)
+}
+
+// releaseAlgorithm drops the algorithm's population once its job is done. If the heap
+// still holds more than maxIdleMB MiB at that point, it also returns the freed memory to
+// the operating system, so a run over many frames doesn't sit on the peak of every
+// frame. It reports whether memory was returned; maxIdleMB of 0 never returns it.
+func releaseAlgorithm(algorithm *genetic.GeneticAlgorithm, maxIdleMB int) bool {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	free := maxIdleMB > 0 && stats.HeapInuse > uint64(maxIdleMB)<<20
+	algorithm.Release(free)
+	return free
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"image/color"
+	"net/http"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+	"github.com/bishal0602/chaotic-canvas/targets"
+)
+
+func TestPprofServerResponds(t *testing.T) {
+	listener, err := startPprofServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Failed to start pprof server: %v", err)
+	}
+	defer listener.Close()
+
+	resp, err := http.Get("http://" + listener.Addr().String() + "/debug/pprof/")
+	if err != nil {
+		t.Fatalf("Request to pprof server failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200 from /debug/pprof/, got %d", resp.StatusCode)
+	}
+}
+
+func TestReleaseAlgorithm(t *testing.T) {
+	ga, err := genetic.NewGeneticAlgorithm(targets.SolidTarget(8, 8, color.RGBA{A: 255}), 4, 1, 0.1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if releaseAlgorithm(ga, 0) {
+		t.Error("Expected no memory to be returned to the OS when disabled")
+	}
+	if ga.Population != nil {
+		t.Error("Expected the population to be released")
+	}
+	if releaseAlgorithm(ga, 1<<30) {
+		t.Error("Expected no memory to be returned below the limit")
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+)
+
+// snapshotWriter saves progress snapshots off the evolution goroutine so that a
+// slow disk can't stall Run.
+//
+// Snapshots are coalesced: the writer holds at most one pending result, and a
+// newer result replaces a pending one that hasn't been written yet. When the
+// disk keeps up every snapshot is saved; when it falls behind, intermediate
+// snapshots are dropped and only the latest pending one is written. The last
+// snapshot sent before recv is closed is always saved.
+type snapshotWriter struct {
+	save    func(genetic.ImageResult) error
+	onError func(genetic.ImageResult, error)
+
+	mu      sync.Mutex
+	pending *genetic.ImageResult
+	dropped int
+	closed  bool
+
+	wake chan struct{}
+	done chan struct{}
+}
+
+// newSnapshotWriter drains recv and calls save for each snapshot on a separate
+// goroutine. onError, if non-nil, is called when save fails.
+func newSnapshotWriter(recv <-chan genetic.ImageResult, save func(genetic.ImageResult) error, onError func(genetic.ImageResult, error)) *snapshotWriter {
+	w := &snapshotWriter{
+		save:    save,
+		onError: onError,
+		wake:    make(chan struct{}, 1),
+		done:    make(chan struct{}),
+	}
+	go w.drain(recv)
+	go w.loop()
+	return w
+}
+
+// Wait blocks until recv is closed and every pending snapshot is written. It
+// returns the number of snapshots that were dropped by coalescing.
+func (w *snapshotWriter) Wait() int {
+	<-w.done
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.dropped
+}
+
+// drain moves results from recv into the pending slot without ever blocking
+// on the writer.
+func (w *snapshotWriter) drain(recv <-chan genetic.ImageResult) {
+	for result := range recv {
+		w.mu.Lock()
+		if w.pending != nil {
+			w.dropped++
+		}
+		w.pending = &result
+		w.mu.Unlock()
+		w.signal()
+	}
+
+	w.mu.Lock()
+	w.closed = true
+	w.mu.Unlock()
+	w.signal()
+}
+
+func (w *snapshotWriter) signal() {
+	select {
+	case w.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *snapshotWriter) loop() {
+	defer close(w.done)
+	for range w.wake {
+		for {
+			w.mu.Lock()
+			result := w.pending
+			w.pending = nil
+			closed := w.closed
+			w.mu.Unlock()
+
+			if result == nil {
+				if closed {
+					return
+				}
+				break
+			}
+			if err := w.save(*result); err != nil && w.onError != nil {
+				w.onError(*result, err)
+			}
+		}
+	}
+}
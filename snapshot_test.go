@@ -0,0 +1,54 @@
+package main
+
+import (
+	"image"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+)
+
+func TestSnapshotWriterDoesNotBlockEvolution(t *testing.T) {
+	const (
+		generations = 40
+		saveDelay   = 20 * time.Millisecond
+	)
+
+	ga, err := genetic.NewGeneticAlgorithm(image.NewRGBA(image.Rect(0, 0, 10, 10)), 10, generations, 0.05, 3)
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+
+	var mu sync.Mutex
+	var saved []int
+	recv := make(chan genetic.ImageResult, snapshotBufferSize)
+	writer := newSnapshotWriter(recv, func(result genetic.ImageResult) error {
+		time.Sleep(saveDelay) // Artificially slow disk
+		mu.Lock()
+		saved = append(saved, result.Generation)
+		mu.Unlock()
+		return nil
+	}, nil)
+
+	start := time.Now()
+	if _, err := ga.Run(recv, 1); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	elapsed := time.Since(start)
+	dropped := writer.Wait()
+
+	// A blocking writer would need generations*saveDelay before Run could return
+	if elapsed >= generations*saveDelay/2 {
+		t.Errorf("Run took %v, evolution appears to be blocked by the writer", elapsed)
+	}
+	if dropped == 0 {
+		t.Errorf("Expected snapshots to be coalesced behind a slow writer")
+	}
+	if len(saved)+dropped != generations {
+		t.Errorf("Saved %d and dropped %d snapshots, expected %d in total", len(saved), dropped, generations)
+	}
+	if last := saved[len(saved)-1]; last != generations {
+		t.Errorf("Last saved snapshot is generation %d, expected final generation %d", last, generations)
+	}
+}
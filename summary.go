@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"time"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+	"github.com/bishal0602/chaotic-canvas/genetic"
+)
+
+// maxFitness is the fitness of an image against its exact opposite: the root of the
+// summed squared difference of four fully different 8-bit channels.
+var maxFitness = math.Sqrt(4 * 255 * 255)
+
+// runSummary describes a finished run, written as summary.json so that experiment runs
+// can be compared without parsing logs.
+type runSummary struct {
+	FinalFitness float64 `json:"final_fitness"`
+	// Similarity is 1 minus the saved result's plain pixel fitness against the target
+	// as a fraction of maxFitness, so 1 is identical whatever the fitness settings
+	Similarity     float64 `json:"similarity"`
+	GenerationsRun int     `json:"generations_run"`
+	GenerationCap  int     `json:"generation_cap"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	// GenPerSec is the mean throughput of the evolution itself, without snapshot I/O
+	GenPerSec                float64 `json:"generations_per_second"`
+	SlowestGeneration        int     `json:"slowest_generation"`
+	SlowestGenerationSeconds float64 `json:"slowest_generation_seconds"`
+	TargetWidth              int     `json:"target_width"`
+	TargetHeight             int     `json:"target_height"`
+	Seed                     int64   `json:"seed"`
+	PopulationSize           int     `json:"population_size"`
+	MutationRate             float64 `json:"mutation_rate"`
+	// Report is the -report-metric measured on the saved result, if one was set
+	Report *metricReport `json:"report,omitempty"`
+	// Parameters are the settings the run was started with. PopulationSize and
+	// MutationRate above differ from them when autotune chose its own.
+	Parameters *config.Config `json:"parameters"`
+}
+
+// metricReport is a quality metric measured separately from the fitness.
+type metricReport struct {
+	Metric string  `json:"metric"`
+	Value  float64 `json:"value"`
+}
+
+// similarity converts a plain pixel fitness into a similarity between 0 and 1.
+func similarity(fitness float64) float64 {
+	return 1 - fitness/maxFitness
+}
+
+// generationTiming returns the generations per second over the whole history and the
+// generation that took longest.
+func generationTiming(history []genetic.GenerationStats) (float64, genetic.GenerationStats) {
+	var total time.Duration
+	var slowest genetic.GenerationStats
+	for _, stats := range history {
+		total += stats.Duration
+		if stats.Duration > slowest.Duration {
+			slowest = stats
+		}
+	}
+	if total <= 0 {
+		return 0, slowest
+	}
+	return float64(len(history)) / total.Seconds(), slowest
+}
+
+// saveSummary writes summary as indented JSON.
+func saveSummary(path string, summary runSummary) error {
+	data, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
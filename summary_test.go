@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+)
+
+func TestRunWritesSummary(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.Summary = true
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.OutDir, "summary.json"))
+	if err != nil {
+		t.Fatalf("Expected a summary: %v", err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("Summary isn't valid JSON: %v", err)
+	}
+	for _, key := range []string{
+		"final_fitness", "similarity", "generations_run", "generation_cap",
+		"elapsed_seconds", "generations_per_second", "slowest_generation", "target_width", "target_height", "seed", "population_size",
+		"mutation_rate", "parameters",
+	} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("Summary is missing %q", key)
+		}
+	}
+
+	var summary runSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.GenerationsRun != cfg.Generations {
+		t.Errorf("Expected all %d generations to run, got %d", cfg.Generations, summary.GenerationsRun)
+	}
+	if summary.TargetWidth != 16 || summary.TargetHeight != 12 {
+		t.Errorf("Expected a 16x12 target, got %dx%d", summary.TargetWidth, summary.TargetHeight)
+	}
+	if summary.Similarity <= 0 || summary.Similarity > 1 {
+		t.Errorf("Expected a similarity in (0, 1], got %f", summary.Similarity)
+	}
+	if summary.Seed != cfg.Seed || summary.Parameters.PopulationSize != cfg.PopulationSize {
+		t.Errorf("Summary parameters don't match the config: seed %d, population %d", summary.Seed, summary.Parameters.PopulationSize)
+	}
+}
+
+func TestSummaryIncludesReportMetric(t *testing.T) {
+	cfg := tinyConfig(t)
+	cfg.Summary = true
+	cfg.ReportMetric = "deltae"
+	if err := run(cfg); err != nil {
+		t.Fatalf("run failed: %v", err)
+	}
+
+	data, err := os.ReadFile(filepath.Join(cfg.OutDir, "summary.json"))
+	if err != nil {
+		t.Fatalf("Expected a summary: %v", err)
+	}
+	var summary runSummary
+	if err := json.Unmarshal(data, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary.Report == nil || summary.Report.Metric != "deltae" || summary.Report.Value <= 0 {
+		t.Errorf("Expected a positive deltae report, got %+v", summary.Report)
+	}
+}
+
+func TestGenerationTiming(t *testing.T) {
+	history := []genetic.GenerationStats{
+		{Generation: 1, Duration: 100 * time.Millisecond},
+		{Generation: 2, Duration: 300 * time.Millisecond},
+		{Generation: 3, Duration: 100 * time.Millisecond},
+	}
+	genPerSec, slowest := generationTiming(history)
+	if math.Abs(genPerSec-6) > 1e-9 {
+		t.Errorf("Expected 3 generations in 0.5s to be 6 gen/s, got %f", genPerSec)
+	}
+	if slowest.Generation != 2 {
+		t.Errorf("Expected generation 2 to be the slowest, got %d", slowest.Generation)
+	}
+	if genPerSec, _ := generationTiming(nil); genPerSec != 0 {
+		t.Errorf("Expected no throughput without history, got %f", genPerSec)
+	}
+}
+
+func TestSimilarity(t *testing.T) {
+	if got := similarity(0); got != 1 {
+		t.Errorf("Expected identical images to have similarity 1, got %f", got)
+	}
+	if got := similarity(maxFitness); got != 0 {
+		t.Errorf("Expected opposite images to have similarity 0, got %f", got)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"image"
+	"log"
+	"math"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+)
+
+// prepareTarget applies the configured crop, compression and posterization to a target
+// frame. The crop is in the coordinates of the original image.
+func prepareTarget(cfg *config.Config, img image.Image) (image.Image, error) {
+	if cfg.Crop != "" {
+		rect, err := imageio.ParseRect(cfg.Crop)
+		if err != nil {
+			return nil, err
+		}
+		if img, err = imageio.Crop(img, rect); err != nil {
+			return nil, fmt.Errorf("error cropping target: %w", err)
+		}
+	}
+	if !cfg.NoCompress {
+		img = imageio.ResizeWithOptions(img, compressedImageDimension, imageio.ResizeOptions{GammaCorrect: cfg.GammaCorrectResize})
+	}
+	if cfg.Posterize > 0 {
+		img = imageio.Posterize(img, cfg.Posterize)
+	}
+	return img, nil
+}
+
+const (
+	// minTargetDimension is the smallest target width or height that leaves the
+	// polygons anything to resolve.
+	minTargetDimension = 8
+	// minTargetVariance is the summed channel variance below which a target is
+	// effectively one solid colour, which the background alone already matches.
+	minTargetVariance = 4.0
+)
+
+// checkTarget reports why a prepared target is trivially degenerate, if it is. It is
+// a guardrail against evolving towards an image with nothing in it, not a hard limit.
+func checkTarget(img image.Image) error {
+	size := img.Bounds().Size()
+	if size.X < minTargetDimension || size.Y < minTargetDimension {
+		return fmt.Errorf("target is only %dx%d pixels; at least %dx%d is recommended", size.X, size.Y, minTargetDimension, minTargetDimension)
+	}
+	stats := imageio.ImageStats(img)
+	if variance := stats.TotalVariance(); variance < minTargetVariance {
+		return fmt.Errorf("target is a near-solid colour (mean %.0f,%.0f,%.0f, variance %.2f); there is little for the polygons to evolve towards",
+			stats.Mean[0], stats.Mean[1], stats.Mean[2], variance)
+	}
+	return nil
+}
+
+// frozenRegion maps the freeze rectangle, given in the pixels of the original image
+// like the crop, onto a prepared target of preparedSize. Any part outside the crop is
+// dropped, and resizing rounds the region outwards so that it covers every pixel the
+// original region touches.
+func frozenRegion(cfg *config.Config, originalSize, preparedSize image.Point) (image.Rectangle, error) {
+	rect, err := imageio.ParseRect(cfg.Freeze)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+	region := image.Rectangle{Max: originalSize}
+	if cfg.Crop != "" {
+		crop, err := imageio.ParseRect(cfg.Crop)
+		if err != nil {
+			return image.Rectangle{}, err
+		}
+		rect, region = rect.Sub(crop.Min), image.Rectangle{Max: crop.Size()}
+	}
+	if rect = rect.Intersect(region); rect.Empty() {
+		return image.Rectangle{}, fmt.Errorf("freeze region %s lies outside the target", cfg.Freeze)
+	}
+
+	sx := float64(preparedSize.X) / float64(region.Dx())
+	sy := float64(preparedSize.Y) / float64(region.Dy())
+	return image.Rect(
+		int(math.Floor(float64(rect.Min.X)*sx)), int(math.Floor(float64(rect.Min.Y)*sy)),
+		int(math.Ceil(float64(rect.Max.X)*sx)), int(math.Ceil(float64(rect.Max.Y)*sy)),
+	), nil
+}
+
+// loadMatchingImage reads an image that must line up pixel for pixel with the prepared
+// target, such as a seed image. When the sizes differ it is resized to the target with a
+// warning if autoResize is set, and rejected with a descriptive error otherwise.
+func loadMatchingImage(kind, path string, target image.Image, autoResize bool) (image.Image, error) {
+	img, err := imageio.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s: %w", kind, err)
+	}
+	return matchTargetSize(kind, img, target, autoResize)
+}
+
+// resizeToMatch returns img resized to the dimensions of target, or img itself when
+// they already match.
+func resizeToMatch(img, target image.Image) image.Image {
+	size := target.Bounds().Size()
+	if img.Bounds().Size() == size {
+		return img
+	}
+	return imageio.ResizeTo(img, size.X, size.Y)
+}
+
+// matchTargetSize checks that img has the same dimensions as target; see loadMatchingImage.
+func matchTargetSize(kind string, img, target image.Image, autoResize bool) (image.Image, error) {
+	got, want := img.Bounds().Size(), target.Bounds().Size()
+	if got == want {
+		return img, nil
+	}
+	if !autoResize {
+		return nil, fmt.Errorf("%s is %dx%d but the target is %dx%d after resizing; provide one of the same size or use -auto-resize-inputs",
+			kind, got.X, got.Y, want.X, want.Y)
+	}
+	log.Printf("Warning: resizing %s from %dx%d to the target's %dx%d\n", kind, got.X, got.Y, want.X, want.Y)
+	return imageio.ResizeTo(img, want.X, want.Y), nil
+}
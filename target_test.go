@@ -0,0 +1,97 @@
+package main
+
+import (
+	"image"
+	"image/color"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/config"
+	"github.com/bishal0602/chaotic-canvas/imageio"
+	"github.com/bishal0602/chaotic-canvas/targets"
+)
+
+func TestLoadMatchingImageRejectsMismatchedSeed(t *testing.T) {
+	target := targets.SolidTarget(40, 30, color.RGBA{A: 255})
+	seedPath := filepath.Join(t.TempDir(), "seed.png")
+	if err := imageio.Save(seedPath, image.NewGray(image.Rect(0, 0, 20, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	_, err := loadMatchingImage("seed image", seedPath, target, false)
+	if err == nil {
+		t.Fatal("Expected an error for a seed image of the wrong size")
+	}
+	if !strings.Contains(err.Error(), "seed image is 20x20 but the target is 40x30") {
+		t.Errorf("Expected the error to name both sizes, got %q", err)
+	}
+	if !strings.Contains(err.Error(), "provide one of the same size") {
+		t.Errorf("Expected the error to suggest a fix, got %q", err)
+	}
+}
+
+func TestLoadMatchingImageAutoResizes(t *testing.T) {
+	target := targets.SolidTarget(40, 30, color.RGBA{A: 255})
+	seedPath := filepath.Join(t.TempDir(), "seed.png")
+	if err := imageio.Save(seedPath, image.NewGray(image.Rect(0, 0, 20, 20))); err != nil {
+		t.Fatal(err)
+	}
+
+	seed, err := loadMatchingImage("seed image", seedPath, target, true)
+	if err != nil {
+		t.Fatalf("Expected the seed image to be resized, got %v", err)
+	}
+	if seed.Bounds().Size() != target.Bounds().Size() {
+		t.Errorf("Expected a %v seed image, got %v", target.Bounds().Size(), seed.Bounds().Size())
+	}
+}
+
+func TestCheckTargetFlagsDegenerateImages(t *testing.T) {
+	solid := targets.SolidTarget(64, 64, color.RGBA{R: 30, G: 120, B: 200, A: 255})
+	if err := checkTarget(solid); err == nil || !strings.Contains(err.Error(), "near-solid colour") {
+		t.Errorf("Expected a solid target to be flagged, got %v", err)
+	}
+
+	tiny := targets.CheckerTarget(4, 4, 1, color.RGBA{A: 255}, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	if err := checkTarget(tiny); err == nil || !strings.Contains(err.Error(), "only 4x4 pixels") {
+		t.Errorf("Expected a tiny target to be flagged, got %v", err)
+	}
+}
+
+func TestCheckTargetAcceptsPhoto(t *testing.T) {
+	photo, err := imageio.Read(filepath.Join("examples", "starry_night.png"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := checkTarget(photo); err != nil {
+		t.Errorf("Expected a photo to pass, got %v", err)
+	}
+}
+
+func TestFrozenRegionFollowsCropAndResize(t *testing.T) {
+	cfg := &config.Config{Freeze: "30,20,40,20"}
+	got, err := frozenRegion(cfg, image.Pt(200, 100), image.Pt(100, 50))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := image.Rect(15, 10, 35, 20); got != want {
+		t.Errorf("Expected the region halved to %v, got %v", want, got)
+	}
+
+	// With a crop at (20,10) the region is measured from the crop's corner, then
+	// scaled from the 100x60 crop down to 50x30
+	cfg.Crop = "20,10,100,60"
+	got, err = frozenRegion(cfg, image.Pt(200, 100), image.Pt(50, 30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := image.Rect(5, 5, 25, 15); got != want {
+		t.Errorf("Expected %v within the crop, got %v", want, got)
+	}
+
+	cfg.Freeze = "150,0,10,10"
+	if _, err := frozenRegion(cfg, image.Pt(200, 100), image.Pt(50, 30)); err == nil {
+		t.Error("Expected an error for a region outside the crop")
+	}
+}
@@ -0,0 +1,61 @@
+// Package targets generates synthetic target images, so an image can be evolved
+// toward a procedural pattern without writing it to a file first.
+package targets
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// SolidTarget returns a width x height image filled with c.
+func SolidTarget(width, height int, c color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{c}, image.Point{}, draw.Src)
+	return img
+}
+
+// GradientTarget returns a width x height image that fades horizontally from
+// the from color on the left edge to the to color on the right edge.
+func GradientTarget(width, height int, from, to color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		t := 0.0
+		if width > 1 {
+			t = float64(x) / float64(width-1)
+		}
+		c := color.RGBA{
+			R: lerp(from.R, to.R, t),
+			G: lerp(from.G, to.G, t),
+			B: lerp(from.B, to.B, t),
+			A: lerp(from.A, to.A, t),
+		}
+		for y := 0; y < height; y++ {
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// CheckerTarget returns a width x height checkerboard of size x size squares
+// alternating between a and b, starting with a in the top-left corner.
+func CheckerTarget(width, height, size int, a, b color.RGBA) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if size < 1 {
+		size = 1
+	}
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			if (x/size+y/size)%2 == 0 {
+				img.SetRGBA(x, y, a)
+			} else {
+				img.SetRGBA(x, y, b)
+			}
+		}
+	}
+	return img
+}
+
+func lerp(a, b uint8, t float64) uint8 {
+	return uint8(float64(a) + (float64(b)-float64(a))*t + 0.5)
+}
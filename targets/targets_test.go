@@ -0,0 +1,83 @@
+package targets
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/bishal0602/chaotic-canvas/genetic"
+)
+
+func TestSolidTarget(t *testing.T) {
+	c := color.RGBA{10, 20, 30, 255}
+	img := SolidTarget(5, 3, c)
+	if img.Bounds().Dx() != 5 || img.Bounds().Dy() != 3 {
+		t.Fatalf("Unexpected bounds %v", img.Bounds())
+	}
+	for y := 0; y < 3; y++ {
+		for x := 0; x < 5; x++ {
+			if got := img.RGBAAt(x, y); got != c {
+				t.Fatalf("Pixel (%d,%d) = %v, want %v", x, y, got, c)
+			}
+		}
+	}
+}
+
+func TestGradientTargetEndpoints(t *testing.T) {
+	from := color.RGBA{0, 0, 0, 255}
+	to := color.RGBA{255, 100, 50, 255}
+	img := GradientTarget(11, 4, from, to)
+
+	if got := img.RGBAAt(0, 2); got != from {
+		t.Errorf("Left edge = %v, want %v", got, from)
+	}
+	if got := img.RGBAAt(10, 2); got != to {
+		t.Errorf("Right edge = %v, want %v", got, to)
+	}
+	for x := 1; x < 11; x++ {
+		if img.RGBAAt(x, 0).R < img.RGBAAt(x-1, 0).R {
+			t.Errorf("Gradient isn't monotonic at x=%d", x)
+		}
+	}
+}
+
+func TestCheckerTarget(t *testing.T) {
+	a := color.RGBA{255, 255, 255, 255}
+	b := color.RGBA{0, 0, 0, 255}
+	img := CheckerTarget(8, 8, 2, a, b)
+
+	cases := []struct {
+		x, y int
+		want color.RGBA
+	}{
+		{0, 0, a}, {1, 1, a}, {2, 0, b}, {0, 2, b}, {2, 2, a}, {7, 7, a},
+	}
+	for _, tc := range cases {
+		if got := img.RGBAAt(tc.x, tc.y); got != tc.want {
+			t.Errorf("Pixel (%d,%d) = %v, want %v", tc.x, tc.y, got, tc.want)
+		}
+	}
+}
+
+func TestEvolveTowardSolidTargetConverges(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping evolution test in short mode")
+	}
+	target := SolidTarget(16, 16, color.RGBA{40, 120, 200, 255})
+
+	// Random backgrounds and polygons start far from the target's single color; locking
+	// polygon colors to the target's palette leaves only coverage to evolve
+	ga, err := genetic.NewGeneticAlgorithm(target, 20, 100, 0.1, 3, genetic.WithSeed(1), genetic.WithPaletteImage(target))
+	if err != nil {
+		t.Fatalf("Failed to create GA: %v", err)
+	}
+	if initial := ga.Population[0].Fitness; initial < 10 {
+		t.Fatalf("Expected the random initial population to be far from the target, got fitness %f", initial)
+	}
+	best, err := ga.Run(make(chan genetic.ImageResult, ga.Generations), 1)
+	if err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+	if best.Fitness > 1 {
+		t.Errorf("Expected the fitness to converge to near zero, got %f", best.Fitness)
+	}
+}